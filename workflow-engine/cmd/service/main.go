@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	service "github.com/prashantsinghb/workflow-engine/api/service"
+	"github.com/prashantsinghb/workflow-engine/pkg/config"
+	"github.com/prashantsinghb/workflow-engine/pkg/execution/postgres"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/secrets"
+	"github.com/prashantsinghb/workflow-engine/pkg/server"
+	"github.com/prashantsinghb/workflow-engine/pkg/webhook"
+	wfexecution "github.com/prashantsinghb/workflow-engine/pkg/workflow/execution"
+	wfregistry "github.com/prashantsinghb/workflow-engine/pkg/workflow/registry"
+)
+
+const (
+	GrpcAddr = ":50051"
+	HttpAddr = ":8081"
+)
+
+func main() {
+	cfg := config.Load()
+
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// ---- STORES ----
+	store := postgres.New(db)
+	workflowStore := wfregistry.NewPostgresWorkflowStore(db)
+	moduleRegistry := registry.NewModuleRegistry(registry.NewPostgresRegistry(db))
+
+	var secretStore secrets.Store
+	keys, err := secrets.NewKeyProvider(cfg)
+	if err != nil {
+		log.Printf("secrets disabled: %v\n", err)
+	} else {
+		secretStore = secrets.NewPostgresStore(db, keys)
+	}
+
+	// ---- EXECUTION BACKEND ----
+	// The API runs a dispatcher next to the workers' ones, so executions it
+	// enqueues are started right away on Notify rather than at the next
+	// poll.
+	backend, err := wfexecution.NewBackend(cfg, db, store.Executions())
+	if err != nil {
+		log.Fatal(err)
+	}
+	dispatcher := wfexecution.NewDispatcher(store, backend)
+	go func() { _ = dispatcher.Run(ctx) }()
+
+	// ---- SERVERS ----
+	workflows := server.NewWorkflowService(store, workflowStore, moduleRegistry, backend, dispatcher)
+	webhooks := server.NewWebhookServer(webhook.NewPostgresStore(db), secretStore, workflows, cfg.PublicURL)
+	timeline := server.NewExecutionTimelineServer(store)
+
+	grpcServer := grpc.NewServer()
+	service.RegisterWorkflowServiceServer(grpcServer, workflows)
+	service.RegisterModuleServiceServer(grpcServer, &server.ModuleServer{Registry: *moduleRegistry})
+	service.RegisterScheduleServiceServer(grpcServer, server.NewScheduleServer(workflowStore, cfg.Engine))
+	service.RegisterWebhookServiceServer(grpcServer, webhooks)
+	if secretStore != nil {
+		service.RegisterSecretServiceServer(grpcServer, server.NewSecretServer(secretStore))
+	}
+
+	lis, err := net.Listen("tcp", GrpcAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		log.Printf("gRPC server listening on %s\n", GrpcAddr)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	// ---- REST GATEWAY ----
+	gateway := runtime.NewServeMux()
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	handlers := []func(context.Context, *runtime.ServeMux, string, []grpc.DialOption) error{
+		service.RegisterWorkflowServiceHandlerFromEndpoint,
+		service.RegisterModuleServiceHandlerFromEndpoint,
+		service.RegisterScheduleServiceHandlerFromEndpoint,
+		service.RegisterWebhookServiceHandlerFromEndpoint,
+	}
+	if secretStore != nil {
+		handlers = append(handlers, service.RegisterSecretServiceHandlerFromEndpoint)
+	}
+	for _, register := range handlers {
+		if err := register(ctx, gateway, "localhost"+GrpcAddr, opts); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Get("/v1/projects/{projectId}/executions/{executionId}/timeline", timeline.GetExecutionTimeline)
+	r.Post("/v1/hooks/{webhookId}", webhooks.ReceiveWebhook)
+	r.Handle("/swagger/*", http.StripPrefix("/swagger/", http.FileServer(http.Dir("api/service/swagger"))))
+	r.Mount("/", gateway)
+
+	httpServer := &http.Server{Addr: HttpAddr, Handler: r}
+	go func() {
+		<-ctx.Done()
+		_ = httpServer.Shutdown(context.Background())
+		grpcServer.GracefulStop()
+	}()
+
+	log.Printf("REST gateway listening on %s\n", HttpAddr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
@@ -3,8 +3,14 @@ package main
 import (
 	"context"
 	"database/sql"
+	"expvar"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -14,8 +20,12 @@ import (
 	"github.com/prashantsinghb/workflow-engine/pkg/config"
 	"github.com/prashantsinghb/workflow-engine/pkg/execution/postgres"
 	"github.com/prashantsinghb/workflow-engine/pkg/module/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/secrets"
+	wfexecution "github.com/prashantsinghb/workflow-engine/pkg/workflow/execution"
 	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor/container"
 	wfregistry "github.com/prashantsinghb/workflow-engine/pkg/workflow/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
 	"github.com/prashantsinghb/workflow-engine/pkg/workflow/temporal"
 )
 
@@ -35,8 +45,8 @@ func main() {
 	// ---- STORES ----
 	store := postgres.New(db)
 	executionStore := store.Executions()
-	// nodeStore := store.Nodes()
-	// eventStore := store.Events()
+	nodeStore := store.Nodes()
+	eventStore := store.Events()
 	workflowStore := wfregistry.NewPostgresWorkflowStore(db)
 
 	// ---- REGISTRIES ----
@@ -44,14 +54,50 @@ func main() {
 	moduleRegistry := registry.NewModuleRegistry(modulePg)
 
 	// ---- TEMPORAL GLOBALS ----
-	temporal.SetExecutionStore(executionStore)
-	temporal.SetWorkflowStore(workflowStore)
-	temporal.SetModuleRegistry(moduleRegistry)
+	runner.SetExecutionStore(executionStore)
+	runner.SetNodeStore(nodeStore)
+	runner.SetEventStore(eventStore)
+	runner.SetWorkflowStore(workflowStore)
+	runner.SetModuleRegistry(moduleRegistry)
+
+	keys, err := secrets.NewKeyProvider(cfg)
+	if err != nil {
+		log.Printf("secrets disabled: %v\n", err)
+	} else {
+		runner.SetSecretStore(secrets.NewPostgresStore(db, keys))
+	}
 
 	// ---- EXECUTORS ----
 	executor.Register("http", executor.NewHttpExecutor(moduleRegistry))
+	executor.Register("grpc", executor.NewGrpcExecutor(moduleRegistry))
 	executor.Register("noop", &executor.NoopExecutor{})
 	executor.Register("go", &executor.FuncExecutor{})
+	executor.Register("exec", executor.NewExecExecutor(moduleRegistry))
+
+	runtime, err := containerRuntime(cfg)
+	if err != nil {
+		log.Printf("container executor disabled: %v\n", err)
+	} else {
+		executor.Register("docker", executor.NewContainerExecutor(moduleRegistry, runtime))
+	}
+
+	if cfg.MetricsAddr != "" {
+		go serveMetrics(cfg.MetricsAddr)
+	}
+
+	// ---- EXECUTION BACKEND ----
+	backend, err := wfexecution.NewBackend(cfg, db, executionStore)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dispatcher := wfexecution.NewDispatcher(store, backend)
+
+	if engine, ok := backend.(*wfexecution.EmbeddedEngine); ok {
+		runEmbeddedEngine(engine, dispatcher)
+		return
+	}
+	go func() { _ = dispatcher.Run(context.Background()) }()
+	go func() { _ = wfexecution.NewReconciler(store, backend).Run(context.Background()) }()
 
 	seen := map[string]bool{}
 	var mu sync.Mutex
@@ -76,6 +122,37 @@ func main() {
 	}
 }
 
+// runEmbeddedEngine runs executions in-process instead of polling Temporal,
+// and starts the enqueued ones. On SIGINT or SIGTERM running nodes are
+// cancelled and their leases released, so another worker picks them up.
+func runEmbeddedEngine(engine *wfexecution.EmbeddedEngine, dispatcher *wfexecution.Dispatcher) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() { _ = dispatcher.Run(ctx) }()
+
+	log.Println("running embedded execution engine")
+	_ = engine.Run(ctx)
+}
+
+// serveMetrics exposes expvar, including the dispatcher's outbox lag.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	log.Printf("serving metrics on %s/debug/vars\n", addr)
+	log.Println(http.ListenAndServe(addr, mux))
+}
+
+func containerRuntime(cfg config.Config) (container.Runtime, error) {
+	switch cfg.ContainerRuntime {
+	case "docker":
+		return container.NewDockerRuntime(cfg.DockerHost)
+	case "kubernetes":
+		return container.NewInClusterKubernetesRuntime(cfg.KubernetesNamespace)
+	}
+	return nil, fmt.Errorf("unknown container runtime %q", cfg.ContainerRuntime)
+}
+
 func listNamespaces() ([]string, error) {
 	c, err := client.Dial(client.Options{HostPort: TemporalAddr})
 	if err != nil {
@@ -17,7 +17,7 @@ CREATE TABLE module_http_specs (
   -- Retry policy
   retry_count INTEGER NOT NULL DEFAULT 3,
   retry_backoff_ms INTEGER NOT NULL DEFAULT 500,
-  retry_on_status JSONB DEFAULT '[500,502,503,504]'::jsonb,
+  retry_on_status JSONB DEFAULT '[429,500,502,503,504]'::jsonb,
 
   -- Auth
   auth_type TEXT NOT NULL DEFAULT 'none'
@@ -0,0 +1,4 @@
+ALTER TABLE executions
+  ADD COLUMN retry_of UUID REFERENCES executions(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_exec_retry_of ON executions(retry_of);
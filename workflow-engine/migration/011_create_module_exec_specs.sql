@@ -0,0 +1,12 @@
+CREATE TABLE module_exec_specs (
+  module_id UUID PRIMARY KEY REFERENCES modules(id) ON DELETE CASCADE,
+
+  command TEXT,
+  script TEXT,
+  args TEXT[],
+  env JSONB,
+
+  working_dir TEXT,
+  timeout_ms INT,
+  output_format TEXT
+);
@@ -0,0 +1,21 @@
+CREATE TABLE module_grpc_specs (
+  module_id UUID PRIMARY KEY
+    REFERENCES modules(id) ON DELETE CASCADE,
+
+  -- Target
+  target TEXT NOT NULL,
+  method TEXT NOT NULL,
+  tls JSONB,
+
+  -- Request
+  request_template JSONB DEFAULT '{}'::jsonb,
+  metadata JSONB DEFAULT '{}'::jsonb,
+  timeout_ms INTEGER NOT NULL DEFAULT 30000,
+
+  -- Serialized google.protobuf.FileDescriptorSet; when NULL the method is
+  -- resolved through server reflection
+  descriptor_set BYTEA,
+
+  created_at TIMESTAMPTZ DEFAULT now(),
+  updated_at TIMESTAMPTZ DEFAULT now()
+);
@@ -0,0 +1,6 @@
+ALTER TABLE module_http_specs
+  DROP CONSTRAINT module_http_specs_auth_type_check;
+
+ALTER TABLE module_http_specs
+  ADD CONSTRAINT module_http_specs_auth_type_check
+    CHECK (auth_type IN ('none','bearer','basic','api_key','oauth2','hmac'));
@@ -0,0 +1,15 @@
+CREATE TABLE secrets (
+  project_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+
+  -- nonce || AES-256-GCM ciphertext of the value under a per-secret data
+  -- key; the data key is stored wrapped by the key provider's key_id
+  ciphertext BYTEA NOT NULL,
+  wrapped_key BYTEA NOT NULL,
+  key_id TEXT NOT NULL,
+
+  created_at TIMESTAMPTZ DEFAULT now(),
+  updated_at TIMESTAMPTZ DEFAULT now(),
+
+  PRIMARY KEY (project_id, name)
+);
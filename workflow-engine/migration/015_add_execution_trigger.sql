@@ -0,0 +1,5 @@
+ALTER TABLE executions
+  ADD COLUMN trigger_type TEXT NOT NULL DEFAULT 'manual',
+  ADD COLUMN schedule_id TEXT;
+
+CREATE INDEX idx_exec_schedule ON executions(project_id, schedule_id);
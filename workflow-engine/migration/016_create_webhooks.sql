@@ -0,0 +1,25 @@
+CREATE TABLE webhooks (
+  id UUID PRIMARY KEY,
+  project_id TEXT NOT NULL,
+  workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+
+  -- project secret holding the HMAC signing key
+  secret_name TEXT NOT NULL,
+  signature_header TEXT NOT NULL,
+  signature_algorithm TEXT NOT NULL,
+  signature_prefix TEXT NOT NULL DEFAULT '',
+
+  input_mapping JSONB NOT NULL DEFAULT '{}',
+  dedup_header TEXT NOT NULL DEFAULT '',
+  disabled BOOLEAN NOT NULL DEFAULT false,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  UNIQUE (project_id, name)
+);
+
+CREATE INDEX idx_webhooks_workflow ON webhooks(project_id, workflow_id);
+
+ALTER TABLE executions ADD COLUMN webhook_id TEXT;
@@ -0,0 +1,11 @@
+ALTER TABLE workflows
+  ADD COLUMN deprecated BOOLEAN NOT NULL DEFAULT false,
+  ADD COLUMN deprecation_message TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_workflows_name ON workflows(project_id, name, created_at DESC);
+
+-- Each execution keeps the definition it started with, so in-flight and
+-- retried runs never see a different one.
+ALTER TABLE executions
+  ADD COLUMN workflow_version TEXT,
+  ADD COLUMN workflow_yaml TEXT;
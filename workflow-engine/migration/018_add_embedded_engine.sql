@@ -0,0 +1,19 @@
+-- engine is "temporal" or "embedded". The embedded engine keeps a run's
+-- state in these tables only: node_inputs holds the `with` overrides of a
+-- retried run, cancel_requested_at a cancellation it has yet to observe.
+ALTER TABLE executions
+  ADD COLUMN engine TEXT NOT NULL DEFAULT 'temporal',
+  ADD COLUMN node_inputs JSONB,
+  ADD COLUMN cancel_requested_at TIMESTAMPTZ;
+
+CREATE INDEX idx_exec_engine_state ON executions(engine, state);
+
+-- A node of an embedded run is leased to the worker running it. A node
+-- whose lease expired, because its worker died, is run again elsewhere;
+-- available_at delays a retry by its backoff.
+ALTER TABLE execution_nodes
+  ADD COLUMN lease_owner TEXT,
+  ADD COLUMN lease_expires_at TIMESTAMPTZ,
+  ADD COLUMN available_at TIMESTAMPTZ;
+
+CREATE INDEX idx_exec_nodes_lease ON execution_nodes(status, lease_expires_at);
@@ -0,0 +1,14 @@
+-- Executions waiting to be started on their backend. An entry is written
+-- in the same transaction as its execution, so the start survives the API
+-- process dying before it called the backend. Dispatchers lease entries
+-- through claimed_until and set dispatched_at once the backend has the run.
+CREATE TABLE execution_outbox (
+  execution_id UUID PRIMARY KEY REFERENCES executions(id) ON DELETE CASCADE,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  claimed_until TIMESTAMPTZ,
+  dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_undispatched ON execution_outbox(created_at)
+  WHERE dispatched_at IS NULL;
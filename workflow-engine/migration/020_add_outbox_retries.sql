@@ -0,0 +1,8 @@
+-- A failed start is retried with backoff: attempts counts the failed
+-- starts, next_attempt_at holds the entry back until the backoff passed
+-- and last_error keeps the latest failure. Once the attempts run out the
+-- execution is marked FAILED and the entry dispatched.
+ALTER TABLE execution_outbox
+  ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+  ADD COLUMN next_attempt_at TIMESTAMPTZ,
+  ADD COLUMN last_error TEXT;
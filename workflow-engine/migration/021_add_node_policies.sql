@@ -0,0 +1,4 @@
+-- defaults holds a module's node timeout and retry policy, e.g.
+-- {"timeout": "5m", "retry": {"max_attempts": 3}}; nodes using the module
+-- inherit the fields they do not set.
+ALTER TABLE modules ADD COLUMN defaults JSONB;
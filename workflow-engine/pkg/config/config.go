@@ -3,10 +3,41 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 )
 
 type Config struct {
 	DatabaseURL string
+
+	// ContainerRuntime selects where docker modules run: "docker" (the
+	// default) or "kubernetes"
+	ContainerRuntime string
+	// DockerHost is the Docker-compatible socket for the docker runtime
+	DockerHost string
+	// KubernetesNamespace receives module Jobs; empty means the worker's own
+	KubernetesNamespace string
+
+	// SecretsKeyProvider wraps the keys secrets are encrypted with; only
+	// "file" is built in
+	SecretsKeyProvider string
+	// SecretsKeyFile holds the file provider's key; empty disables secrets
+	SecretsKeyFile string
+	// SecretsKeyGenerate lets the file provider create a missing key file;
+	// only meant for local development
+	SecretsKeyGenerate bool
+
+	// PublicURL is where senders reach the API; it prefixes webhook URLs
+	PublicURL string
+
+	// Engine runs executions: "temporal" (the default) or "embedded",
+	// which runs them in-process without a Temporal cluster
+	Engine string
+	// EngineWorkers caps the nodes an embedded engine runs at once
+	EngineWorkers int
+
+	// MetricsAddr is where the worker serves expvar metrics on
+	// /debug/vars; empty disables them
+	MetricsAddr string
 }
 
 func Load() Config {
@@ -18,7 +49,37 @@ func Load() Config {
 		log.Fatal("DATABASE_URL is not set")
 	}
 
+	runtime := os.Getenv("CONTAINER_RUNTIME")
+	if runtime == "" {
+		runtime = "docker"
+	}
+
+	keyProvider := os.Getenv("SECRETS_KEY_PROVIDER")
+	if keyProvider == "" {
+		keyProvider = "file"
+	}
+
+	engine := os.Getenv("ENGINE")
+	if engine == "" {
+		engine = "temporal"
+	}
+
+	generateKey, _ := strconv.ParseBool(os.Getenv("SECRETS_KEY_GENERATE"))
+
+	// zero selects the engine's default
+	workers, _ := strconv.Atoi(os.Getenv("ENGINE_WORKERS"))
+
 	return Config{
-		DatabaseURL: dbURL,
+		DatabaseURL:         dbURL,
+		ContainerRuntime:    runtime,
+		DockerHost:          os.Getenv("DOCKER_HOST"),
+		KubernetesNamespace: os.Getenv("KUBERNETES_NAMESPACE"),
+		SecretsKeyProvider:  keyProvider,
+		SecretsKeyFile:      os.Getenv("SECRETS_KEY_FILE"),
+		SecretsKeyGenerate:  generateKey,
+		PublicURL:           os.Getenv("PUBLIC_URL"),
+		Engine:              engine,
+		EngineWorkers:       workers,
+		MetricsAddr:         os.Getenv("METRICS_ADDR"),
 	}
 }
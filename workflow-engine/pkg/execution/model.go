@@ -28,19 +28,57 @@ const (
 	NodeSkipped   NodeStatus = "SKIPPED"
 )
 
+// Event types appended to execution_events.
+const (
+	EventExecutionStarted   = "EXECUTION_STARTED"
+	EventNodeStarted        = "NODE_STARTED"
+	EventNodeRetry          = "NODE_RETRY"
+	EventNodeSucceeded      = "NODE_SUCCEEDED"
+	EventNodeFailed         = "NODE_FAILED"
+	EventNodeSkipped        = "NODE_SKIPPED"
+	EventNodeLog            = "NODE_LOG"
+	EventExecutionPaused    = "EXECUTION_PAUSED"
+	EventExecutionResumed   = "EXECUTION_RESUMED"
+	EventExecutionCancelled = "EXECUTION_CANCELLED"
+	// The reconciler corrected a row to match the backend
+	EventExecutionReconciled = "EXECUTION_RECONCILED"
+	EventNodeReconciled      = "NODE_RECONCILED"
+)
+
+// Trigger types recorded on an execution.
+const (
+	TriggerManual   = "manual"
+	TriggerSchedule = "schedule"
+	TriggerWebhook  = "webhook"
+)
+
 type Execution struct {
 	ID uuid.UUID
 
 	ProjectID  string
 	WorkflowID string
-	Version    int
+	// Version is the workflow version the execution runs
+	Version string
+	// Definition is the workflow YAML the execution started with. It is
+	// only written by Create; read it with ExecutionStore.GetDefinition.
+	Definition string
 
 	ClientRequestID string
 	TriggerType     string
+	// ScheduleID is set for runs started by a schedule
+	ScheduleID string
+	// WebhookID is set for runs started by a webhook
+	WebhookID string
 
 	TemporalWorkflowID string
 	TemporalRunID      string
 
+	// RetryOf links a retried run to the execution it was retried from
+	RetryOf *uuid.UUID
+	// NodeInputs holds the `with` overrides of a retried run. It is only
+	// written by Enqueue.
+	NodeInputs map[string]map[string]any
+
 	Status ExecutionStatus
 
 	Inputs  map[string]any
@@ -88,6 +126,17 @@ type ExecutionEvent struct {
 	CreatedAt time.Time
 }
 
+// OutboxEntry is an execution waiting to be started.
+type OutboxEntry struct {
+	ExecutionID uuid.UUID
+	ProjectID   string
+	// NodeInputs holds the `with` overrides of a retried run
+	NodeInputs map[string]map[string]any
+	// Attempts counts the starts that failed so far
+	Attempts  int
+	CreatedAt time.Time
+}
+
 type ExecutionStats struct {
 	TotalExecutions   int64
 	RunningExecutions int64
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/prashantsinghb/workflow-engine/pkg/execution"
@@ -13,36 +14,155 @@ type executionStore struct {
 	db *sql.DB
 }
 
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
 func (s *executionStore) Create(ctx context.Context, e *execution.Execution) error {
+	return insertExecution(ctx, s.db, e)
+}
+
+func (s *executionStore) Enqueue(ctx context.Context, e *execution.Execution) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertExecution(ctx, tx, e); err != nil {
+		return err
+	}
+	if e.RetryOf != nil {
+		if err := copySucceededNodes(ctx, tx, *e.RetryOf, e.ID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO execution_outbox (execution_id) VALUES ($1)
+	`, e.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertExecution(ctx context.Context, db execer, e *execution.Execution) error {
 	if e.ID == uuid.Nil {
 		e.ID = uuid.New()
 	}
 
 	inputs, _ := json.Marshal(e.Inputs)
 
-	_, err := s.db.ExecContext(ctx, `
+	var nodeInputs []byte
+	if e.NodeInputs != nil {
+		nodeInputs, _ = json.Marshal(e.NodeInputs)
+	}
+
+	if e.TriggerType == "" {
+		e.TriggerType = execution.TriggerManual
+	}
+
+	res, err := db.ExecContext(ctx, `
 		INSERT INTO executions (
 			id,
 			project_id,
 			workflow_id,
+			workflow_version,
+			workflow_yaml,
 			client_request_id,
+			trigger_type,
+			schedule_id,
+			webhook_id,
 			temporal_workflow_id,
+			retry_of,
 			state,
-			inputs
+			inputs,
+			node_inputs
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		VALUES ($1,$2,$3,NULLIF($4, ''),NULLIF($5, ''),$6,$7,NULLIF($8, ''),NULLIF($9, ''),$10,$11,$12,$13,$14)
 		ON CONFLICT (project_id, workflow_id, client_request_id)
 		DO NOTHING
 	`,
 		e.ID,
 		e.ProjectID,
 		e.WorkflowID,
+		e.Version,
+		e.Definition,
 		e.ClientRequestID,
+		e.TriggerType,
+		e.ScheduleID,
+		e.WebhookID,
 		e.TemporalWorkflowID,
+		e.RetryOf,
 		execution.ExecutionPending,
 		inputs,
+		nodeInputs,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return execution.ErrDuplicate
+	}
+	return nil
+}
+
+// copySucceededNodes carries the original run's succeeded nodes over, so
+// the retry skips them and its timeline is complete.
+func copySucceededNodes(ctx context.Context, tx *sql.Tx, from, to uuid.UUID) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT node_id, executor_type, attempt, max_attempts, input, output
+		FROM execution_nodes
+		WHERE execution_id = $1 AND status = $2
+	`, from, execution.NodeSucceeded)
+	if err != nil {
+		return err
+	}
+
+	type copied struct {
+		nodeID, executorType string
+		attempt, maxAttempts int
+		input, output        []byte
+	}
+	var nodes []copied
+	for rows.Next() {
+		var n copied
+		if err := rows.Scan(&n.nodeID, &n.executorType, &n.attempt, &n.maxAttempts, &n.input, &n.output); err != nil {
+			rows.Close()
+			return err
+		}
+		nodes = append(nodes, n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO execution_nodes (
+				id, execution_id, node_id,
+				executor_type, status,
+				attempt, max_attempts,
+				input, output
+			)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		`,
+			uuid.New(),
+			to,
+			n.nodeID,
+			n.executorType,
+			execution.NodeSucceeded,
+			n.attempt,
+			n.maxAttempts,
+			n.input,
+			n.output,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *executionStore) Get(
@@ -53,9 +173,10 @@ func (s *executionStore) Get(
 
 	row := s.db.QueryRowContext(ctx, `
 		SELECT
-			id, project_id, workflow_id,
-			client_request_id,
+			id, project_id, workflow_id, COALESCE(workflow_version, ''),
+			client_request_id, trigger_type, schedule_id, webhook_id,
 			temporal_workflow_id, temporal_run_id,
+			retry_of,
 			state, error,
 			inputs, outputs,
 			started_at, completed_at,
@@ -67,6 +188,20 @@ func (s *executionStore) Get(
 	return scanExecution(row)
 }
 
+// GetDefinition returns the workflow YAML the execution started with; it
+// is empty for executions created before definitions were recorded.
+func (s *executionStore) GetDefinition(
+	ctx context.Context,
+	executionID uuid.UUID,
+) (string, error) {
+
+	var yaml sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT workflow_yaml FROM executions WHERE id = $1
+	`, executionID).Scan(&yaml)
+	return yaml.String, err
+}
+
 func (s *executionStore) GetByIdempotencyKey(
 	ctx context.Context,
 	projectID, workflowID, clientRequestID string,
@@ -74,9 +209,10 @@ func (s *executionStore) GetByIdempotencyKey(
 
 	row := s.db.QueryRowContext(ctx, `
 		SELECT
-			id, project_id, workflow_id,
-			client_request_id,
+			id, project_id, workflow_id, COALESCE(workflow_version, ''),
+			client_request_id, trigger_type, schedule_id, webhook_id,
 			temporal_workflow_id, temporal_run_id,
+			retry_of,
 			state, error,
 			inputs, outputs,
 			started_at, completed_at,
@@ -94,19 +230,41 @@ func (s *executionStore) MarkRunning(
 	runID string,
 ) error {
 
-	_, err := s.db.ExecContext(ctx, `
+	// a fast run may have finished, or the execution been cancelled,
+	// before its start is recorded
+	res, err := s.db.ExecContext(ctx, `
 		UPDATE executions
 		SET
 			state = $2,
 			temporal_run_id = $3,
 			started_at = now(),
 			updated_at = now()
-		WHERE id = $1
+		WHERE id = $1 AND state = $4
 	`,
 		executionID,
 		execution.ExecutionRunning,
 		runID,
+		execution.ExecutionPending,
 	)
+	if err != nil {
+		return err
+	}
+	return checkTransition(res, executionID, execution.ExecutionPending)
+}
+
+func (s *executionStore) UpdateRunID(
+	ctx context.Context,
+	executionID uuid.UUID,
+	runID string,
+) error {
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE executions
+		SET
+			temporal_run_id = $2,
+			updated_at = now()
+		WHERE id = $1
+	`, executionID, runID)
 	return err
 }
 
@@ -118,20 +276,26 @@ func (s *executionStore) MarkCompleted(
 
 	out, _ := json.Marshal(outputs)
 
-	_, err := s.db.ExecContext(ctx, `
+	res, err := s.db.ExecContext(ctx, `
 		UPDATE executions
 		SET
 			state = $2,
 			outputs = $3,
 			completed_at = now(),
 			updated_at = now()
-		WHERE id = $1
+		WHERE id = $1 AND state NOT IN ($4, $5, $6)
 	`,
 		executionID,
 		execution.ExecutionSucceeded,
 		out,
+		execution.ExecutionSucceeded,
+		execution.ExecutionFailed,
+		execution.ExecutionCancelled,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return checkFinish(res, executionID)
 }
 
 func (s *executionStore) MarkFailed(
@@ -142,20 +306,166 @@ func (s *executionStore) MarkFailed(
 
 	errJSON, _ := json.Marshal(errPayload)
 
-	_, err := s.db.ExecContext(ctx, `
+	res, err := s.db.ExecContext(ctx, `
 		UPDATE executions
 		SET
 			state = $2,
 			error = $3,
 			completed_at = now(),
 			updated_at = now()
-		WHERE id = $1
+		WHERE id = $1 AND state NOT IN ($4, $5, $6)
 	`,
 		executionID,
 		execution.ExecutionFailed,
 		errJSON,
+		execution.ExecutionSucceeded,
+		execution.ExecutionFailed,
+		execution.ExecutionCancelled,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return checkFinish(res, executionID)
+}
+
+func (s *executionStore) MarkPaused(
+	ctx context.Context,
+	executionID uuid.UUID,
+) error {
+	return s.transition(ctx, executionID, execution.ExecutionRunning, execution.ExecutionPaused)
+}
+
+func (s *executionStore) MarkResumed(
+	ctx context.Context,
+	executionID uuid.UUID,
+) error {
+	return s.transition(ctx, executionID, execution.ExecutionPaused, execution.ExecutionRunning)
+}
+
+// transition updates the state only if the execution is still in `from`, so
+// a late pause/resume never overwrites a terminal state.
+func (s *executionStore) transition(
+	ctx context.Context,
+	executionID uuid.UUID,
+	from, to execution.ExecutionStatus,
+) error {
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE executions
+		SET
+			state = $3,
+			updated_at = now()
+		WHERE id = $1 AND state = $2
+	`,
+		executionID,
+		from,
+		to,
+	)
+	if err != nil {
+		return err
+	}
+	return checkTransition(res, executionID, from)
+}
+
+// checkTransition returns ErrStateChanged if a guarded update matched no
+// row.
+func checkTransition(res sql.Result, executionID uuid.UUID, from execution.ExecutionStatus) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("execution %s is not %s: %w", executionID, from, execution.ErrStateChanged)
+	}
+	return nil
+}
+
+// checkFinish returns ErrStateChanged if an update guarded against
+// finished executions matched no row.
+func checkFinish(res sql.Result, executionID uuid.UUID) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("execution %s already finished: %w", executionID, execution.ErrStateChanged)
+	}
+	return nil
+}
+
+func (s *executionStore) MarkCancelled(
+	ctx context.Context,
+	executionID uuid.UUID,
+	reason map[string]any,
+) error {
+
+	reasonJSON, _ := json.Marshal(reason)
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE executions
+		SET
+			state = $2,
+			error = $3,
+			completed_at = now(),
+			updated_at = now()
+		WHERE id = $1 AND state NOT IN ($4, $5, $6)
+	`,
+		executionID,
+		execution.ExecutionCancelled,
+		reasonJSON,
+		execution.ExecutionSucceeded,
+		execution.ExecutionFailed,
+		execution.ExecutionCancelled,
+	)
+	if err != nil {
+		return err
+	}
+	return checkFinish(res, executionID)
+}
+
+func (s *executionStore) CancelPending(
+	ctx context.Context,
+	executionID uuid.UUID,
+	reason map[string]any,
+) error {
+
+	reasonJSON, _ := json.Marshal(reason)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE executions
+		SET
+			state = $2,
+			error = $3,
+			completed_at = now(),
+			updated_at = now()
+		WHERE id = $1 AND state = $4
+	`,
+		executionID,
+		execution.ExecutionCancelled,
+		reasonJSON,
+		execution.ExecutionPending,
+	)
+	if err != nil {
+		return err
+	}
+	if err := checkTransition(res, executionID, execution.ExecutionPending); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE execution_outbox
+		SET dispatched_at = now()
+		WHERE execution_id = $1 AND dispatched_at IS NULL
+	`, executionID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *executionStore) List(
@@ -165,9 +475,10 @@ func (s *executionStore) List(
 
 	query := `
 		SELECT
-			id, project_id, workflow_id,
-			client_request_id,
+			id, project_id, workflow_id, COALESCE(workflow_version, ''),
+			client_request_id, trigger_type, schedule_id, webhook_id,
 			temporal_workflow_id, temporal_run_id,
+			retry_of,
 			state, error,
 			inputs, outputs,
 			started_at, completed_at,
@@ -201,18 +512,26 @@ func (s *executionStore) List(
 	return list, nil
 }
 
-func (s *executionStore) ListRunning(ctx context.Context) ([]*execution.Execution, error) {
+func (s *executionStore) ListActive(ctx context.Context, engine string) ([]*execution.Execution, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT
-			id, project_id, workflow_id,
-			client_request_id,
+			id, project_id, workflow_id, COALESCE(workflow_version, ''),
+			client_request_id, trigger_type, schedule_id, webhook_id,
 			temporal_workflow_id, temporal_run_id,
-			state,
-			started_at,
+			retry_of,
+			state, error,
+			inputs, outputs,
+			started_at, completed_at,
 			created_at, updated_at
 		FROM executions
-		WHERE state = $1
-	`, execution.ExecutionRunning)
+		WHERE engine = $1 AND state IN ($2, $3, $4)
+		ORDER BY created_at
+	`,
+		engine,
+		execution.ExecutionPending,
+		execution.ExecutionRunning,
+		execution.ExecutionPaused,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -258,16 +577,22 @@ func scanExecution(row interface {
 
 	var e execution.Execution
 	var inputs, outputs, errJSON []byte
-	var runID sql.NullString
+	var runID, scheduleID, webhookID sql.NullString
+	var retryOf uuid.NullUUID
 	var startedAt, completedAt sql.NullTime
 
 	err := row.Scan(
 		&e.ID,
 		&e.ProjectID,
 		&e.WorkflowID,
+		&e.Version,
 		&e.ClientRequestID,
+		&e.TriggerType,
+		&scheduleID,
+		&webhookID,
 		&e.TemporalWorkflowID,
 		&runID,
+		&retryOf,
 		&e.Status,
 		&errJSON,
 		&inputs,
@@ -284,6 +609,15 @@ func scanExecution(row interface {
 	if runID.Valid {
 		e.TemporalRunID = runID.String
 	}
+	if scheduleID.Valid {
+		e.ScheduleID = scheduleID.String
+	}
+	if webhookID.Valid {
+		e.WebhookID = webhookID.String
+	}
+	if retryOf.Valid {
+		e.RetryOf = &retryOf.UUID
+	}
 	if startedAt.Valid {
 		e.StartedAt = &startedAt.Time
 	}
@@ -20,19 +20,21 @@ func (s *nodeStore) Upsert(ctx context.Context, n *execution.ExecutionNode) erro
 	}
 
 	input, _ := json.Marshal(n.Input)
+	output, _ := json.Marshal(n.Output)
 
 	_, err := s.db.ExecContext(ctx, `
 		INSERT INTO execution_nodes (
 			id, execution_id, node_id,
 			executor_type, status,
 			attempt, max_attempts,
-			input
+			input, output
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
 		ON CONFLICT (execution_id, node_id)
 		DO UPDATE SET
 			executor_type = EXCLUDED.executor_type,
-			max_attempts = EXCLUDED.max_attempts
+			max_attempts = EXCLUDED.max_attempts,
+			input = EXCLUDED.input
 	`,
 		n.ID,
 		n.ExecutionID,
@@ -42,6 +44,7 @@ func (s *nodeStore) Upsert(ctx context.Context, n *execution.ExecutionNode) erro
 		n.Attempt,
 		n.MaxAttempts,
 		input,
+		output,
 	)
 	return err
 }
@@ -51,7 +54,9 @@ func (s *nodeStore) MarkRunning(ctx context.Context, executionID uuid.UUID, node
 		UPDATE execution_nodes
 		SET
 			status = $3,
-			started_at = now()
+			started_at = now(),
+			completed_at = NULL,
+			duration_ms = NULL
 		WHERE execution_id = $1 AND node_id = $2
 	`, executionID, nodeID, execution.NodeRunning)
 	return err
@@ -72,6 +77,7 @@ func (s *nodeStore) MarkSucceeded(
 		SET
 			status = $3,
 			output = $4,
+			error = NULL,
 			completed_at = $5,
 			duration_ms = EXTRACT(EPOCH FROM ($5 - started_at)) * 1000
 		WHERE execution_id = $1 AND node_id = $2
@@ -88,16 +94,54 @@ func (s *nodeStore) MarkFailed(
 ) error {
 
 	errJSON, _ := json.Marshal(errPayload)
+	now := time.Now()
 
 	_, err := s.db.ExecContext(ctx, `
 		UPDATE execution_nodes
 		SET
 			status = $3,
 			error = $4,
-			completed_at = now()
+			completed_at = $5,
+			duration_ms = EXTRACT(EPOCH FROM ($5 - started_at)) * 1000
 		WHERE execution_id = $1 AND node_id = $2
-	`, executionID, nodeID, execution.NodeFailed, errJSON)
+	`, executionID, nodeID, execution.NodeFailed, errJSON, now)
+
+	return err
+}
+
+// MarkSkipped marks a node SKIPPED, creating its row if the node was never
+// scheduled. Nodes that already succeeded are left untouched.
+func (s *nodeStore) MarkSkipped(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	reason map[string]any,
+) error {
 
+	reasonJSON, _ := json.Marshal(reason)
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO execution_nodes (
+			id, execution_id, node_id,
+			executor_type, status,
+			attempt, max_attempts,
+			error, completed_at
+		)
+		VALUES ($1,$2,$3,'',$4,0,0,$5,now())
+		ON CONFLICT (execution_id, node_id)
+		DO UPDATE SET
+			status = EXCLUDED.status,
+			error = EXCLUDED.error,
+			completed_at = EXCLUDED.completed_at
+		WHERE execution_nodes.status <> $6
+	`,
+		uuid.New(),
+		executionID,
+		nodeID,
+		execution.NodeSkipped,
+		reasonJSON,
+		execution.NodeSucceeded,
+	)
 	return err
 }
 
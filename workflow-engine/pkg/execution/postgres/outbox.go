@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+)
+
+type outboxStore struct {
+	db *sql.DB
+}
+
+// Claim skips entries other dispatchers are claiming; an entry whose lease
+// ran out, because its dispatcher died, is claimed again.
+func (s *outboxStore) Claim(
+	ctx context.Context,
+	limit int,
+	ttl time.Duration,
+) ([]execution.OutboxEntry, error) {
+
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE execution_outbox o
+		SET claimed_until = now() + make_interval(secs => $2)
+		FROM executions e
+		WHERE e.id = o.execution_id
+			AND o.execution_id IN (
+				SELECT c.execution_id
+				FROM execution_outbox c
+				WHERE c.dispatched_at IS NULL
+					AND (c.claimed_until IS NULL OR c.claimed_until < now())
+					AND (c.next_attempt_at IS NULL OR c.next_attempt_at <= now())
+				ORDER BY c.created_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING o.execution_id, e.project_id, e.node_inputs, o.attempts, o.created_at
+	`, limit, ttl.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []execution.OutboxEntry
+	for rows.Next() {
+		var entry execution.OutboxEntry
+		var nodeInputs []byte
+
+		if err := rows.Scan(
+			&entry.ExecutionID,
+			&entry.ProjectID,
+			&nodeInputs,
+			&entry.Attempts,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal(nodeInputs, &entry.NodeInputs)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *outboxStore) MarkDispatched(ctx context.Context, executionID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE execution_outbox
+		SET
+			dispatched_at = now(),
+			claimed_until = NULL
+		WHERE execution_id = $1
+	`, executionID)
+	return err
+}
+
+func (s *outboxStore) Retry(
+	ctx context.Context,
+	executionID uuid.UUID,
+	at time.Time,
+	errMsg string,
+) error {
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE execution_outbox
+		SET
+			attempts = attempts + 1,
+			next_attempt_at = $2,
+			last_error = $3,
+			claimed_until = NULL
+		WHERE execution_id = $1
+	`, executionID, at, errMsg)
+	return err
+}
+
+func (s *outboxStore) OldestPending(ctx context.Context) (time.Time, bool, error) {
+	var createdAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT min(created_at)
+		FROM execution_outbox
+		WHERE dispatched_at IS NULL
+	`).Scan(&createdAt)
+	return createdAt.Time, createdAt.Valid, err
+}
@@ -10,6 +10,7 @@ type Store struct {
 	executions execution.ExecutionStore
 	nodes      execution.NodeStore
 	events     execution.EventStore
+	outbox     execution.OutboxStore
 }
 
 func New(db *sql.DB) *Store {
@@ -17,6 +18,7 @@ func New(db *sql.DB) *Store {
 		executions: &executionStore{db: db},
 		nodes:      &nodeStore{db: db},
 		events:     &eventStore{db: db},
+		outbox:     &outboxStore{db: db},
 	}
 }
 
@@ -31,3 +33,7 @@ func (s *Store) Nodes() execution.NodeStore {
 func (s *Store) Events() execution.EventStore {
 	return s.events
 }
+
+func (s *Store) Outbox() execution.OutboxStore {
+	return s.outbox
+}
@@ -2,36 +2,63 @@ package execution
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrDuplicate is returned by ExecutionStore.Create when the project,
+// workflow and client request ID already belong to an execution.
+var ErrDuplicate = errors.New("execution already exists")
+
+// ErrStateChanged is returned by guarded state transitions when the
+// execution is no longer in the state they move it from.
+var ErrStateChanged = errors.New("execution state changed")
+
 type Store interface {
 	Executions() ExecutionStore
 	Nodes() NodeStore
 	Events() EventStore
+	Outbox() OutboxStore
 }
 
 type ExecutionStore interface {
 	Create(ctx context.Context, exec *Execution) error
 
+	// Enqueue creates a PENDING execution together with its outbox entry
+	// in one transaction, so the execution is started even if the caller
+	// dies right after. A retry's SUCCEEDED nodes are copied from RetryOf
+	// in the same transaction.
+	Enqueue(ctx context.Context, exec *Execution) error
+
 	Get(
 		ctx context.Context,
 		projectID string,
 		executionID uuid.UUID,
 	) (*Execution, error)
 
+	// GetDefinition returns the workflow YAML an execution is pinned to.
+	GetDefinition(
+		ctx context.Context,
+		executionID uuid.UUID,
+	) (string, error)
+
 	GetByIdempotencyKey(
 		ctx context.Context,
 		projectID, workflowID, clientRequestID string,
 	) (*Execution, error)
 
+	// MarkRunning moves a PENDING execution to RUNNING, or returns
+	// ErrStateChanged if it already left PENDING.
 	MarkRunning(
 		ctx context.Context,
 		executionID uuid.UUID,
 		runID string,
 	) error
 
+	// MarkCompleted, MarkFailed and MarkCancelled finish an execution, or
+	// return ErrStateChanged if it already finished.
 	MarkCompleted(
 		ctx context.Context,
 		executionID uuid.UUID,
@@ -44,12 +71,48 @@ type ExecutionStore interface {
 		err map[string]any,
 	) error
 
+	// MarkPaused moves a RUNNING execution to PAUSED.
+	MarkPaused(
+		ctx context.Context,
+		executionID uuid.UUID,
+	) error
+
+	// MarkResumed moves a PAUSED execution back to RUNNING.
+	MarkResumed(
+		ctx context.Context,
+		executionID uuid.UUID,
+	) error
+
+	MarkCancelled(
+		ctx context.Context,
+		executionID uuid.UUID,
+		reason map[string]any,
+	) error
+
+	// CancelPending cancels an execution that was never started and
+	// closes its outbox entry in one transaction, or returns
+	// ErrStateChanged if it already left PENDING.
+	CancelPending(
+		ctx context.Context,
+		executionID uuid.UUID,
+		reason map[string]any,
+	) error
+
 	List(
 		ctx context.Context,
 		projectID, workflowID string,
 	) ([]*Execution, error)
 
-	ListRunning(ctx context.Context) ([]*Execution, error)
+	// ListActive returns the PENDING, RUNNING and PAUSED executions of an
+	// engine, oldest first.
+	ListActive(ctx context.Context, engine string) ([]*Execution, error)
+
+	// UpdateRunID records the run an execution's workflow continued in.
+	UpdateRunID(
+		ctx context.Context,
+		executionID uuid.UUID,
+		runID string,
+	) error
 
 	GetStats(
 		ctx context.Context,
@@ -80,6 +143,13 @@ type NodeStore interface {
 		err map[string]any,
 	) error
 
+	MarkSkipped(
+		ctx context.Context,
+		executionID uuid.UUID,
+		nodeID string,
+		reason map[string]any,
+	) error
+
 	IncrementAttempt(
 		ctx context.Context,
 		executionID uuid.UUID,
@@ -96,3 +166,23 @@ type EventStore interface {
 	Append(ctx context.Context, event *ExecutionEvent) error
 	List(ctx context.Context, executionID uuid.UUID) ([]ExecutionEvent, error)
 }
+
+// OutboxStore holds the executions waiting to be started on their
+// backend. Entries are leased, so concurrent dispatchers never start the
+// same execution at once.
+type OutboxStore interface {
+	// Claim leases up to limit undispatched entries, oldest first, for ttl.
+	// Entries waiting out a retry backoff are left alone.
+	Claim(ctx context.Context, limit int, ttl time.Duration) ([]OutboxEntry, error)
+
+	// MarkDispatched records that the entry's execution was handed to its
+	// backend, or given up on.
+	MarkDispatched(ctx context.Context, executionID uuid.UUID) error
+
+	// Retry records a failed start and releases the entry until `at`.
+	Retry(ctx context.Context, executionID uuid.UUID, at time.Time, errMsg string) error
+
+	// OldestPending returns when the oldest undispatched entry was
+	// written; ok is false if there is none.
+	OldestPending(ctx context.Context) (createdAt time.Time, ok bool, err error)
+}
@@ -45,6 +45,17 @@ func (b *TimelineBuilder) Build(
 
 	var timeline []ExecutionTimelineEvent
 
+	// Explicit events take precedence (e.g. one per node attempt); only
+	// derive events from node and execution rows for types never emitted.
+	recorded := map[string]bool{}
+	for _, e := range events {
+		key := e.EventType
+		if e.NodeID != nil {
+			key = *e.NodeID + "/" + e.EventType
+		}
+		recorded[key] = true
+	}
+
 	// 1. Execution started
 	if exec.StartedAt != nil {
 		timeline = append(timeline, ExecutionTimelineEvent{
@@ -56,7 +67,7 @@ func (b *TimelineBuilder) Build(
 
 	// 2. Node events
 	for _, n := range nodes {
-		if n.StartedAt != nil {
+		if n.StartedAt != nil && !recorded[n.NodeID+"/"+string(TimelineNodeStarted)] {
 			timeline = append(timeline, ExecutionTimelineEvent{
 				Timestamp: *n.StartedAt,
 				Type:      TimelineNodeStarted,
@@ -67,8 +78,14 @@ func (b *TimelineBuilder) Build(
 
 		if n.CompletedAt != nil {
 			eventType := TimelineNodeSucceeded
-			if n.Status == execution.NodeFailed {
+			switch n.Status {
+			case execution.NodeFailed:
 				eventType = TimelineNodeFailed
+			case execution.NodeSkipped:
+				eventType = TimelineNodeSkipped
+			}
+			if recorded[n.NodeID+"/"+string(eventType)] {
+				continue
 			}
 
 			timeline = append(timeline, ExecutionTimelineEvent{
@@ -95,14 +112,19 @@ func (b *TimelineBuilder) Build(
 	// 4. Execution end
 	if exec.CompletedAt != nil {
 		endType := TimelineExecutionSucceeded
-		if exec.Status == execution.ExecutionFailed {
+		switch exec.Status {
+		case execution.ExecutionFailed:
 			endType = TimelineExecutionFailed
+		case execution.ExecutionCancelled:
+			endType = TimelineExecutionCancelled
 		}
 
-		timeline = append(timeline, ExecutionTimelineEvent{
-			Timestamp: *exec.CompletedAt,
-			Type:      endType,
-		})
+		if !recorded[string(endType)] {
+			timeline = append(timeline, ExecutionTimelineEvent{
+				Timestamp: *exec.CompletedAt,
+				Type:      endType,
+			})
+		}
 	}
 
 	// 5. Sort chronologically
@@ -13,11 +13,14 @@ const (
 	TimelineExecutionStarted   TimelineEventType = "EXECUTION_STARTED"
 	TimelineExecutionSucceeded TimelineEventType = "EXECUTION_SUCCEEDED"
 	TimelineExecutionFailed    TimelineEventType = "EXECUTION_FAILED"
+	TimelineExecutionCancelled TimelineEventType = "EXECUTION_CANCELLED"
 
 	TimelineNodeStarted   TimelineEventType = "NODE_STARTED"
 	TimelineNodeSucceeded TimelineEventType = "NODE_SUCCEEDED"
 	TimelineNodeFailed    TimelineEventType = "NODE_FAILED"
 	TimelineNodeRetry     TimelineEventType = "NODE_RETRY"
+	TimelineNodeSkipped   TimelineEventType = "NODE_SKIPPED"
+	TimelineNodeLog       TimelineEventType = "NODE_LOG"
 )
 
 type ExecutionTimelineEvent struct {
@@ -1,6 +1,10 @@
 package api
 
-import "time"
+import (
+	"time"
+
+	wfapi "github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+)
 
 type Module struct {
 	ID        string                 `json:"id"`
@@ -12,4 +16,7 @@ type Module struct {
 	Outputs   map[string]interface{} `json:"outputs,omitempty"`
 	CreatedAt time.Time              `json:"created_at"`
 	UpdatedAt time.Time              `json:"updated_at"`
+
+	// Defaults sets the timeout and retry policy of nodes using the module
+	Defaults *wfapi.NodeDefaults `json:"defaults,omitempty"`
 }
@@ -0,0 +1,249 @@
+// Package contract checks values against the Inputs and Outputs a module
+// declares.
+//
+// A contract is stored as a JSON object in one of two forms. The field form
+// maps each field to a type name or to an object with type and required:
+//
+//	{"name": "string", "size": {"type": "integer", "required": true}}
+//
+// The JSON Schema form uses properties and required:
+//
+//	{"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+//
+// Supported types are string, number, integer, boolean, object, array and
+// any. An empty contract accepts everything.
+package contract
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Field is one declared input or output.
+type Field struct {
+	Type     string
+	Required bool
+}
+
+// Contract is the parsed form of a module's Inputs or Outputs.
+type Contract struct {
+	Fields map[string]Field
+}
+
+// Empty reports whether the contract declares no fields.
+func (c *Contract) Empty() bool {
+	return c == nil || len(c.Fields) == 0
+}
+
+// Parse reads a contract from a module's Inputs or Outputs map.
+func Parse(raw map[string]interface{}) (*Contract, error) {
+	c := &Contract{Fields: map[string]Field{}}
+	if len(raw) == 0 {
+		return c, nil
+	}
+
+	if props, ok := raw["properties"].(map[string]interface{}); ok {
+		for name, p := range props {
+			f, err := parseField(p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			c.Fields[name] = f
+		}
+
+		required, _ := raw["required"].([]interface{})
+		for _, r := range required {
+			name, ok := r.(string)
+			if !ok {
+				return nil, fmt.Errorf("required: expected field names, got %T", r)
+			}
+			f, ok := c.Fields[name]
+			if !ok {
+				return nil, fmt.Errorf("required: %s is not a declared property", name)
+			}
+			f.Required = true
+			c.Fields[name] = f
+		}
+		return c, nil
+	}
+
+	for name, v := range raw {
+		f, err := parseField(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		c.Fields[name] = f
+	}
+	return c, nil
+}
+
+func parseField(v interface{}) (Field, error) {
+	var f Field
+
+	switch v := v.(type) {
+	case string:
+		f.Type = v
+	case map[string]interface{}:
+		if t, ok := v["type"]; ok {
+			s, ok := t.(string)
+			if !ok {
+				return f, fmt.Errorf("type must be a string")
+			}
+			f.Type = s
+		}
+		if r, ok := v["required"]; ok {
+			b, ok := r.(bool)
+			if !ok {
+				return f, fmt.Errorf("required must be a boolean")
+			}
+			f.Required = b
+		}
+	default:
+		return f, fmt.Errorf("expected a type name or an object, got %T", v)
+	}
+
+	if !KnownType(f.Type) {
+		return f, fmt.Errorf("unknown type %q", f.Type)
+	}
+	return f, nil
+}
+
+// KnownType reports whether typ is a supported type name ("" means any).
+func KnownType(typ string) bool {
+	switch typ {
+	case "", "any", "string", "number", "integer", "boolean", "object", "array":
+		return true
+	}
+	return false
+}
+
+// CheckType reports whether v is of the named type. Numbers may be any of
+// the numeric types produced by YAML and JSON decoding.
+func CheckType(typ string, v interface{}) error {
+	ok := true
+
+	switch typ {
+	case "", "any":
+	case "string":
+		_, ok = v.(string)
+	case "number":
+		_, ok = number(v)
+	case "integer":
+		f, isNum := number(v)
+		ok = isNum && f == math.Trunc(f)
+	case "boolean":
+		_, ok = v.(bool)
+	case "object":
+		_, ok = v.(map[string]interface{})
+	case "array":
+		_, ok = v.([]interface{})
+	}
+
+	if !ok {
+		return fmt.Errorf("expected %s, got %T", typ, v)
+	}
+	return nil
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// Check validates values against the contract: required fields must be
+// present and declared fields must have the declared type. Undeclared
+// fields are ignored; see Unknown.
+func (c *Contract) Check(values map[string]interface{}) []Violation {
+	if c.Empty() {
+		return nil
+	}
+
+	var violations []Violation
+	for _, name := range c.names() {
+		f := c.Fields[name]
+
+		v, ok := values[name]
+		if !ok || v == nil {
+			if f.Required {
+				violations = append(violations, Violation{Field: name, Message: "required"})
+			}
+			continue
+		}
+
+		if err := CheckType(f.Type, v); err != nil {
+			violations = append(violations, Violation{Field: name, Message: err.Error()})
+		}
+	}
+	return violations
+}
+
+// Unknown returns the sorted keys of values the contract does not declare.
+func (c *Contract) Unknown(values map[string]interface{}) []string {
+	if c.Empty() {
+		return nil
+	}
+
+	var unknown []string
+	for k := range values {
+		if _, ok := c.Fields[k]; !ok {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func (c *Contract) names() []string {
+	names := make([]string, 0, len(c.Fields))
+	for name := range c.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Violation is a single field that does not satisfy a contract.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Direction tells which side of a module's contract was violated.
+type Direction string
+
+const (
+	DirectionInput  Direction = "input"
+	DirectionOutput Direction = "output"
+)
+
+// ViolationError is returned when a node's inputs or outputs do not match
+// its module's contract.
+type ViolationError struct {
+	Module     string      `json:"module"`
+	Direction  Direction   `json:"direction"`
+	Violations []Violation `json:"violations"`
+}
+
+func (e *ViolationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Field + ": " + v.Message
+	}
+	return fmt.Sprintf(
+		"module %s %s contract violated: %s",
+		e.Module, e.Direction, strings.Join(parts, "; "),
+	)
+}
@@ -0,0 +1,268 @@
+package contract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     map[string]interface{}
+		want    map[string]Field
+		wantErr string
+	}{
+		{
+			name: "empty",
+			raw:  nil,
+			want: map[string]Field{},
+		},
+		{
+			name: "field form",
+			raw: map[string]interface{}{
+				"name": "string",
+				"size": map[string]interface{}{"type": "integer", "required": true},
+				"meta": map[string]interface{}{},
+			},
+			want: map[string]Field{
+				"name": {Type: "string"},
+				"size": {Type: "integer", Required: true},
+				"meta": {},
+			},
+		},
+		{
+			name: "JSON Schema form",
+			raw: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "string"},
+					"tags": map[string]interface{}{"type": "array"},
+				},
+				"required": []interface{}{"name"},
+			},
+			want: map[string]Field{
+				"name": {Type: "string", Required: true},
+				"tags": {Type: "array"},
+			},
+		},
+		{
+			name:    "unknown type",
+			raw:     map[string]interface{}{"name": "text"},
+			wantErr: `name: unknown type "text"`,
+		},
+		{
+			name:    "type is not a string",
+			raw:     map[string]interface{}{"name": map[string]interface{}{"type": 1}},
+			wantErr: "name: type must be a string",
+		},
+		{
+			name:    "required is not a boolean",
+			raw:     map[string]interface{}{"name": map[string]interface{}{"required": "yes"}},
+			wantErr: "name: required must be a boolean",
+		},
+		{
+			name:    "field is neither a type nor an object",
+			raw:     map[string]interface{}{"name": 3},
+			wantErr: "name: expected a type name or an object, got int",
+		},
+		{
+			name: "required property that is not declared",
+			raw: map[string]interface{}{
+				"properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}},
+				"required":   []interface{}{"size"},
+			},
+			wantErr: "required: size is not a declared property",
+		},
+		{
+			name: "required entry that is not a name",
+			raw: map[string]interface{}{
+				"properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}},
+				"required":   []interface{}{1},
+			},
+			wantErr: "required: expected field names, got int",
+		},
+		{
+			name: "unknown property type",
+			raw: map[string]interface{}{
+				"properties": map[string]interface{}{"name": map[string]interface{}{"type": "str"}},
+			},
+			wantErr: `name: unknown type "str"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(c.Fields, tt.want) {
+				t.Errorf("fields = %v, want %v", c.Fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	fieldForm := map[string]interface{}{
+		"name":    map[string]interface{}{"type": "string", "required": true},
+		"count":   "integer",
+		"ratio":   "number",
+		"enabled": "boolean",
+		"meta":    "object",
+		"tags":    "array",
+		"raw":     "any",
+	}
+	schemaForm := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string"},
+			"count": map[string]interface{}{"type": "integer"},
+		},
+		"required": []interface{}{"name"},
+	}
+
+	tests := []struct {
+		name   string
+		raw    map[string]interface{}
+		values map[string]interface{}
+		want   []Violation
+	}{
+		{
+			name: "all types match",
+			raw:  fieldForm,
+			values: map[string]interface{}{
+				"name": "a", "count": float64(2), "ratio": 0.5, "enabled": true,
+				"meta": map[string]interface{}{}, "tags": []interface{}{}, "raw": 1,
+			},
+		},
+		{
+			name:   "YAML integers",
+			raw:    fieldForm,
+			values: map[string]interface{}{"name": "a", "count": 2, "ratio": int64(1)},
+		},
+		{
+			name:   "undeclared fields are ignored",
+			raw:    fieldForm,
+			values: map[string]interface{}{"name": "a", "other": 1},
+		},
+		{
+			name:   "missing required field",
+			raw:    fieldForm,
+			values: map[string]interface{}{"count": 1},
+			want:   []Violation{{Field: "name", Message: "required"}},
+		},
+		{
+			name:   "null required field",
+			raw:    schemaForm,
+			values: map[string]interface{}{"name": nil},
+			want:   []Violation{{Field: "name", Message: "required"}},
+		},
+		{
+			name: "wrong types, sorted by field",
+			raw:  fieldForm,
+			values: map[string]interface{}{
+				"name": 1, "count": 1.5, "enabled": "true", "tags": "a,b",
+			},
+			want: []Violation{
+				{Field: "count", Message: "expected integer, got float64"},
+				{Field: "enabled", Message: "expected boolean, got string"},
+				{Field: "name", Message: "expected string, got int"},
+				{Field: "tags", Message: "expected array, got string"},
+			},
+		},
+		{
+			name:   "JSON Schema form",
+			raw:    schemaForm,
+			values: map[string]interface{}{"count": "3"},
+			want: []Violation{
+				{Field: "count", Message: "expected integer, got string"},
+				{Field: "name", Message: "required"},
+			},
+		},
+		{
+			name:   "empty contract accepts everything",
+			raw:    nil,
+			values: map[string]interface{}{"anything": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := c.Check(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    map[string]interface{}
+		values map[string]interface{}
+		want   []string
+	}{
+		{
+			name:   "field form",
+			raw:    map[string]interface{}{"name": "string"},
+			values: map[string]interface{}{"name": "a", "zone": 1, "extra": 2},
+			want:   []string{"extra", "zone"},
+		},
+		{
+			name: "JSON Schema form",
+			raw: map[string]interface{}{
+				"properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}},
+			},
+			values: map[string]interface{}{"name": "a", "other": 1},
+			want:   []string{"other"},
+		},
+		{
+			name:   "all declared",
+			raw:    map[string]interface{}{"name": "string"},
+			values: map[string]interface{}{"name": "a"},
+		},
+		{
+			name:   "empty contract declares nothing to compare with",
+			values: map[string]interface{}{"name": "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := c.Unknown(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unknown = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViolationError(t *testing.T) {
+	err := &ViolationError{
+		Module:    "dns.create",
+		Direction: DirectionInput,
+		Violations: []Violation{
+			{Field: "name", Message: "required"},
+			{Field: "ttl", Message: "expected integer, got string"},
+		},
+	}
+
+	want := "module dns.create input contract violated: name: required; ttl: expected integer, got string"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
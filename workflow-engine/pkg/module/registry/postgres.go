@@ -33,6 +33,11 @@ func (s *PostgresRegistry) Insert(ctx context.Context, m *api.Module) error {
 		outputsJSON = []byte("{}")
 	}
 
+	var defaultsJSON []byte
+	if m.Defaults != nil {
+		defaultsJSON, _ = json.Marshal(m.Defaults)
+	}
+
 	// Handle empty project_id for global modules
 	projectID := m.ProjectID
 	if projectID == "" {
@@ -40,16 +45,16 @@ func (s *PostgresRegistry) Insert(ctx context.Context, m *api.Module) error {
 	}
 
 	query := `
-	INSERT INTO modules (id, name, version, project_id, runtime, inputs, outputs, created_at)
-	VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	INSERT INTO modules (id, name, version, project_id, runtime, inputs, outputs, created_at, defaults)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
 	ON CONFLICT (project_id, name, version) DO UPDATE 
-	SET runtime=$5, inputs=$6, outputs=$7
+	SET runtime=$5, inputs=$6, outputs=$7, defaults=$9
 	`
 
 	now := time.Now()
 	_, err := s.DB.ExecContext(ctx, query,
 		m.ID, m.Name, m.Version, projectID, m.Runtime,
-		string(inputsJSON), string(outputsJSON), now,
+		string(inputsJSON), string(outputsJSON), now, defaultsJSON,
 	)
 	return err
 }
@@ -57,7 +62,7 @@ func (s *PostgresRegistry) Insert(ctx context.Context, m *api.Module) error {
 // Lookup module (project-specific then global)
 func (s *PostgresRegistry) Get(ctx context.Context, projectID, name, version string) (*api.Module, error) {
 	query := `
-	SELECT id, name, version, project_id, runtime, inputs, outputs, created_at
+	SELECT id, name, version, project_id, runtime, inputs, outputs, created_at, defaults
 	FROM modules
 	WHERE name=$1 AND (project_id=$2 OR project_id IS NULL OR project_id = '')
 	ORDER BY CASE WHEN project_id=$2 THEN 0 ELSE 1 END, created_at DESC
@@ -67,13 +72,15 @@ func (s *PostgresRegistry) Get(ctx context.Context, projectID, name, version str
 	row := s.DB.QueryRowContext(ctx, query, name, projectID)
 	var m api.Module
 	var inputsJSON, outputsJSON string
-	if err := row.Scan(&m.ID, &m.Name, &m.Version, &m.ProjectID, &m.Runtime, &inputsJSON, &outputsJSON, &m.CreatedAt); err != nil {
+	var defaultsJSON []byte
+	if err := row.Scan(&m.ID, &m.Name, &m.Version, &m.ProjectID, &m.Runtime, &inputsJSON, &outputsJSON, &m.CreatedAt, &defaultsJSON); err != nil {
 		return nil, err
 	}
 	m.UpdatedAt = m.CreatedAt // Use created_at as updated_at since column doesn't exist
 
 	json.Unmarshal([]byte(inputsJSON), &m.Inputs)
 	json.Unmarshal([]byte(outputsJSON), &m.Outputs)
+	_ = json.Unmarshal(defaultsJSON, &m.Defaults)
 
 	if version != "" && m.Version != version {
 		return nil, fmt.Errorf("module version not found")
@@ -85,7 +92,7 @@ func (s *PostgresRegistry) Get(ctx context.Context, projectID, name, version str
 // List modules (global + project)
 func (s *PostgresRegistry) List(ctx context.Context, projectID string) ([]*api.Module, error) {
 	query := `
-	SELECT id, name, version, project_id, runtime, inputs, outputs, created_at
+	SELECT id, name, version, project_id, runtime, inputs, outputs, created_at, defaults
 	FROM modules
 	WHERE project_id=$1 OR project_id IS NULL OR project_id = ''
 	ORDER BY name, version
@@ -101,17 +108,30 @@ func (s *PostgresRegistry) List(ctx context.Context, projectID string) ([]*api.M
 	for rows.Next() {
 		var m api.Module
 		var inputsJSON, outputsJSON string
-		if err := rows.Scan(&m.ID, &m.Name, &m.Version, &m.ProjectID, &m.Runtime, &inputsJSON, &outputsJSON, &m.CreatedAt); err != nil {
+		var defaultsJSON []byte
+		if err := rows.Scan(&m.ID, &m.Name, &m.Version, &m.ProjectID, &m.Runtime, &inputsJSON, &outputsJSON, &m.CreatedAt, &defaultsJSON); err != nil {
 			return nil, err
 		}
 		m.UpdatedAt = m.CreatedAt // Use created_at as updated_at since column doesn't exist
 		json.Unmarshal([]byte(inputsJSON), &m.Inputs)
 		json.Unmarshal([]byte(outputsJSON), &m.Outputs)
+		_ = json.Unmarshal(defaultsJSON, &m.Defaults)
 		modules = append(modules, &m)
 	}
 	return modules, nil
 }
 
+// Defaults of module_http_specs, applied when a spec leaves them unset.
+const DefaultRetryBackoffMs = 500
+
+// MaxRetryCount caps the retry_count of module_http_specs.
+const MaxRetryCount = 10
+
+var (
+	DefaultRetryOnStatus = []int32{429, 500, 502, 503, 504}
+	DefaultSuccessCodes  = []int32{200, 201, 202, 204}
+)
+
 // InsertHttpSpec inserts HTTP spec for a module
 func (s *PostgresRegistry) InsertHttpSpec(ctx context.Context, moduleID string, spec *service.HttpModuleSpec) error {
 	headersJSON, _ := json.Marshal(spec.Headers)
@@ -157,6 +177,24 @@ func (s *PostgresRegistry) InsertHttpSpec(ctx context.Context, moduleID string,
 				authConfig["token_url"] = v.Oauth2.TokenUrl
 				authConfig["client_id"] = v.Oauth2.ClientId
 				authConfig["client_secret"] = v.Oauth2.ClientSecret
+				authConfig["scope"] = v.Oauth2.Scope
+				authConfig["grant_type"] = v.Oauth2.GrantType
+				authConfig["refresh_token"] = v.Oauth2.RefreshToken
+				authConfig["audience"] = v.Oauth2.Audience
+			}
+		case *service.HttpAuth_Basic:
+			if v.Basic != nil {
+				authType = "basic"
+				authConfig["username"] = v.Basic.Username
+				authConfig["password"] = v.Basic.Password
+			}
+		case *service.HttpAuth_Hmac:
+			if v.Hmac != nil {
+				authType = "hmac"
+				authConfig["secret"] = v.Hmac.Secret
+				authConfig["algorithm"] = v.Hmac.Algorithm
+				authConfig["header"] = v.Hmac.Header
+				authConfig["timestamp_header"] = v.Hmac.TimestampHeader
 			}
 		}
 		if len(authConfig) > 0 {
@@ -166,10 +204,10 @@ func (s *PostgresRegistry) InsertHttpSpec(ctx context.Context, moduleID string,
 	}
 
 	query := `
-	INSERT INTO module_http_specs (module_id, method, url, headers, query_params, body_template, timeout_ms, retry_count, auth_type, auth_config)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	INSERT INTO module_http_specs (module_id, method, url, headers, query_params, body_template, timeout_ms, retry_count, auth_type, auth_config, retry_backoff_ms, retry_on_status, success_codes)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	ON CONFLICT (module_id) DO UPDATE 
-	SET method=$2, url=$3, headers=$4, query_params=$5, body_template=$6, timeout_ms=$7, retry_count=$8, auth_type=$9, auth_config=$10
+	SET method=$2, url=$3, headers=$4, query_params=$5, body_template=$6, timeout_ms=$7, retry_count=$8, auth_type=$9, auth_config=$10, retry_backoff_ms=$11, retry_on_status=$12, success_codes=$13, updated_at=now()
 	`
 
 	timeoutMs := int32(30000)
@@ -180,6 +218,20 @@ func (s *PostgresRegistry) InsertHttpSpec(ctx context.Context, moduleID string,
 	if spec.RetryCount > 0 {
 		retryCount = spec.RetryCount
 	}
+	retryBackoffMs := int32(DefaultRetryBackoffMs)
+	if spec.RetryBackoffMs > 0 {
+		retryBackoffMs = spec.RetryBackoffMs
+	}
+	retryOnStatus := spec.RetryOnStatus
+	if len(retryOnStatus) == 0 {
+		retryOnStatus = DefaultRetryOnStatus
+	}
+	successCodes := spec.SuccessCodes
+	if len(successCodes) == 0 {
+		successCodes = DefaultSuccessCodes
+	}
+	retryOnStatusJSON, _ := json.Marshal(retryOnStatus)
+	successCodesJSON, _ := json.Marshal(successCodes)
 
 	// Handle NULL for optional JSON fields - only set to NULL if truly empty, otherwise use "{}"
 	var bodyTemplateSQL interface{}
@@ -200,6 +252,7 @@ func (s *PostgresRegistry) InsertHttpSpec(ctx context.Context, moduleID string,
 		moduleID, spec.Method, spec.Url,
 		string(headersJSON), string(queryParamsJSON), bodyTemplateSQL,
 		timeoutMs, retryCount, authType, authConfigSQL,
+		retryBackoffMs, string(retryOnStatusJSON), string(successCodesJSON),
 	)
 	return err
 }
@@ -207,7 +260,8 @@ func (s *PostgresRegistry) InsertHttpSpec(ctx context.Context, moduleID string,
 // GetHttpSpec retrieves HTTP spec for a module
 func (s *PostgresRegistry) GetHttpSpec(ctx context.Context, moduleID string) (*service.HttpModuleSpec, error) {
 	query := `
-	SELECT method, url, headers, query_params, body_template, timeout_ms, retry_count, auth_type, auth_config
+	SELECT method, url, headers, query_params, body_template, timeout_ms, retry_count, auth_type, auth_config,
+		retry_backoff_ms, retry_on_status, success_codes
 	FROM module_http_specs
 	WHERE module_id=$1
 	`
@@ -215,8 +269,11 @@ func (s *PostgresRegistry) GetHttpSpec(ctx context.Context, moduleID string) (*s
 	row := s.DB.QueryRowContext(ctx, query, moduleID)
 	var spec service.HttpModuleSpec
 	var headersJSON, queryParamsJSON, bodyTemplateJSON, authType string
-	var authConfigJSON sql.NullString
-	if err := row.Scan(&spec.Method, &spec.Url, &headersJSON, &queryParamsJSON, &bodyTemplateJSON, &spec.TimeoutMs, &spec.RetryCount, &authType, &authConfigJSON); err != nil {
+	var authConfigJSON, retryOnStatusJSON, successCodesJSON sql.NullString
+	if err := row.Scan(
+		&spec.Method, &spec.Url, &headersJSON, &queryParamsJSON, &bodyTemplateJSON, &spec.TimeoutMs, &spec.RetryCount, &authType, &authConfigJSON,
+		&spec.RetryBackoffMs, &retryOnStatusJSON, &successCodesJSON,
+	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // No spec found
 		}
@@ -231,6 +288,16 @@ func (s *PostgresRegistry) GetHttpSpec(ctx context.Context, moduleID string) (*s
 	spec.Headers = headers
 	spec.QueryParams = queryParams
 
+	// NULL status lists fall back to the column defaults
+	spec.RetryOnStatus = DefaultRetryOnStatus
+	if retryOnStatusJSON.Valid {
+		json.Unmarshal([]byte(retryOnStatusJSON.String), &spec.RetryOnStatus)
+	}
+	spec.SuccessCodes = DefaultSuccessCodes
+	if successCodesJSON.Valid {
+		json.Unmarshal([]byte(successCodesJSON.String), &spec.SuccessCodes)
+	}
+
 	// Parse body_template
 	if bodyTemplateJSON != "" && bodyTemplateJSON != "null" {
 		var bodyTemplate map[string]interface{}
@@ -273,11 +340,30 @@ func (s *PostgresRegistry) GetHttpSpec(ctx context.Context, moduleID string) (*s
 						if clientSecret, ok := authConfig["client_secret"].(string); ok {
 							oauth2.ClientSecret = clientSecret
 						}
+						oauth2.Scope, _ = authConfig["scope"].(string)
+						oauth2.GrantType, _ = authConfig["grant_type"].(string)
+						oauth2.RefreshToken, _ = authConfig["refresh_token"].(string)
+						oauth2.Audience, _ = authConfig["audience"].(string)
 						auth.Type = &service.HttpAuth_Oauth2{
 							Oauth2: oauth2,
 						}
 					}
 				}
+			case "basic":
+				if username, ok := authConfig["username"].(string); ok {
+					password, _ := authConfig["password"].(string)
+					auth.Type = &service.HttpAuth_Basic{
+						Basic: &service.BasicAuth{Username: username, Password: password},
+					}
+				}
+			case "hmac":
+				if secret, ok := authConfig["secret"].(string); ok {
+					hmac := &service.HmacAuth{Secret: secret}
+					hmac.Algorithm, _ = authConfig["algorithm"].(string)
+					hmac.Header, _ = authConfig["header"].(string)
+					hmac.TimestampHeader, _ = authConfig["timestamp_header"].(string)
+					auth.Type = &service.HttpAuth_Hmac{Hmac: hmac}
+				}
 			}
 			spec.Auth = auth
 		}
@@ -330,6 +416,154 @@ func (s *PostgresRegistry) GetContainerSpec(ctx context.Context, moduleID string
 	return &spec, nil
 }
 
+// InsertExecSpec inserts the local command spec for a module
+func (s *PostgresRegistry) InsertExecSpec(ctx context.Context, moduleID string, spec *service.ExecModuleSpec) error {
+	envJSON, _ := json.Marshal(spec.Env)
+
+	query := `
+	INSERT INTO module_exec_specs (module_id, command, script, args, env, working_dir, timeout_ms, output_format)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (module_id) DO UPDATE
+	SET command=$2, script=$3, args=$4, env=$5, working_dir=$6, timeout_ms=$7, output_format=$8
+	`
+
+	_, err := s.DB.ExecContext(ctx, query,
+		moduleID, spec.Command, spec.Script, pq.Array(spec.Args), string(envJSON),
+		spec.WorkingDir, spec.TimeoutMs, spec.OutputFormat,
+	)
+	return err
+}
+
+// GetExecSpec retrieves the local command spec for a module
+func (s *PostgresRegistry) GetExecSpec(ctx context.Context, moduleID string) (*service.ExecModuleSpec, error) {
+	query := `
+	SELECT command, script, args, env, working_dir, timeout_ms, output_format
+	FROM module_exec_specs
+	WHERE module_id=$1
+	`
+
+	row := s.DB.QueryRowContext(ctx, query, moduleID)
+	var spec service.ExecModuleSpec
+	var envJSON string
+	var args pq.StringArray
+	if err := row.Scan(
+		&spec.Command, &spec.Script, &args, &envJSON,
+		&spec.WorkingDir, &spec.TimeoutMs, &spec.OutputFormat,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No spec found
+		}
+		return nil, err
+	}
+
+	spec.Args = []string(args)
+	var env map[string]string
+	json.Unmarshal([]byte(envJSON), &env)
+	spec.Env = env
+
+	return &spec, nil
+}
+
+// grpcTLSConfig is the stored form of a gRPC module's TLS settings.
+type grpcTLSConfig struct {
+	Enabled            bool   `json:"enabled"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+	CACert             string `json:"ca_cert,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+}
+
+func nullableJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+// InsertGrpcSpec inserts the gRPC spec for a module
+func (s *PostgresRegistry) InsertGrpcSpec(ctx context.Context, moduleID string, spec *service.GrpcModuleSpec) error {
+	var tlsJSON []byte
+	if t := spec.Tls; t != nil {
+		tlsJSON, _ = json.Marshal(grpcTLSConfig{
+			Enabled:            t.Enabled,
+			InsecureSkipVerify: t.InsecureSkipVerify,
+			CACert:             t.CaCert,
+			ServerName:         t.ServerName,
+		})
+	}
+
+	requestTemplateJSON := []byte("{}")
+	if spec.RequestTemplate != nil {
+		requestTemplateJSON, _ = json.Marshal(spec.RequestTemplate.AsMap())
+	}
+
+	metadataJSON, _ := json.Marshal(spec.Metadata)
+	if string(metadataJSON) == "null" {
+		metadataJSON = []byte("{}")
+	}
+
+	query := `
+	INSERT INTO module_grpc_specs (module_id, target, method, tls, request_template, metadata, timeout_ms, descriptor_set)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (module_id) DO UPDATE
+	SET target=$2, method=$3, tls=$4, request_template=$5, metadata=$6, timeout_ms=$7, descriptor_set=$8, updated_at=now()
+	`
+
+	_, err := s.DB.ExecContext(ctx, query,
+		moduleID, spec.Target, spec.Method, nullableJSON(tlsJSON), string(requestTemplateJSON),
+		string(metadataJSON), spec.TimeoutMs, spec.DescriptorSet,
+	)
+	return err
+}
+
+// GetGrpcSpec retrieves the gRPC spec for a module
+func (s *PostgresRegistry) GetGrpcSpec(ctx context.Context, moduleID string) (*service.GrpcModuleSpec, error) {
+	query := `
+	SELECT target, method, tls, request_template, metadata, timeout_ms, descriptor_set
+	FROM module_grpc_specs
+	WHERE module_id=$1
+	`
+
+	row := s.DB.QueryRowContext(ctx, query, moduleID)
+	var spec service.GrpcModuleSpec
+	var tlsJSON, requestTemplateJSON, metadataJSON sql.NullString
+	if err := row.Scan(
+		&spec.Target, &spec.Method, &tlsJSON, &requestTemplateJSON,
+		&metadataJSON, &spec.TimeoutMs, &spec.DescriptorSet,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No spec found
+		}
+		return nil, err
+	}
+
+	if tlsJSON.Valid {
+		var t grpcTLSConfig
+		if err := json.Unmarshal([]byte(tlsJSON.String), &t); err == nil {
+			spec.Tls = &service.GrpcTls{
+				Enabled:            t.Enabled,
+				InsecureSkipVerify: t.InsecureSkipVerify,
+				CaCert:             t.CACert,
+				ServerName:         t.ServerName,
+			}
+		}
+	}
+
+	if requestTemplateJSON.Valid {
+		var requestTemplate map[string]interface{}
+		if err := json.Unmarshal([]byte(requestTemplateJSON.String), &requestTemplate); err == nil {
+			spec.RequestTemplate, _ = structpb.NewStruct(requestTemplate)
+		}
+	}
+
+	if metadataJSON.Valid {
+		var metadata map[string]string
+		json.Unmarshal([]byte(metadataJSON.String), &metadata)
+		spec.Metadata = metadata
+	}
+
+	return &spec, nil
+}
+
 func (r *ModuleRegistry) Resolve(
 	ctx context.Context,
 	projectID string,
@@ -0,0 +1,182 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/config"
+)
+
+// KeyProvider wraps and unwraps data keys with a key it holds, e.g. a
+// local key file or a cloud KMS key.
+type KeyProvider interface {
+	// KeyID identifies the current key; it is stored with every secret so
+	// a provider can tell which key to unwrap with after a rotation.
+	KeyID() string
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// NewKeyProvider returns the key provider cfg selects. Without a key file
+// configured secrets are disabled, which is reported as an error.
+func NewKeyProvider(cfg config.Config) (KeyProvider, error) {
+	switch cfg.SecretsKeyProvider {
+	case "file":
+		if cfg.SecretsKeyFile == "" {
+			return nil, fmt.Errorf("SECRETS_KEY_FILE is not set")
+		}
+		return NewFileKeyProvider(cfg.SecretsKeyFile, cfg.SecretsKeyGenerate)
+	}
+	return nil, fmt.Errorf("unknown secrets key provider %q", cfg.SecretsKeyProvider)
+}
+
+// FileKeyProvider wraps data keys with a 256-bit key read from a file. It
+// is meant for local development; production setups should keep the key
+// in a KMS.
+type FileKeyProvider struct {
+	id  string
+	key []byte
+}
+
+// NewFileKeyProvider reads a base64-encoded 32-byte key from path. A
+// missing file is an error unless generate is set, in which case it is
+// created with a new random key. Secrets sealed with a lost key cannot be
+// recovered, so generate is only meant for local development.
+func NewFileKeyProvider(path string, generate bool) (*FileKeyProvider, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if !generate {
+			return nil, fmt.Errorf("secrets key file %s does not exist; set SECRETS_KEY_GENERATE=true to create one for local development", path)
+		}
+		raw, err = generateKeyFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("secrets key file: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("secrets key file %s must hold a base64-encoded 32-byte key", path)
+	}
+
+	sum := sha256.Sum256(key)
+	return &FileKeyProvider{id: "file:" + hex.EncodeToString(sum[:8]), key: key}, nil
+}
+
+func generateKeyFile(path string) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	raw := []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	// O_EXCL: if another process created the file meanwhile, use its key
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Write(raw); err != nil {
+		return nil, err
+	}
+
+	log.Printf("generated secrets key file %s\n", path)
+	return raw, nil
+}
+
+func (p *FileKeyProvider) KeyID() string {
+	return p.id
+}
+
+func (p *FileKeyProvider) WrapKey(_ context.Context, dataKey []byte) ([]byte, error) {
+	return encrypt(p.key, dataKey, []byte(p.id))
+}
+
+func (p *FileKeyProvider) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	if keyID != p.id {
+		return nil, fmt.Errorf("secret was encrypted with key %s, but the key file holds %s", keyID, p.id)
+	}
+	return decrypt(p.key, wrapped, []byte(keyID))
+}
+
+// seal encrypts value with a new data key and wraps the data key. aad binds
+// the ciphertext to its row, so it cannot be copied to another secret.
+func seal(ctx context.Context, kp KeyProvider, value, aad []byte) (ciphertext, wrappedKey []byte, keyID string, err error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, "", err
+	}
+
+	ciphertext, err = encrypt(dataKey, value, aad)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	wrappedKey, err = kp.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("wrap data key: %w", err)
+	}
+	return ciphertext, wrappedKey, kp.KeyID(), nil
+}
+
+func open(ctx context.Context, kp KeyProvider, ciphertext, wrappedKey []byte, keyID string, aad []byte) ([]byte, error) {
+	dataKey, err := kp.UnwrapKey(ctx, keyID, wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return decrypt(dataKey, ciphertext, aad)
+}
+
+// encrypt returns nonce || AES-GCM ciphertext.
+func encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func decrypt(key, sealed, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ct := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ct, aad)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
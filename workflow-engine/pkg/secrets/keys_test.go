@@ -0,0 +1,178 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestKeyProvider(t *testing.T) *FileKeyProvider {
+	t.Helper()
+	kp, err := NewFileKeyProvider(filepath.Join(t.TempDir(), "secrets.key"), true)
+	if err != nil {
+		t.Fatalf("NewFileKeyProvider: %v", err)
+	}
+	return kp
+}
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.Background()
+	kp := newTestKeyProvider(t)
+
+	ciphertext, wrappedKey, keyID, err := seal(ctx, kp, []byte("s3cret"), aad("p1", "TOKEN"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if keyID != kp.KeyID() {
+		t.Errorf("key id = %q, want %q", keyID, kp.KeyID())
+	}
+	if strings.Contains(string(ciphertext), "s3cret") {
+		t.Error("ciphertext holds the plain value")
+	}
+
+	got, err := open(ctx, kp, ciphertext, wrappedKey, keyID, aad("p1", "TOKEN"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if string(got) != "s3cret" {
+		t.Errorf("open = %q, want %q", got, "s3cret")
+	}
+
+	// every value gets its own data key and nonce
+	again, _, _, err := seal(ctx, kp, []byte("s3cret"), aad("p1", "TOKEN"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if string(again) == string(ciphertext) {
+		t.Error("sealing the same value twice gave the same ciphertext")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	ctx := context.Background()
+	kp := newTestKeyProvider(t)
+
+	ciphertext, wrappedKey, keyID, err := seal(ctx, kp, []byte("s3cret"), aad("p1", "TOKEN"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	tamperedCiphertext := append([]byte(nil), ciphertext...)
+	tamperedCiphertext[len(tamperedCiphertext)-1] ^= 1
+
+	tests := []struct {
+		name       string
+		kp         KeyProvider
+		ciphertext []byte
+		keyID      string
+		aad        []byte
+		wantErr    string
+	}{
+		{
+			name:       "another secret's row",
+			kp:         kp,
+			ciphertext: ciphertext,
+			keyID:      keyID,
+			aad:        aad("p1", "OTHER"),
+			wantErr:    "decrypt:",
+		},
+		{
+			name:       "another project",
+			kp:         kp,
+			ciphertext: ciphertext,
+			keyID:      keyID,
+			aad:        aad("p2", "TOKEN"),
+			wantErr:    "decrypt:",
+		},
+		{
+			name:       "tampered ciphertext",
+			kp:         kp,
+			ciphertext: tamperedCiphertext,
+			keyID:      keyID,
+			aad:        aad("p1", "TOKEN"),
+			wantErr:    "decrypt:",
+		},
+		{
+			name:       "short ciphertext",
+			kp:         kp,
+			ciphertext: ciphertext[:4],
+			keyID:      keyID,
+			aad:        aad("p1", "TOKEN"),
+			wantErr:    "ciphertext too short",
+		},
+		{
+			name:       "another key file",
+			kp:         newTestKeyProvider(t),
+			ciphertext: ciphertext,
+			keyID:      keyID,
+			aad:        aad("p1", "TOKEN"),
+			wantErr:    "unwrap data key: secret was encrypted with key " + keyID,
+		},
+		{
+			name:       "another key behind the same id",
+			kp:         &FileKeyProvider{id: keyID, key: make([]byte, 32)},
+			ciphertext: ciphertext,
+			keyID:      keyID,
+			aad:        aad("p1", "TOKEN"),
+			wantErr:    "unwrap data key: decrypt:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := open(ctx, tt.kp, tt.ciphertext, wrappedKey, tt.keyID, tt.aad)
+			if err == nil {
+				t.Fatalf("open = %q, want an error", got)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewFileKeyProvider(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys", "secrets.key")
+
+	if _, err := NewFileKeyProvider(path, false); err == nil || !strings.Contains(err.Error(), "SECRETS_KEY_GENERATE") {
+		t.Fatalf("missing file: error = %v, want it to mention SECRETS_KEY_GENERATE", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("the key file was created without generate: %v", err)
+	}
+
+	generated, err := NewFileKeyProvider(path, true)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("key file mode = %o, want 600", perm)
+	}
+
+	// the generated file is read back as is, with or without generate
+	for _, generate := range []bool{false, true} {
+		loaded, err := NewFileKeyProvider(path, generate)
+		if err != nil {
+			t.Fatalf("reload: %v", err)
+		}
+		if loaded.KeyID() != generated.KeyID() {
+			t.Errorf("reload key id = %q, want %q", loaded.KeyID(), generated.KeyID())
+		}
+	}
+
+	invalid := filepath.Join(dir, "short.key")
+	if err := os.WriteFile(invalid, []byte(base64.StdEncoding.EncodeToString(make([]byte, 16))), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileKeyProvider(invalid, true); err == nil || !strings.Contains(err.Error(), "32-byte key") {
+		t.Errorf("short key: error = %v, want a 32-byte key error", err)
+	}
+}
@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type PostgresStore struct {
+	DB   *sql.DB
+	Keys KeyProvider
+}
+
+func NewPostgresStore(db *sql.DB, keys KeyProvider) *PostgresStore {
+	return &PostgresStore{DB: db, Keys: keys}
+}
+
+func (s *PostgresStore) Put(ctx context.Context, projectID, name, value string) (*Secret, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	ciphertext, wrappedKey, keyID, err := seal(ctx, s.Keys, []byte(value), aad(projectID, name))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt secret %s: %w", name, err)
+	}
+
+	query := `
+	INSERT INTO secrets (project_id, name, ciphertext, wrapped_key, key_id)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (project_id, name) DO UPDATE
+	SET ciphertext=$3, wrapped_key=$4, key_id=$5, updated_at=now()
+	RETURNING created_at, updated_at
+	`
+	sec := &Secret{ProjectID: projectID, Name: name, KeyID: keyID}
+	err = s.DB.QueryRowContext(ctx, query, projectID, name, ciphertext, wrappedKey, keyID).
+		Scan(&sec.CreatedAt, &sec.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return sec, nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, projectID, name string) (string, error) {
+	var ciphertext, wrappedKey []byte
+	var keyID string
+	err := s.DB.QueryRowContext(ctx, `
+	SELECT ciphertext, wrapped_key, key_id
+	FROM secrets
+	WHERE project_id=$1 AND name=$2
+	`, projectID, name).Scan(&ciphertext, &wrappedKey, &keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	value, err := open(ctx, s.Keys, ciphertext, wrappedKey, keyID, aad(projectID, name))
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", name, err)
+	}
+	return string(value), nil
+}
+
+func (s *PostgresStore) List(ctx context.Context, projectID string) ([]*Secret, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+	SELECT project_id, name, key_id, created_at, updated_at
+	FROM secrets
+	WHERE project_id=$1
+	ORDER BY name
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Secret
+	for rows.Next() {
+		var sec Secret
+		if err := rows.Scan(&sec.ProjectID, &sec.Name, &sec.KeyID, &sec.CreatedAt, &sec.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, &sec)
+	}
+	return out, rows.Err()
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, projectID, name string) error {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM secrets WHERE project_id=$1 AND name=$2`, projectID, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return nil
+}
+
+func aad(projectID, name string) []byte {
+	return []byte(projectID + "/" + name)
+}
@@ -0,0 +1,161 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secret values in recorded inputs, outputs, errors and
+// logs.
+const Redacted = "***"
+
+// minRedactLength keeps very short values, which would also match
+// unrelated text, from being redacted.
+const minRedactLength = 4
+
+// Resolver resolves secrets.NAME references for one node execution and
+// remembers the values it handed out, so they can be redacted from
+// everything the node records. It implements expr.Resolver.
+type Resolver struct {
+	ctx       context.Context
+	store     Store
+	projectID string
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// NewResolver returns a resolver for projectID. With a nil store every
+// reference fails, so a missing configuration does not go unnoticed.
+func NewResolver(ctx context.Context, store Store, projectID string) *Resolver {
+	return &Resolver{
+		ctx:       ctx,
+		store:     store,
+		projectID: projectID,
+		values:    map[string]string{},
+	}
+}
+
+func (r *Resolver) Resolve(name string) (interface{}, error) {
+	r.mu.Lock()
+	v, ok := r.values[name]
+	r.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	if r.store == nil {
+		return nil, fmt.Errorf("secrets.%s: secrets are not configured on this worker", name)
+	}
+	v, err := r.store.Get(r.ctx, r.projectID, name)
+	if err != nil {
+		return nil, fmt.Errorf("secrets.%s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	r.values[name] = v
+	r.mu.Unlock()
+	return v, nil
+}
+
+// RedactString replaces resolved secret values in s.
+func (r *Resolver) RedactString(s string) string {
+	for _, v := range r.redactable() {
+		s = strings.ReplaceAll(s, v, Redacted)
+	}
+	return s
+}
+
+// Redact returns a copy of v with resolved secret values replaced in all
+// strings of nested maps and lists.
+func (r *Resolver) Redact(v interface{}) interface{} {
+	values := r.redactable()
+	if len(values) == 0 {
+		return v
+	}
+	return redact(v, values)
+}
+
+// RedactMap is Redact for the common map case.
+func (r *Resolver) RedactMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	return r.Redact(m).(map[string]interface{})
+}
+
+// RedactError hides resolved secret values in err's message. The original
+// error stays available to errors.Is and errors.As.
+func (r *Resolver) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := r.RedactString(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+// redactable returns the values to redact, longest first so a secret that
+// contains another one is replaced as a whole.
+func (r *Resolver) redactable() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []string
+	for _, v := range r.values {
+		if len(v) >= minRedactLength {
+			out = append(out, v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return len(out[i]) > len(out[j]) })
+	return out
+}
+
+func redact(v interface{}, values []string) interface{} {
+	switch val := v.(type) {
+	case string:
+		for _, s := range values {
+			val = strings.ReplaceAll(val, s, Redacted)
+		}
+		return val
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = redact(item, values)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = redact(item, values)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for k, item := range val {
+			out[k] = redact(item, values).(string)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = redact(item, values).(string)
+		}
+		return out
+	}
+	return v
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,146 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// mapStore is a Store over plain values, for resolver tests.
+type mapStore struct {
+	values map[string]string
+	gets   int
+}
+
+func (s *mapStore) Put(ctx context.Context, projectID, name, value string) (*Secret, error) {
+	s.values[name] = value
+	return &Secret{ProjectID: projectID, Name: name}, nil
+}
+
+func (s *mapStore) Get(ctx context.Context, projectID, name string) (string, error) {
+	s.gets++
+	v, ok := s.values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (s *mapStore) List(ctx context.Context, projectID string) ([]*Secret, error) {
+	return nil, nil
+}
+
+func (s *mapStore) Delete(ctx context.Context, projectID, name string) error {
+	delete(s.values, name)
+	return nil
+}
+
+func newTestResolver(t *testing.T, names ...string) *Resolver {
+	t.Helper()
+	store := &mapStore{values: map[string]string{
+		"TOKEN":     "tok-123",
+		"LONGTOKEN": "tok-123-extra",
+		"PIN":       "123",
+	}}
+	r := NewResolver(context.Background(), store, "p1")
+	for _, name := range names {
+		if _, err := r.Resolve(name); err != nil {
+			t.Fatalf("Resolve(%s): %v", name, err)
+		}
+	}
+	return r
+}
+
+func TestResolve(t *testing.T) {
+	store := &mapStore{values: map[string]string{"TOKEN": "tok-123"}}
+	r := NewResolver(context.Background(), store, "p1")
+
+	for i := 0; i < 2; i++ {
+		v, err := r.Resolve("TOKEN")
+		if err != nil || v != "tok-123" {
+			t.Fatalf("Resolve = %v, %v", v, err)
+		}
+	}
+	if store.gets != 1 {
+		t.Errorf("store was read %d times, want 1", store.gets)
+	}
+
+	_, err := r.Resolve("MISSING")
+	if !errors.Is(err, ErrNotFound) || err.Error() != "secrets.MISSING: secret not found" {
+		t.Errorf("missing secret: error = %v", err)
+	}
+
+	_, err = NewResolver(context.Background(), nil, "p1").Resolve("TOKEN")
+	if err == nil || err.Error() != "secrets.TOKEN: secrets are not configured on this worker" {
+		t.Errorf("nil store: error = %v", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	r := newTestResolver(t, "TOKEN", "LONGTOKEN", "PIN")
+
+	in := map[string]interface{}{
+		"header": "Bearer tok-123",
+		"nested": map[string]interface{}{
+			"list": []interface{}{"tok-123-extra", 42, map[string]interface{}{"k": "x tok-123 y"}},
+		},
+		"env":  map[string]string{"TOKEN": "tok-123"},
+		"args": []string{"--token", "tok-123"},
+		// shorter than minRedactLength
+		"pin": "123",
+	}
+	want := map[string]interface{}{
+		"header": "Bearer ***",
+		"nested": map[string]interface{}{
+			"list": []interface{}{"***", 42, map[string]interface{}{"k": "x *** y"}},
+		},
+		"env":  map[string]string{"TOKEN": "***"},
+		"args": []string{"--token", "***"},
+		"pin":  "123",
+	}
+
+	if got := r.RedactMap(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactMap = %v, want %v", got, want)
+	}
+	if in["header"] != "Bearer tok-123" {
+		t.Error("Redact changed its input")
+	}
+	if got := r.RedactString("tok-123-extra and tok-123"); got != "*** and ***" {
+		t.Errorf("RedactString = %q", got)
+	}
+	if r.RedactMap(nil) != nil {
+		t.Error("RedactMap(nil) is not nil")
+	}
+}
+
+func TestRedactNothingResolved(t *testing.T) {
+	r := newTestResolver(t)
+
+	in := map[string]interface{}{"header": "Bearer tok-123"}
+	if got := r.RedactMap(in); !reflect.DeepEqual(got, in) {
+		t.Errorf("RedactMap = %v, want %v", got, in)
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	r := newTestResolver(t, "TOKEN")
+	cause := errors.New("401 for token tok-123")
+
+	err := r.RedactError(fmt.Errorf("call: %w", cause))
+	if err.Error() != "call: 401 for token ***" {
+		t.Errorf("error = %q", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("redacted error does not wrap the original")
+	}
+
+	clean := errors.New("timeout")
+	if r.RedactError(clean) != clean {
+		t.Error("an error without secrets was wrapped")
+	}
+	if r.RedactError(nil) != nil {
+		t.Error("RedactError(nil) is not nil")
+	}
+}
@@ -0,0 +1,49 @@
+// Package secrets stores project-scoped secrets, encrypted at rest, and
+// resolves the ${{ secrets.NAME }} references of workflows and modules on
+// the worker.
+//
+// Each secret value is encrypted with its own data key (AES-256-GCM); the
+// data key is in turn encrypted ("wrapped") by a KeyProvider, so the key
+// that protects the database never leaves the provider.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ErrNotFound is returned for a secret the project does not have.
+var ErrNotFound = errors.New("secret not found")
+
+// Secret describes a stored secret. Its value is never returned by the API.
+type Secret struct {
+	ProjectID string
+	Name      string
+	// KeyID identifies the provider key that wrapped the data key
+	KeyID     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Store persists secrets encrypted.
+type Store interface {
+	// Put creates or replaces a secret
+	Put(ctx context.Context, projectID, name, value string) (*Secret, error)
+	// Get decrypts a single secret
+	Get(ctx context.Context, projectID, name string) (string, error)
+	List(ctx context.Context, projectID string) ([]*Secret, error)
+	Delete(ctx context.Context, projectID, name string) error
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateName checks that name can be referenced as secrets.NAME.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: use letters, digits and underscores, not starting with a digit", name)
+	}
+	return nil
+}
@@ -2,10 +2,21 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/prashantsinghb/workflow-engine/api/service"
 	"github.com/prashantsinghb/workflow-engine/pkg/module/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/contract"
 	"github.com/prashantsinghb/workflow-engine/pkg/module/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/secrets"
+	wfapi "github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor/container"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/expr"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/validation"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -27,6 +38,60 @@ func (s *ModuleServer) RegisterModule(ctx context.Context, req *service.Register
 		outputs[k] = val
 	}
 
+	if _, err := contract.Parse(inputs); err != nil {
+		return nil, fmt.Errorf("invalid inputs contract: %w", err)
+	}
+	if _, err := contract.Parse(outputs); err != nil {
+		return nil, fmt.Errorf("invalid outputs contract: %w", err)
+	}
+
+	if spec := req.GetHttp(); spec != nil {
+		if err := validateHttpSpec(spec); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec := req.GetContainerRegistry(); spec != nil {
+		if spec.Image == "" {
+			return nil, fmt.Errorf("container image is required")
+		}
+		if _, err := container.ParseCPU(spec.Cpu); err != nil {
+			return nil, err
+		}
+		if _, err := container.ParseMemory(spec.Memory); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec := req.GetExec(); spec != nil {
+		if (spec.Command == "") == (spec.Script == "") {
+			return nil, fmt.Errorf("exec module requires exactly one of command or script")
+		}
+		if spec.TimeoutMs < 0 {
+			return nil, fmt.Errorf("exec timeout_ms must not be negative")
+		}
+		if err := executor.ValidateExecOutputFormat(spec.OutputFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec := req.GetGrpc(); spec != nil {
+		if err := validateGrpcSpec(spec); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateCredentials(req); err != nil {
+		return nil, err
+	}
+
+	defaults := nodeDefaultsFromProto(req.Defaults)
+	if defaults != nil {
+		if err := validation.ValidateNodeDefaults(defaults.Timeout, defaults.Retry); err != nil {
+			return nil, fmt.Errorf("invalid defaults: %w", err)
+		}
+	}
+
 	// Treat "global" as empty string for global modules
 	projectID := req.ProjectId
 	if projectID == "global" {
@@ -40,6 +105,7 @@ func (s *ModuleServer) RegisterModule(ctx context.Context, req *service.Register
 		Runtime:   req.Runtime,
 		Inputs:    inputs,
 		Outputs:   outputs,
+		Defaults:  defaults,
 	}
 
 	id, err := s.Registry.Register(ctx, module)
@@ -63,6 +129,22 @@ func (s *ModuleServer) RegisterModule(ctx context.Context, req *service.Register
 		}
 	}
 
+	// Save exec spec if present
+	if req.Runtime == "exec" && req.GetExec() != nil {
+		postgresReg := s.Registry.GetStore()
+		if err := postgresReg.InsertExecSpec(ctx, id, req.GetExec()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Save gRPC spec if present
+	if req.Runtime == "grpc" && req.GetGrpc() != nil {
+		postgresReg := s.Registry.GetStore()
+		if err := postgresReg.InsertGrpcSpec(ctx, id, req.GetGrpc()); err != nil {
+			return nil, err
+		}
+	}
+
 	return &service.RegisterModuleResponse{ModuleId: id}, nil
 }
 
@@ -89,6 +171,7 @@ func (s *ModuleServer) GetModule(
 		Name:      m.Name,
 		Version:   m.Version,
 		Runtime:   m.Runtime,
+		Defaults:  nodeDefaultsToProto(m.Defaults),
 	}
 
 	// Load spec from appropriate table
@@ -100,7 +183,7 @@ func (s *ModuleServer) GetModule(
 		}
 		if httpSpec != nil {
 			serviceModule.Spec = &service.Module_Http{
-				Http: httpSpec,
+				Http: maskHttpAuth(httpSpec),
 			}
 		} else {
 			serviceModule.Spec = &service.Module_Http{
@@ -114,13 +197,41 @@ func (s *ModuleServer) GetModule(
 		}
 		if containerSpec != nil {
 			serviceModule.Spec = &service.Module_ContainerRegistry{
-				ContainerRegistry: containerSpec,
+				ContainerRegistry: maskContainerSpec(containerSpec),
 			}
 		} else {
 			serviceModule.Spec = &service.Module_ContainerRegistry{
 				ContainerRegistry: &service.ContainerRegistryModuleSpec{},
 			}
 		}
+	} else if m.Runtime == "exec" {
+		execSpec, err := postgresReg.GetExecSpec(ctx, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		if execSpec != nil {
+			serviceModule.Spec = &service.Module_Exec{
+				Exec: maskExecSpec(execSpec),
+			}
+		} else {
+			serviceModule.Spec = &service.Module_Exec{
+				Exec: &service.ExecModuleSpec{},
+			}
+		}
+	} else if m.Runtime == "grpc" {
+		grpcSpec, err := postgresReg.GetGrpcSpec(ctx, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		if grpcSpec != nil {
+			serviceModule.Spec = &service.Module_Grpc{
+				Grpc: maskGrpcSpec(grpcSpec),
+			}
+		} else {
+			serviceModule.Spec = &service.Module_Grpc{
+				Grpc: &service.GrpcModuleSpec{},
+			}
+		}
 	}
 
 	if inputs != nil {
@@ -158,6 +269,7 @@ func (s *ModuleServer) ListModules(
 			Name:      m.Name,
 			Version:   m.Version,
 			Runtime:   m.Runtime,
+			Defaults:  nodeDefaultsToProto(m.Defaults),
 		}
 
 		// Load spec from appropriate table
@@ -166,7 +278,7 @@ func (s *ModuleServer) ListModules(
 			httpSpec, err := postgresReg.GetHttpSpec(ctx, m.ID)
 			if err == nil && httpSpec != nil {
 				serviceModule.Spec = &service.Module_Http{
-					Http: httpSpec,
+					Http: maskHttpAuth(httpSpec),
 				}
 			} else {
 				serviceModule.Spec = &service.Module_Http{
@@ -177,13 +289,35 @@ func (s *ModuleServer) ListModules(
 			containerSpec, err := postgresReg.GetContainerSpec(ctx, m.ID)
 			if err == nil && containerSpec != nil {
 				serviceModule.Spec = &service.Module_ContainerRegistry{
-					ContainerRegistry: containerSpec,
+					ContainerRegistry: maskContainerSpec(containerSpec),
 				}
 			} else {
 				serviceModule.Spec = &service.Module_ContainerRegistry{
 					ContainerRegistry: &service.ContainerRegistryModuleSpec{},
 				}
 			}
+		} else if m.Runtime == "exec" {
+			execSpec, err := postgresReg.GetExecSpec(ctx, m.ID)
+			if err == nil && execSpec != nil {
+				serviceModule.Spec = &service.Module_Exec{
+					Exec: maskExecSpec(execSpec),
+				}
+			} else {
+				serviceModule.Spec = &service.Module_Exec{
+					Exec: &service.ExecModuleSpec{},
+				}
+			}
+		} else if m.Runtime == "grpc" {
+			grpcSpec, err := postgresReg.GetGrpcSpec(ctx, m.ID)
+			if err == nil && grpcSpec != nil {
+				serviceModule.Spec = &service.Module_Grpc{
+					Grpc: maskGrpcSpec(grpcSpec),
+				}
+			} else {
+				serviceModule.Spec = &service.Module_Grpc{
+					Grpc: &service.GrpcModuleSpec{},
+				}
+			}
 		}
 
 		if inputs != nil {
@@ -205,3 +339,265 @@ func structpbToInterface(v *structpb.Value) (interface{}, error) {
 	}
 	return v.AsInterface(), nil
 }
+
+// validateGrpcSpec checks a gRPC module before it is stored. A descriptor
+// set must contain the method; without one it is resolved by reflection
+// when the module first runs.
+func nodeDefaultsFromProto(d *service.NodeDefaults) *wfapi.NodeDefaults {
+	if d == nil {
+		return nil
+	}
+	out := &wfapi.NodeDefaults{Timeout: d.Timeout}
+	if r := d.Retry; r != nil {
+		out.Retry = &wfapi.RetryPolicy{
+			MaxAttempts:        int(r.MaxAttempts),
+			InitialInterval:    r.InitialInterval,
+			Backoff:            r.Backoff,
+			MaxInterval:        r.MaxInterval,
+			NonRetryableErrors: r.NonRetryableErrors,
+		}
+	}
+	return out
+}
+
+func nodeDefaultsToProto(d *wfapi.NodeDefaults) *service.NodeDefaults {
+	if d == nil {
+		return nil
+	}
+	out := &service.NodeDefaults{Timeout: d.Timeout}
+	if r := d.Retry; r != nil {
+		out.Retry = &service.RetryPolicy{
+			MaxAttempts:        int32(r.MaxAttempts),
+			InitialInterval:    r.InitialInterval,
+			Backoff:            r.Backoff,
+			MaxInterval:        r.MaxInterval,
+			NonRetryableErrors: r.NonRetryableErrors,
+		}
+	}
+	return out
+}
+
+func validateGrpcSpec(spec *service.GrpcModuleSpec) error {
+	if spec.Target == "" {
+		return fmt.Errorf("grpc target is required")
+	}
+	if _, _, err := executor.ParseGrpcMethod(spec.Method); err != nil {
+		return err
+	}
+	if len(spec.DescriptorSet) > 0 {
+		if _, err := executor.GrpcMethodFromDescriptorSet(spec.DescriptorSet, spec.Method); err != nil {
+			return err
+		}
+	}
+	if _, err := executor.GrpcCredentials(spec.Tls); err != nil {
+		return err
+	}
+	if spec.TimeoutMs < 0 {
+		return fmt.Errorf("grpc timeout_ms must not be negative")
+	}
+	if spec.RequestTemplate != nil {
+		if err := validation.ValidateBodyTemplate(spec.RequestTemplate.AsMap()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maskHttpAuth hides credentials stored in plain text, by modules
+// registered before they were rejected, before a spec is returned.
+// ${{ secrets.NAME }} references are not sensitive and are kept, so
+// clients can tell which secret a module uses.
+func maskHttpAuth(spec *service.HttpModuleSpec) *service.HttpModuleSpec {
+	for _, c := range authCredentials(spec.Auth) {
+		if *c.value != "" && !expr.IsTemplate(*c.value) {
+			*c.value = secrets.Redacted
+		}
+	}
+	maskCredentialValues(spec.Headers)
+	return spec
+}
+
+func maskContainerSpec(spec *service.ContainerRegistryModuleSpec) *service.ContainerRegistryModuleSpec {
+	maskCredentialValues(spec.Env)
+	return spec
+}
+
+func maskExecSpec(spec *service.ExecModuleSpec) *service.ExecModuleSpec {
+	maskCredentialValues(spec.Env)
+	return spec
+}
+
+func maskGrpcSpec(spec *service.GrpcModuleSpec) *service.GrpcModuleSpec {
+	maskCredentialValues(spec.Metadata)
+	return spec
+}
+
+// maskCredentialValues hides the plain text values of credential-named
+// keys of headers, env vars or metadata.
+func maskCredentialValues(m map[string]string) {
+	for k, v := range m {
+		if isCredentialKey(k) && v != "" && !expr.IsTemplate(v) {
+			m[k] = secrets.Redacted
+		}
+	}
+}
+
+type credential struct {
+	field string
+	value *string
+}
+
+// authCredentials returns the secret fields of auth.
+func authCredentials(auth *service.HttpAuth) []credential {
+	if auth == nil || auth.Type == nil {
+		return nil
+	}
+
+	switch v := auth.Type.(type) {
+	case *service.HttpAuth_Bearer:
+		if v.Bearer != nil {
+			return []credential{{"auth.bearer.token", &v.Bearer.Token}}
+		}
+	case *service.HttpAuth_ApiKey:
+		if v.ApiKey != nil {
+			return []credential{{"auth.api_key.value", &v.ApiKey.Value}}
+		}
+	case *service.HttpAuth_Basic:
+		if v.Basic != nil {
+			return []credential{{"auth.basic.password", &v.Basic.Password}}
+		}
+	case *service.HttpAuth_Oauth2:
+		if v.Oauth2 != nil {
+			return []credential{
+				{"auth.oauth2.client_secret", &v.Oauth2.ClientSecret},
+				{"auth.oauth2.refresh_token", &v.Oauth2.RefreshToken},
+			}
+		}
+	case *service.HttpAuth_Hmac:
+		if v.Hmac != nil {
+			return []credential{{"auth.hmac.secret", &v.Hmac.Secret}}
+		}
+	}
+	return nil
+}
+
+// credentialKeyParts mark header, env var and metadata names that carry
+// credentials, e.g. Authorization, X-Api-Key or DB_PASSWORD.
+var credentialKeyParts = []string{
+	"authorization", "token", "secret", "password", "passwd",
+	"api_key", "api-key", "apikey", "credential", "private_key",
+}
+
+func isCredentialKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, part := range credentialKeyParts {
+		if strings.Contains(k, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCredentials rejects credentials written into a module in plain
+// text: auth secrets, and headers, env vars and gRPC metadata with
+// credential names, must reference a project secret. Module specs are
+// stored unencrypted and returned by GetModule.
+func validateCredentials(req *service.RegisterModuleRequest) error {
+	var creds []credential
+	addMap := func(field string, m map[string]string) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			if isCredentialKey(k) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := m[k]
+			creds = append(creds, credential{field + " " + k, &v})
+		}
+	}
+
+	if spec := req.GetHttp(); spec != nil {
+		creds = append(creds, authCredentials(spec.Auth)...)
+		addMap("header", spec.Headers)
+	}
+	if spec := req.GetContainerRegistry(); spec != nil {
+		addMap("env", spec.Env)
+	}
+	if spec := req.GetExec(); spec != nil {
+		addMap("env", spec.Env)
+	}
+	if spec := req.GetGrpc(); spec != nil {
+		addMap("metadata", spec.Metadata)
+	}
+
+	for _, c := range creds {
+		if *c.value == "" {
+			continue
+		}
+		ok, err := referencesSecret(*c.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.field, err)
+		}
+		if !ok {
+			return fmt.Errorf("%s must reference a project secret, e.g. ${{ secrets.NAME }}", c.field)
+		}
+	}
+	return nil
+}
+
+// referencesSecret reports whether the template s reads a project secret.
+func referencesSecret(s string) (bool, error) {
+	exprs, err := expr.TemplateExpressions(s)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range exprs {
+		for _, ref := range e.References() {
+			if ref[0] == "secrets" {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// validateHttpSpec checks the templates and status lists of an HTTP module.
+func validateHttpSpec(spec *service.HttpModuleSpec) error {
+	if spec.BodyTemplate != nil {
+		if err := validation.ValidateBodyTemplate(spec.BodyTemplate.AsMap()); err != nil {
+			return err
+		}
+	}
+
+	// url, headers and query params are templated like the body
+	templated := map[string]interface{}{"url": spec.Url}
+	for k, v := range spec.Headers {
+		templated["header "+k] = v
+	}
+	for k, v := range spec.QueryParams {
+		templated["query param "+k] = v
+	}
+	if err := validation.ValidateBodyTemplate(templated); err != nil {
+		return err
+	}
+
+	for _, code := range append(spec.RetryOnStatus, spec.SuccessCodes...) {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid HTTP status code %d", code)
+		}
+	}
+	for _, code := range spec.RetryOnStatus {
+		if slices.Contains(spec.SuccessCodes, code) {
+			return fmt.Errorf("status %d is both a success code and retried", code)
+		}
+	}
+	if spec.RetryCount < 0 || spec.RetryCount > registry.MaxRetryCount {
+		return fmt.Errorf("retry_count must be between 0 and %d", registry.MaxRetryCount)
+	}
+	if spec.RetryBackoffMs < 0 {
+		return fmt.Errorf("retry_backoff_ms must not be negative")
+	}
+	return executor.ValidateHttpAuth(spec.Auth)
+}
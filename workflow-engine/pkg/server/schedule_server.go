@@ -0,0 +1,319 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	service "github.com/prashantsinghb/workflow-engine/api/service"
+	wfexecution "github.com/prashantsinghb/workflow-engine/pkg/workflow/execution"
+	wfregistry "github.com/prashantsinghb/workflow-engine/pkg/workflow/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/temporal"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/validation"
+)
+
+// ScheduleServer manages cron and interval schedules. Schedules live in
+// Temporal; each run they fire is recorded as an execution with trigger
+// type "schedule" and the schedule's ID.
+type ScheduleServer struct {
+	service.UnimplementedScheduleServiceServer
+
+	wfStore wfregistry.WorkflowStore
+	// engine is the configured execution engine
+	engine string
+}
+
+func NewScheduleServer(wfStore wfregistry.WorkflowStore, engine string) *ScheduleServer {
+	return &ScheduleServer{wfStore: wfStore, engine: engine}
+}
+
+// checkEngine rejects schedule requests unless executions run on Temporal.
+// The embedded engine starts no Temporal workers, so schedules would be
+// accepted but never fire.
+func (s *ScheduleServer) checkEngine() error {
+	if s.engine != wfexecution.EngineTemporal {
+		return status.Errorf(codes.FailedPrecondition, "schedules need the %s engine, but the %s engine is configured", wfexecution.EngineTemporal, s.engine)
+	}
+	return nil
+}
+
+func (s *ScheduleServer) CreateSchedule(
+	ctx context.Context,
+	req *service.CreateScheduleRequest,
+) (*service.CreateScheduleResponse, error) {
+
+	if err := s.checkEngine(); err != nil {
+		return nil, err
+	}
+
+	if req.Schedule == nil {
+		return nil, fmt.Errorf("schedule is required")
+	}
+	sched, err := s.fromProto(ctx, req.ProjectId, req.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	if sched.ID == "" {
+		sched.ID = uuid.NewString()
+	}
+
+	tc, err := temporal.GetClientForProject(req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if err := temporal.CreateSchedule(ctx, tc, req.ProjectId, sched); err != nil {
+		return nil, fmt.Errorf("create schedule: %w", err)
+	}
+
+	created, err := s.describe(ctx, tc, sched.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &service.CreateScheduleResponse{Schedule: created}, nil
+}
+
+func (s *ScheduleServer) GetSchedule(
+	ctx context.Context,
+	req *service.GetScheduleRequest,
+) (*service.GetScheduleResponse, error) {
+
+	if err := s.checkEngine(); err != nil {
+		return nil, err
+	}
+
+	tc, err := temporal.GetClientForProject(req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	sched, err := s.describe(ctx, tc, req.ScheduleId)
+	if err != nil {
+		return nil, err
+	}
+	return &service.GetScheduleResponse{Schedule: sched}, nil
+}
+
+func (s *ScheduleServer) ListSchedules(
+	ctx context.Context,
+	req *service.ListSchedulesRequest,
+) (*service.ListSchedulesResponse, error) {
+
+	if err := s.checkEngine(); err != nil {
+		return nil, err
+	}
+
+	tc, err := temporal.GetClientForProject(req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	list, err := temporal.ListSchedules(ctx, tc, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &service.ListSchedulesResponse{}
+	for _, sched := range list {
+		pb, err := toScheduleProto(sched)
+		if err != nil {
+			return nil, err
+		}
+		resp.Schedules = append(resp.Schedules, pb)
+	}
+	return resp, nil
+}
+
+// UpdateSchedule replaces the schedule's timing, inputs and overlap
+// policy. Use PauseSchedule and ResumeSchedule to pause it.
+func (s *ScheduleServer) UpdateSchedule(
+	ctx context.Context,
+	req *service.UpdateScheduleRequest,
+) (*service.UpdateScheduleResponse, error) {
+
+	if err := s.checkEngine(); err != nil {
+		return nil, err
+	}
+
+	if req.Schedule == nil {
+		return nil, fmt.Errorf("schedule is required")
+	}
+	sched, err := s.fromProto(ctx, req.ProjectId, req.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	sched.ID = req.ScheduleId
+
+	tc, err := temporal.GetClientForProject(req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if err := temporal.UpdateSchedule(ctx, tc, req.ProjectId, sched); err != nil {
+		return nil, fmt.Errorf("update schedule %s: %w", req.ScheduleId, err)
+	}
+
+	updated, err := s.describe(ctx, tc, req.ScheduleId)
+	if err != nil {
+		return nil, err
+	}
+	return &service.UpdateScheduleResponse{Schedule: updated}, nil
+}
+
+func (s *ScheduleServer) DeleteSchedule(
+	ctx context.Context,
+	req *service.DeleteScheduleRequest,
+) (*service.DeleteScheduleResponse, error) {
+
+	if err := s.checkEngine(); err != nil {
+		return nil, err
+	}
+
+	tc, err := temporal.GetClientForProject(req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if err := temporal.DeleteSchedule(ctx, tc, req.ScheduleId); err != nil {
+		return nil, fmt.Errorf("delete schedule %s: %w", req.ScheduleId, err)
+	}
+	return &service.DeleteScheduleResponse{}, nil
+}
+
+func (s *ScheduleServer) PauseSchedule(
+	ctx context.Context,
+	req *service.PauseScheduleRequest,
+) (*service.PauseScheduleResponse, error) {
+
+	if err := s.checkEngine(); err != nil {
+		return nil, err
+	}
+
+	tc, err := temporal.GetClientForProject(req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if err := temporal.PauseSchedule(ctx, tc, req.ScheduleId, req.Note); err != nil {
+		return nil, fmt.Errorf("pause schedule %s: %w", req.ScheduleId, err)
+	}
+
+	sched, err := s.describe(ctx, tc, req.ScheduleId)
+	if err != nil {
+		return nil, err
+	}
+	return &service.PauseScheduleResponse{Schedule: sched}, nil
+}
+
+func (s *ScheduleServer) ResumeSchedule(
+	ctx context.Context,
+	req *service.ResumeScheduleRequest,
+) (*service.ResumeScheduleResponse, error) {
+
+	if err := s.checkEngine(); err != nil {
+		return nil, err
+	}
+
+	tc, err := temporal.GetClientForProject(req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if err := temporal.ResumeSchedule(ctx, tc, req.ScheduleId, req.Note); err != nil {
+		return nil, fmt.Errorf("resume schedule %s: %w", req.ScheduleId, err)
+	}
+
+	sched, err := s.describe(ctx, tc, req.ScheduleId)
+	if err != nil {
+		return nil, err
+	}
+	return &service.ResumeScheduleResponse{Schedule: sched}, nil
+}
+
+func (s *ScheduleServer) describe(
+	ctx context.Context,
+	tc *temporal.Client,
+	scheduleID string,
+) (*service.Schedule, error) {
+	sched, err := temporal.DescribeSchedule(ctx, tc, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("schedule %s: %w", scheduleID, err)
+	}
+	return toScheduleProto(sched)
+}
+
+// fromProto converts and validates a schedule. Inputs are checked against
+// the workflow now, so a bad schedule is rejected instead of failing on
+// every run.
+func (s *ScheduleServer) fromProto(
+	ctx context.Context,
+	projectID string,
+	pb *service.Schedule,
+) (*temporal.Schedule, error) {
+
+	sched := &temporal.Schedule{
+		ID:         pb.Id,
+		WorkflowID: pb.WorkflowId,
+		Cron:       pb.Cron,
+		Interval:   pb.Interval.AsDuration(),
+		Timezone:   pb.Timezone,
+		Jitter:     pb.Jitter.AsDuration(),
+		Overlap:    pb.Overlap,
+		Paused:     pb.Paused,
+		Note:       pb.Note,
+		Inputs:     make(map[string]interface{}, len(pb.Inputs)),
+	}
+	for k, v := range pb.Inputs {
+		if v != nil {
+			sched.Inputs[k] = v.AsInterface()
+		}
+	}
+
+	if err := temporal.ValidateSchedule(sched); err != nil {
+		return nil, err
+	}
+
+	wf, err := s.wfStore.Get(ctx, projectID, sched.WorkflowID)
+	if err != nil {
+		return nil, err
+	}
+	if wf.Def != nil {
+		if _, err := validation.ValidateInputs(wf.Def.Inputs, sched.Inputs); err != nil {
+			return nil, err
+		}
+	}
+	return sched, nil
+}
+
+func toScheduleProto(sched *temporal.Schedule) (*service.Schedule, error) {
+	pb := &service.Schedule{
+		Id:         sched.ID,
+		WorkflowId: sched.WorkflowID,
+		Cron:       sched.Cron,
+		Timezone:   sched.Timezone,
+		Overlap:    sched.Overlap,
+		Paused:     sched.Paused,
+		Note:       sched.Note,
+		Inputs:     make(map[string]*structpb.Value, len(sched.Inputs)),
+		CreatedAt:  timestamppb.New(sched.CreatedAt),
+	}
+	if sched.Interval > 0 {
+		pb.Interval = durationpb.New(sched.Interval)
+	}
+	if sched.Jitter > 0 {
+		pb.Jitter = durationpb.New(sched.Jitter)
+	}
+	if !sched.UpdatedAt.IsZero() {
+		pb.UpdatedAt = timestamppb.New(sched.UpdatedAt)
+	}
+	for _, t := range sched.NextRunTimes {
+		pb.NextRunTimes = append(pb.NextRunTimes, timestamppb.New(t))
+	}
+	for k, v := range sched.Inputs {
+		val, err := structpb.NewValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("schedule input %s: %w", k, err)
+		}
+		pb.Inputs[k] = val
+	}
+	return pb, nil
+}
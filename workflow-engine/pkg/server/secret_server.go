@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/prashantsinghb/workflow-engine/api/service"
+	"github.com/prashantsinghb/workflow-engine/pkg/secrets"
+)
+
+// SecretServer manages a project's secrets. Values can be written but are
+// never returned; workflows and modules use them as ${{ secrets.NAME }}.
+type SecretServer struct {
+	service.UnimplementedSecretServiceServer
+	Store secrets.Store
+}
+
+func NewSecretServer(store secrets.Store) *SecretServer {
+	return &SecretServer{Store: store}
+}
+
+func (s *SecretServer) PutSecret(
+	ctx context.Context,
+	req *service.PutSecretRequest,
+) (*service.PutSecretResponse, error) {
+	if req.ProjectId == "" {
+		return nil, fmt.Errorf("project_id is required")
+	}
+	if err := secrets.ValidateName(req.Name); err != nil {
+		return nil, err
+	}
+
+	sec, err := s.Store.Put(ctx, req.ProjectId, req.Name, req.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &service.PutSecretResponse{Secret: toSecretInfo(sec)}, nil
+}
+
+func (s *SecretServer) ListSecrets(
+	ctx context.Context,
+	req *service.ListSecretsRequest,
+) (*service.ListSecretsResponse, error) {
+	list, err := s.Store.List(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &service.ListSecretsResponse{}
+	for _, sec := range list {
+		resp.Secrets = append(resp.Secrets, toSecretInfo(sec))
+	}
+	return resp, nil
+}
+
+func (s *SecretServer) DeleteSecret(
+	ctx context.Context,
+	req *service.DeleteSecretRequest,
+) (*service.DeleteSecretResponse, error) {
+	if err := s.Store.Delete(ctx, req.ProjectId, req.Name); err != nil {
+		return nil, err
+	}
+	return &service.DeleteSecretResponse{}, nil
+}
+
+func toSecretInfo(sec *secrets.Secret) *service.SecretInfo {
+	return &service.SecretInfo{
+		Name:      sec.Name,
+		KeyId:     sec.KeyID,
+		CreatedAt: timestamppb.New(sec.CreatedAt),
+		UpdatedAt: timestamppb.New(sec.UpdatedAt),
+	}
+}
@@ -3,41 +3,58 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
-	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	service "github.com/prashantsinghb/workflow-engine/api/service"
 	"github.com/prashantsinghb/workflow-engine/pkg/execution"
 	moduleregistry "github.com/prashantsinghb/workflow-engine/pkg/module/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+	wfexecution "github.com/prashantsinghb/workflow-engine/pkg/workflow/execution"
 	"github.com/prashantsinghb/workflow-engine/pkg/workflow/parser"
 	wfregistry "github.com/prashantsinghb/workflow-engine/pkg/workflow/registry"
-	"github.com/prashantsinghb/workflow-engine/pkg/workflow/temporal"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
 	"github.com/prashantsinghb/workflow-engine/pkg/workflow/validation"
 )
 
 type WorkflowServer struct {
 	service.UnimplementedWorkflowServiceServer
 
-	execStore execution.ExecutionStore
-	wfStore   wfregistry.WorkflowStore
-	modules   *moduleregistry.ModuleRegistry
-	validator *validation.WorkflowValidator
+	execStore  execution.ExecutionStore
+	nodeStore  execution.NodeStore
+	eventStore execution.EventStore
+	wfStore    wfregistry.WorkflowStore
+	modules    *moduleregistry.ModuleRegistry
+	validator  *validation.WorkflowValidator
+	// backend runs executions: on Temporal, or embedded in-process
+	backend wfexecution.ExecutionBackend
+	// dispatcher starts the executions enqueued in the outbox
+	dispatcher *wfexecution.Dispatcher
 }
 
 func NewWorkflowService(
-	execStore execution.ExecutionStore,
+	store execution.Store,
 	wfStore wfregistry.WorkflowStore,
 	modules *moduleregistry.ModuleRegistry,
+	backend wfexecution.ExecutionBackend,
+	dispatcher *wfexecution.Dispatcher,
 ) *WorkflowServer {
 	return &WorkflowServer{
-		execStore: execStore,
-		wfStore:   wfStore,
-		modules:   modules,
-		validator: validation.NewWorkflowValidator(),
+		execStore:  store.Executions(),
+		nodeStore:  store.Nodes(),
+		eventStore: store.Events(),
+		wfStore:    wfStore,
+		modules:    modules,
+		validator:  validation.NewWorkflowValidator(),
+		backend:    backend,
+		dispatcher: dispatcher,
 	}
 }
 
@@ -116,6 +133,7 @@ func (s *WorkflowServer) RegisterWorkflow(
 
 	return &service.RegisterWorkflowResponse{
 		WorkflowId: id,
+		Version:    wf.Version,
 	}, nil
 }
 
@@ -133,17 +151,77 @@ func (s *WorkflowServer) ListWorkflows(
 
 	out := make([]*service.WorkflowInfo, 0, len(workflows))
 	for _, wf := range workflows {
-		out = append(out, &service.WorkflowInfo{
-			Id:        wf.ID,
-			Name:      wf.Name,
-			Version:   wf.Version,
-			ProjectId: wf.ProjectID,
-		})
+		out = append(out, toWorkflowInfo(wf))
 	}
 
 	return &service.ListWorkflowsResponse{Workflows: out}, nil
 }
 
+// ListWorkflowVersions returns the versions of a workflow, newest first.
+func (s *WorkflowServer) ListWorkflowVersions(
+	ctx context.Context,
+	req *service.ListWorkflowVersionsRequest,
+) (*service.ListWorkflowVersionsResponse, error) {
+
+	versions, err := s.wfStore.ListVersions(ctx, req.ProjectId, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("workflow %s not found", req.Name)
+	}
+
+	out := make([]*service.WorkflowInfo, 0, len(versions))
+	for _, wf := range versions {
+		out = append(out, toWorkflowInfo(wf))
+	}
+	return &service.ListWorkflowVersionsResponse{Versions: out}, nil
+}
+
+// DeprecateWorkflow marks a version as deprecated, or clears the mark.
+// Deprecated versions are no longer picked as latest; executions already
+// started with them are unaffected.
+func (s *WorkflowServer) DeprecateWorkflow(
+	ctx context.Context,
+	req *service.DeprecateWorkflowRequest,
+) (*service.DeprecateWorkflowResponse, error) {
+
+	wf, err := s.wfStore.SetDeprecated(ctx, req.ProjectId, req.WorkflowId, req.Deprecated, req.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &service.DeprecateWorkflowResponse{Workflow: toWorkflowInfo(wf)}, nil
+}
+
+func toWorkflowInfo(wf *wfregistry.Workflow) *service.WorkflowInfo {
+	return &service.WorkflowInfo{
+		Id:                 wf.ID,
+		Name:               wf.Name,
+		Version:            wf.Version,
+		ProjectId:          wf.ProjectID,
+		Deprecated:         wf.Deprecated,
+		DeprecationMessage: wf.DeprecationMessage,
+		CreatedAt:          timestamppb.New(wf.CreatedAt),
+	}
+}
+
+// resolveWorkflow looks a workflow up by ID, or by name and version when
+// no ID is given. An empty version or "latest" selects the newest version
+// that is not deprecated.
+func (s *WorkflowServer) resolveWorkflow(
+	ctx context.Context,
+	projectID, workflowID, name, version string,
+) (*wfregistry.Workflow, error) {
+
+	if workflowID != "" {
+		return s.wfStore.Get(ctx, projectID, workflowID)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("workflow_id or workflow_name is required")
+	}
+	return s.wfStore.GetByName(ctx, projectID, name, version)
+}
+
 /* ---------------------- GET ---------------------- */
 
 func (s *WorkflowServer) GetWorkflow(
@@ -151,22 +229,66 @@ func (s *WorkflowServer) GetWorkflow(
 	req *service.GetWorkflowRequest,
 ) (*service.GetWorkflowResponse, error) {
 
-	wf, err := s.wfStore.Get(ctx, req.ProjectId, req.WorkflowId)
+	wf, err := s.resolveWorkflow(ctx, req.ProjectId, req.WorkflowId, req.Name, req.Version)
 	if err != nil {
 		return nil, err
 	}
 
+	var inputs []*service.WorkflowInput
+	if wf.Def != nil {
+		inputs, err = toWorkflowInputs(wf.Def.Inputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &service.GetWorkflowResponse{
-		Workflow: &service.WorkflowInfo{
-			Id:        wf.ID,
-			Name:      wf.Name,
-			Version:   wf.Version,
-			ProjectId: wf.ProjectID,
-		},
-		Yaml: wf.Yaml,
+		Workflow: toWorkflowInfo(wf),
+		Yaml:     wf.Yaml,
+		Inputs:   inputs,
 	}, nil
 }
 
+// toWorkflowInputs converts the declared inputs, sorted by name, so clients
+// can render a run form.
+func toWorkflowInputs(schema map[string]api.InputSpec) ([]*service.WorkflowInput, error) {
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]*service.WorkflowInput, 0, len(names))
+	for _, name := range names {
+		spec := schema[name]
+
+		in := &service.WorkflowInput{
+			Name:        name,
+			Type:        spec.Type,
+			Description: spec.Description,
+			Required:    spec.Required,
+			Pattern:     spec.Pattern,
+		}
+		if spec.Default != nil {
+			v, err := structpb.NewValue(spec.Default)
+			if err != nil {
+				return nil, fmt.Errorf("input %s: default: %w", name, err)
+			}
+			in.Default = v
+		}
+		for _, e := range spec.Enum {
+			v, err := structpb.NewValue(e)
+			if err != nil {
+				return nil, fmt.Errorf("input %s: enum: %w", name, err)
+			}
+			in.Enum = append(in.Enum, v)
+		}
+
+		out = append(out, in)
+	}
+	return out, nil
+}
+
 /* ---------------------- START ---------------------- */
 
 func (s *WorkflowServer) StartWorkflow(
@@ -174,13 +296,6 @@ func (s *WorkflowServer) StartWorkflow(
 	req *service.StartWorkflowRequest,
 ) (*service.StartWorkflowResponse, error) {
 
-	temporalWorkflowID := fmt.Sprintf(
-		"%s:%s:%s",
-		req.ProjectId,
-		req.WorkflowId,
-		req.ClientRequestId,
-	)
-
 	inputs := make(map[string]interface{}, len(req.Inputs))
 	for k, v := range req.Inputs {
 		if v != nil {
@@ -188,75 +303,189 @@ func (s *WorkflowServer) StartWorkflow(
 		}
 	}
 
-	exec := &execution.Execution{
-		ID:                 uuid.New(),
-		ProjectID:          req.ProjectId,
-		WorkflowID:         req.WorkflowId,
-		ClientRequestID:    req.ClientRequestId,
-		TemporalWorkflowID: temporalWorkflowID,
-		Status:             execution.ExecutionPending,
-		Inputs:             inputs,
+	wf, err := s.resolveWorkflow(ctx, req.ProjectId, req.WorkflowId, req.WorkflowName, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	exec, err := s.startExecution(ctx, &execution.Execution{
+		ProjectID:       req.ProjectId,
+		WorkflowID:      wf.ID,
+		ClientRequestID: req.ClientRequestId,
+		TriggerType:     execution.TriggerManual,
+		Inputs:          inputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A new execution is PENDING until its workflow has started
+	return &service.StartWorkflowResponse{
+		ExecutionId: exec.ID.String(),
+		State:       string(exec.Status),
+		WorkflowId:  exec.WorkflowID,
+		Version:     exec.Version,
+	}, nil
+}
+
+// startExecution validates exec's inputs and enqueues it for the
+// dispatcher to start on the backend. The execution is pinned to the workflow's current
+// definition. A repeated ClientRequestID returns the existing execution
+// instead, so clients and webhook senders can retry safely.
+func (s *WorkflowServer) startExecution(
+	ctx context.Context,
+	exec *execution.Execution,
+) (*execution.Execution, error) {
+
+	// Reject bad inputs before an execution row exists
+	wf, err := s.wfStore.Get(ctx, exec.ProjectID, exec.WorkflowID)
+	if err != nil {
+		return nil, err
+	}
+	if wf.Def != nil {
+		exec.Inputs, err = validation.ValidateInputs(wf.Def.Inputs, exec.Inputs)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	err := s.execStore.Create(ctx, exec)
+	exec.ID = uuid.New()
+	exec.Version = wf.Version
+	exec.Definition = wf.Yaml
+	exec.TemporalWorkflowID = fmt.Sprintf(
+		"%s:%s:%s",
+		exec.ProjectID,
+		exec.WorkflowID,
+		exec.ClientRequestID,
+	)
+	exec.Status = execution.ExecutionPending
+
+	err = s.execStore.Enqueue(ctx, exec)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			existing, err := s.execStore.GetByIdempotencyKey(
+		if errors.Is(err, execution.ErrDuplicate) {
+			return s.execStore.GetByIdempotencyKey(
 				ctx,
-				req.ProjectId,
-				req.WorkflowId,
-				req.ClientRequestId,
+				exec.ProjectID,
+				exec.WorkflowID,
+				exec.ClientRequestID,
 			)
-			if err != nil {
-				return nil, err
-			}
-			return &service.StartWorkflowResponse{
-				ExecutionId: existing.ID.String(),
-				State:       string(existing.Status),
-			}, nil
 		}
 		return nil, err
 	}
 
-	// Start workflow asynchronously to avoid blocking HTTP request
-	// Use a goroutine to start the workflow in the background
-	go func() {
-		bgCtx := context.Background()
+	s.dispatcher.Notify()
+	return exec, nil
+}
 
-		tc, err := temporal.GetClientForProject(req.ProjectId)
-		if err != nil {
-			// Mark execution as failed if we can't connect to Temporal
-			_ = s.execStore.MarkFailed(bgCtx, exec.ID, map[string]any{"message": fmt.Sprintf("Failed to connect to Temporal: %v", err)})
-			return
-		}
+/* ---------------------- RETRY EXECUTION ---------------------- */
+
+func (s *WorkflowServer) RetryExecution(
+	ctx context.Context,
+	req *service.RetryExecutionRequest,
+) (*service.RetryExecutionResponse, error) {
+
+	originalID, err := uuid.Parse(req.ExecutionId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid execution ID: %w", err)
+	}
 
-		opts := client.StartWorkflowOptions{
-			ID:        temporalWorkflowID,
-			TaskQueue: "workflow-task-queue",
+	original, err := s.execStore.Get(ctx, req.ProjectId, originalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if original.Status != execution.ExecutionFailed && original.Status != execution.ExecutionCancelled {
+		return nil, fmt.Errorf("only failed or cancelled executions can be retried, execution is %s", original.Status)
+	}
+
+	nodes, err := s.nodeStore.ListByExecution(ctx, original.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The retry runs the definition the original ran, not the current one
+	def, definition, err := s.executionDefinition(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+
+	succeeded := map[string]bool{}
+	for _, n := range nodes {
+		if n.Status == execution.NodeSucceeded {
+			succeeded[n.NodeID] = true
 		}
+	}
+	nodeInputs := map[string]map[string]any{}
+	for nodeID, with := range req.NodeInputs {
+		if _, ok := def.Nodes[nodeID]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "node_inputs: unknown node %s", nodeID)
+		}
+		if succeeded[nodeID] {
+			return nil, fmt.Errorf("node %s already succeeded and will not be retried", nodeID)
+		}
+		if with != nil {
+			nodeInputs[nodeID] = with.AsMap()
+		}
+	}
 
-		we, err := tc.Client.ExecuteWorkflow(
-			bgCtx,
-			opts,
-			temporal.WorkflowExecution,
-			exec.ID.String(),
-			req.ProjectId,
-			req.WorkflowId,
-			inputs,
+	clientRequestID := req.ClientRequestId
+	if clientRequestID == "" {
+		clientRequestID = "retry-" + uuid.NewString()
+	} else if existing, err := s.execStore.GetByIdempotencyKey(
+		ctx,
+		original.ProjectID,
+		original.WorkflowID,
+		clientRequestID,
+	); err == nil {
+		return &service.RetryExecutionResponse{
+			ExecutionId: existing.ID.String(),
+			State:       string(existing.Status),
+		}, nil
+	}
+
+	exec := &execution.Execution{
+		ID:              uuid.New(),
+		ProjectID:       original.ProjectID,
+		WorkflowID:      original.WorkflowID,
+		ClientRequestID: clientRequestID,
+		TemporalWorkflowID: fmt.Sprintf(
+			"%s:%s:%s",
+			original.ProjectID,
+			original.WorkflowID,
+			clientRequestID,
+		),
+		Version:    original.Version,
+		Definition: definition,
+		RetryOf:    &original.ID,
+		NodeInputs: nodeInputs,
+		Status:     execution.ExecutionPending,
+		Inputs:     original.Inputs,
+	}
+
+	// Succeeded nodes are carried over with the row, so the new run's
+	// timeline is complete
+	if err := s.execStore.Enqueue(ctx, exec); err != nil {
+		if !errors.Is(err, execution.ErrDuplicate) {
+			return nil, err
+		}
+		// a concurrent retry with the same client_request_id won
+		existing, err := s.execStore.GetByIdempotencyKey(
+			ctx,
+			original.ProjectID,
+			original.WorkflowID,
+			clientRequestID,
 		)
 		if err != nil {
-			// Mark execution as failed if workflow start fails
-			_ = s.execStore.MarkFailed(bgCtx, exec.ID, map[string]any{"message": fmt.Sprintf("Failed to start workflow: %v", err)})
-			return
+			return nil, err
 		}
+		return &service.RetryExecutionResponse{
+			ExecutionId: existing.ID.String(),
+			State:       string(existing.Status),
+		}, nil
+	}
+	s.dispatcher.Notify()
 
-		// Mark execution as running once workflow is started
-		_ = s.execStore.MarkRunning(bgCtx, exec.ID, we.GetRunID())
-	}()
-
-	// Return immediately with PENDING state
-	// The workflow will be started asynchronously and state will update to RUNNING
-	return &service.StartWorkflowResponse{
+	return &service.RetryExecutionResponse{
 		ExecutionId: exec.ID.String(),
 		State:       string(execution.ExecutionPending),
 	}, nil
@@ -289,6 +518,10 @@ func (s *WorkflowServer) GetExecution(
 		state = service.ExecutionState_SUCCESS
 	case execution.ExecutionFailed:
 		state = service.ExecutionState_FAILED
+	case execution.ExecutionCancelled:
+		state = service.ExecutionState_CANCELLED
+	case execution.ExecutionPaused:
+		state = service.ExecutionState_PAUSED
 	default:
 		state = service.ExecutionState_EXECUTION_STATE_UNSPECIFIED
 	}
@@ -312,12 +545,237 @@ func (s *WorkflowServer) GetExecution(
 	}
 
 	return &service.GetExecutionResponse{
-		State:   state,
-		Outputs: outputs,
-		Error:   errorStr,
+		State:           state,
+		Outputs:         outputs,
+		Error:           errorStr,
+		WorkflowId:      exec.WorkflowID,
+		WorkflowVersion: exec.Version,
+	}, nil
+}
+
+/* ---------------------- CANCEL EXECUTION ---------------------- */
+
+func (s *WorkflowServer) CancelExecution(
+	ctx context.Context,
+	req *service.CancelExecutionRequest,
+) (*service.CancelExecutionResponse, error) {
+
+	executionID, err := uuid.Parse(req.ExecutionId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid execution ID: %w", err)
+	}
+
+	exec, err := s.execStore.Get(ctx, req.ProjectId, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch exec.Status {
+	case execution.ExecutionSucceeded, execution.ExecutionFailed, execution.ExecutionCancelled:
+		return nil, fmt.Errorf("execution is already %s", exec.Status)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "cancelled by user"
+	}
+
+	// The backend observes the cancellation, waits for running nodes and
+	// marks the execution CANCELLED itself, recording the event then.
+	state := exec.Status
+	err = s.backend.Cancel(ctx, exec)
+	if err != nil {
+		if !errors.Is(err, wfexecution.ErrNotStarted) {
+			return nil, fmt.Errorf("failed to cancel workflow: %w", err)
+		}
+
+		// Never started: nothing ran, so cancel the row directly
+		if state, err = s.cancelUnstarted(ctx, exec, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	return &service.CancelExecutionResponse{
+		ExecutionId: exec.ID.String(),
+		State:       string(state),
+	}, nil
+}
+
+// cancelUnstarted cancels a PENDING execution the backend has not started,
+// and skips its nodes. An execution the dispatcher started meanwhile is
+// cancelled on the backend instead.
+func (s *WorkflowServer) cancelUnstarted(
+	ctx context.Context,
+	exec *execution.Execution,
+	reason string,
+) (execution.ExecutionStatus, error) {
+
+	err := s.execStore.CancelPending(ctx, exec.ID, map[string]any{"message": reason})
+	if errors.Is(err, execution.ErrStateChanged) {
+		current, err := s.execStore.Get(ctx, exec.ProjectID, exec.ID)
+		if err != nil {
+			return "", err
+		}
+		switch current.Status {
+		case execution.ExecutionSucceeded, execution.ExecutionFailed, execution.ExecutionCancelled:
+			return "", fmt.Errorf("execution is already %s", current.Status)
+		}
+		if err := s.backend.Cancel(ctx, current); err != nil {
+			return "", fmt.Errorf("failed to cancel workflow: %w", err)
+		}
+		return current.Status, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	_ = s.eventStore.Append(ctx, &execution.ExecutionEvent{
+		ExecutionID: exec.ID,
+		EventType:   execution.EventExecutionCancelled,
+		Message:     reason,
+	})
+
+	def, _, err := s.executionDefinition(ctx, exec)
+	if err != nil {
+		// the execution is cancelled either way; only its node rows are missing
+		return execution.ExecutionCancelled, nil
+	}
+
+	for nodeID := range def.Nodes {
+		if err := s.nodeStore.MarkSkipped(ctx, exec.ID, nodeID, map[string]any{"message": reason}); err != nil {
+			return "", err
+		}
+	}
+	return execution.ExecutionCancelled, nil
+}
+
+// executionDefinition returns the definition an execution is pinned to
+// and its YAML. Executions recorded before definitions were pinned fall
+// back to the workflow, with an empty YAML.
+func (s *WorkflowServer) executionDefinition(
+	ctx context.Context,
+	exec *execution.Execution,
+) (*api.Definition, string, error) {
+
+	yaml, err := s.execStore.GetDefinition(ctx, exec.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if yaml == "" {
+		wf, err := s.wfStore.Get(ctx, exec.ProjectID, exec.WorkflowID)
+		if err != nil {
+			return nil, "", err
+		}
+		return wf.Def, "", nil
+	}
+
+	def, err := parser.ParseWorkflow([]byte(yaml))
+	if err != nil {
+		return nil, "", err
+	}
+	return def, yaml, nil
+}
+
+/* ---------------------- PAUSE / RESUME EXECUTION ---------------------- */
+
+func (s *WorkflowServer) PauseExecution(
+	ctx context.Context,
+	req *service.PauseExecutionRequest,
+) (*service.PauseExecutionResponse, error) {
+
+	exec, err := s.signalExecution(
+		ctx,
+		req.ProjectId,
+		req.ExecutionId,
+		execution.ExecutionRunning,
+		runner.SignalPause,
+		s.execStore.MarkPaused,
+		s.execStore.MarkResumed,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.eventStore.Append(ctx, &execution.ExecutionEvent{
+		ExecutionID: exec.ID,
+		EventType:   execution.EventExecutionPaused,
+		Message:     req.Reason,
+	})
+
+	return &service.PauseExecutionResponse{
+		ExecutionId: exec.ID.String(),
+		State:       string(execution.ExecutionPaused),
+	}, nil
+}
+
+func (s *WorkflowServer) ResumeExecution(
+	ctx context.Context,
+	req *service.ResumeExecutionRequest,
+) (*service.ResumeExecutionResponse, error) {
+
+	exec, err := s.signalExecution(
+		ctx,
+		req.ProjectId,
+		req.ExecutionId,
+		execution.ExecutionPaused,
+		runner.SignalResume,
+		s.execStore.MarkResumed,
+		s.execStore.MarkPaused,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.eventStore.Append(ctx, &execution.ExecutionEvent{
+		ExecutionID: exec.ID,
+		EventType:   execution.EventExecutionResumed,
+	})
+
+	return &service.ResumeExecutionResponse{
+		ExecutionId: exec.ID.String(),
+		State:       string(execution.ExecutionRunning),
 	}, nil
 }
 
+// signalExecution records the transition of an execution in the expected
+// state with mark, then sends it signal, runner.SignalPause or
+// runner.SignalResume. The transition is guarded, so of concurrent
+// calls only one signals; revert undoes it if the signal fails.
+func (s *WorkflowServer) signalExecution(
+	ctx context.Context,
+	projectID string,
+	executionIDStr string,
+	expected execution.ExecutionStatus,
+	signal string,
+	mark, revert func(context.Context, uuid.UUID) error,
+) (*execution.Execution, error) {
+
+	executionID, err := uuid.Parse(executionIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid execution ID: %w", err)
+	}
+
+	exec, err := s.execStore.Get(ctx, projectID, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if exec.Status != expected {
+		return nil, fmt.Errorf("execution is %s, expected %s", exec.Status, expected)
+	}
+
+	if err := mark(ctx, exec.ID); err != nil {
+		return nil, err
+	}
+	if err := s.backend.Signal(ctx, exec, signal); err != nil {
+		// a run that finished meanwhile keeps its terminal state
+		_ = revert(context.WithoutCancel(ctx), exec.ID)
+		return nil, err
+	}
+
+	return exec, nil
+}
+
 /* ---------------------- LIST EXECUTIONS ---------------------- */
 
 func (s *WorkflowServer) ListExecutions(
@@ -369,14 +827,24 @@ func (s *WorkflowServer) ListExecutions(
 			}
 		}
 
+		var retryOf string
+		if e.RetryOf != nil {
+			retryOf = e.RetryOf.String()
+		}
+
 		res.Executions[i] = &service.ExecutionInfo{
 			Id:              e.ID.String(),
 			WorkflowId:      e.WorkflowID,
 			WorkflowName:    workflowName,
+			WorkflowVersion: e.Version,
 			ProjectId:       e.ProjectID,
 			ClientRequestId: e.ClientRequestID,
 			State:           string(e.Status),
 			Error:           errorStr,
+			RetryOf:         retryOf,
+			TriggerType:     e.TriggerType,
+			ScheduleId:      e.ScheduleID,
+			WebhookId:       e.WebhookID,
 		}
 	}
 
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	service "github.com/prashantsinghb/workflow-engine/api/service"
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/secrets"
+	"github.com/prashantsinghb/workflow-engine/pkg/webhook"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/validation"
+)
+
+// maxWebhookPayload caps the body a webhook sender may post.
+const maxWebhookPayload = 5 << 20
+
+// WebhookServer manages webhook triggers and receives their requests.
+// Each accepted request starts an execution through the same idempotency
+// path as StartWorkflow, recorded with trigger type "webhook".
+type WebhookServer struct {
+	service.UnimplementedWebhookServiceServer
+
+	store     webhook.Store
+	secrets   secrets.Store
+	workflows *WorkflowServer
+	// baseURL prefixes the webhook URLs returned by the API
+	baseURL string
+}
+
+func NewWebhookServer(
+	store webhook.Store,
+	secretStore secrets.Store,
+	workflows *WorkflowServer,
+	baseURL string,
+) *WebhookServer {
+	return &WebhookServer{
+		store:     store,
+		secrets:   secretStore,
+		workflows: workflows,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (s *WebhookServer) CreateWebhook(
+	ctx context.Context,
+	req *service.CreateWebhookRequest,
+) (*service.CreateWebhookResponse, error) {
+
+	if req.Webhook == nil {
+		return nil, fmt.Errorf("webhook is required")
+	}
+	wh := fromWebhookProto(req.ProjectId, req.Webhook)
+	wh.ID = ""
+	if err := s.validate(ctx, wh); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Create(ctx, wh); err != nil {
+		return nil, err
+	}
+	return &service.CreateWebhookResponse{Webhook: s.toProto(wh)}, nil
+}
+
+func (s *WebhookServer) GetWebhook(
+	ctx context.Context,
+	req *service.GetWebhookRequest,
+) (*service.GetWebhookResponse, error) {
+
+	wh, err := s.get(ctx, req.ProjectId, req.WebhookId)
+	if err != nil {
+		return nil, err
+	}
+	return &service.GetWebhookResponse{Webhook: s.toProto(wh)}, nil
+}
+
+func (s *WebhookServer) ListWebhooks(
+	ctx context.Context,
+	req *service.ListWebhooksRequest,
+) (*service.ListWebhooksResponse, error) {
+
+	list, err := s.store.List(ctx, req.ProjectId, req.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &service.ListWebhooksResponse{}
+	for _, wh := range list {
+		resp.Webhooks = append(resp.Webhooks, s.toProto(wh))
+	}
+	return resp, nil
+}
+
+// UpdateWebhook replaces a webhook's settings. Its workflow and URL stay
+// the same.
+func (s *WebhookServer) UpdateWebhook(
+	ctx context.Context,
+	req *service.UpdateWebhookRequest,
+) (*service.UpdateWebhookResponse, error) {
+
+	if req.Webhook == nil {
+		return nil, fmt.Errorf("webhook is required")
+	}
+	existing, err := s.get(ctx, req.ProjectId, req.WebhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	wh := fromWebhookProto(req.ProjectId, req.Webhook)
+	wh.ID = existing.ID
+	wh.WorkflowID = existing.WorkflowID
+	if err := s.validate(ctx, wh); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Update(ctx, wh); err != nil {
+		return nil, err
+	}
+	return &service.UpdateWebhookResponse{Webhook: s.toProto(wh)}, nil
+}
+
+func (s *WebhookServer) DeleteWebhook(
+	ctx context.Context,
+	req *service.DeleteWebhookRequest,
+) (*service.DeleteWebhookResponse, error) {
+
+	if err := s.store.Delete(ctx, req.ProjectId, req.WebhookId); err != nil {
+		return nil, err
+	}
+	return &service.DeleteWebhookResponse{}, nil
+}
+
+// ReceiveWebhook handles POST /v1/hooks/{webhookId}. It answers 202 with
+// the new execution, or 200 with the existing one for a redelivery.
+func (s *WebhookServer) ReceiveWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id := chi.URLParam(r, "webhookId")
+	wh, err := s.store.Get(ctx, id)
+	if err != nil && !errors.Is(err, webhook.ErrNotFound) {
+		log.Printf("webhook %s: load: %v\n", id, err)
+		http.Error(w, "failed to load webhook", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || wh.Disabled {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayload))
+	if err != nil {
+		http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if s.secrets == nil {
+		http.Error(w, "secrets are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	key, err := s.secrets.Get(ctx, wh.ProjectID, wh.SecretName)
+	if err != nil {
+		log.Printf("webhook %s: signing secret: %v\n", wh.ID, err)
+		http.Error(w, "signing secret unavailable", http.StatusInternalServerError)
+		return
+	}
+	if err := wh.VerifySignature(r.Header, body, []byte(key)); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// Bodies that are not JSON are mapped as a plain string
+	var payload interface{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			payload = string(body)
+		}
+	}
+
+	inputs, err := wh.MapInputs(payload, r.Header, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clientRequestID, err := wh.ClientRequestID(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if clientRequestID == "" {
+		clientRequestID = fmt.Sprintf("webhook:%s:%s", wh.ID, uuid.NewString())
+	}
+
+	run := &execution.Execution{
+		ProjectID:       wh.ProjectID,
+		WorkflowID:      wh.WorkflowID,
+		ClientRequestID: clientRequestID,
+		TriggerType:     execution.TriggerWebhook,
+		WebhookID:       wh.ID,
+		Inputs:          inputs,
+	}
+	exec, err := s.workflows.startExecution(ctx, run)
+	if errors.Is(err, validation.ErrInvalidInputs) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("webhook %s: start workflow: %v\n", wh.ID, err)
+		http.Error(w, "failed to start workflow", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusAccepted
+	if exec != run {
+		// an earlier delivery with the same dedup header
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"executionId": exec.ID.String(),
+		"state":       string(exec.Status),
+	})
+}
+
+// get returns a webhook of projectID; the store looks webhooks up by ID
+// alone.
+func (s *WebhookServer) get(ctx context.Context, projectID, id string) (*webhook.Webhook, error) {
+	wh, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if wh.ProjectID != projectID {
+		return nil, fmt.Errorf("%w: %s", webhook.ErrNotFound, id)
+	}
+	return wh, nil
+}
+
+func (s *WebhookServer) validate(ctx context.Context, wh *webhook.Webhook) error {
+	wh.ApplyDefaults()
+	if err := wh.Validate(); err != nil {
+		return err
+	}
+	if err := secrets.ValidateName(wh.SecretName); err != nil {
+		return err
+	}
+	if _, err := s.workflows.wfStore.Get(ctx, wh.ProjectID, wh.WorkflowID); err != nil {
+		return fmt.Errorf("workflow %s: %w", wh.WorkflowID, err)
+	}
+	return nil
+}
+
+func fromWebhookProto(projectID string, pb *service.Webhook) *webhook.Webhook {
+	return &webhook.Webhook{
+		ID:         pb.Id,
+		ProjectID:  projectID,
+		WorkflowID: pb.WorkflowId,
+		Name:       pb.Name,
+		SecretName: pb.SecretName,
+		Signature: webhook.Signature{
+			Header:    pb.SignatureHeader,
+			Algorithm: pb.SignatureAlgorithm,
+			Prefix:    pb.SignaturePrefix,
+		},
+		Inputs:      pb.Inputs,
+		DedupHeader: pb.DedupHeader,
+		Disabled:    pb.Disabled,
+	}
+}
+
+func (s *WebhookServer) toProto(wh *webhook.Webhook) *service.Webhook {
+	return &service.Webhook{
+		Id:                 wh.ID,
+		WorkflowId:         wh.WorkflowID,
+		Name:               wh.Name,
+		SecretName:         wh.SecretName,
+		SignatureHeader:    wh.Signature.Header,
+		SignatureAlgorithm: wh.Signature.Algorithm,
+		SignaturePrefix:    wh.Signature.Prefix,
+		Inputs:             wh.Inputs,
+		DedupHeader:        wh.DedupHeader,
+		Disabled:           wh.Disabled,
+		Url:                s.baseURL + "/v1/hooks/" + wh.ID,
+		CreatedAt:          timestamppb.New(wh.CreatedAt),
+		UpdatedAt:          timestamppb.New(wh.UpdatedAt),
+	}
+}
@@ -0,0 +1,24 @@
+// Package signing holds what outbound request signing and inbound webhook
+// verification share: the HMAC algorithms both accept.
+package signing
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+)
+
+// Hash returns the hash for an HMAC algorithm name: sha1, sha256 or sha512.
+func Hash(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unsupported HMAC algorithm %q", algorithm)
+}
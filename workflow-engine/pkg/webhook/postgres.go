@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type PostgresStore struct {
+	DB *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+const webhookColumns = `
+	id, project_id, workflow_id, name, secret_name,
+	signature_header, signature_algorithm, signature_prefix,
+	input_mapping, dedup_header, disabled, created_at, updated_at
+`
+
+func (s *PostgresStore) Create(ctx context.Context, wh *Webhook) error {
+	if wh.ID == "" {
+		wh.ID = uuid.NewString()
+	}
+	mapping, _ := json.Marshal(wh.Inputs)
+
+	return s.DB.QueryRowContext(ctx, `
+	INSERT INTO webhooks (
+		id, project_id, workflow_id, name, secret_name,
+		signature_header, signature_algorithm, signature_prefix,
+		input_mapping, dedup_header, disabled
+	)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+	RETURNING created_at, updated_at
+	`,
+		wh.ID, wh.ProjectID, wh.WorkflowID, wh.Name, wh.SecretName,
+		wh.Signature.Header, wh.Signature.Algorithm, wh.Signature.Prefix,
+		mapping, wh.DedupHeader, wh.Disabled,
+	).Scan(&wh.CreatedAt, &wh.UpdatedAt)
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Webhook, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	row := s.DB.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id=$1`, id)
+	wh, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return wh, err
+}
+
+// List returns the project's webhooks, or only those of workflowID when
+// it is set.
+func (s *PostgresStore) List(ctx context.Context, projectID, workflowID string) ([]*Webhook, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+	SELECT `+webhookColumns+`
+	FROM webhooks
+	WHERE project_id=$1 AND ($2 = '' OR workflow_id::text = $2)
+	ORDER BY name
+	`, projectID, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Webhook
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, wh)
+	}
+	return out, rows.Err()
+}
+
+func (s *PostgresStore) Update(ctx context.Context, wh *Webhook) error {
+	mapping, _ := json.Marshal(wh.Inputs)
+
+	err := s.DB.QueryRowContext(ctx, `
+	UPDATE webhooks
+	SET
+		name = $3,
+		secret_name = $4,
+		signature_header = $5,
+		signature_algorithm = $6,
+		signature_prefix = $7,
+		input_mapping = $8,
+		dedup_header = $9,
+		disabled = $10,
+		updated_at = now()
+	WHERE id=$1 AND project_id=$2
+	RETURNING workflow_id, created_at, updated_at
+	`,
+		wh.ID, wh.ProjectID, wh.Name, wh.SecretName,
+		wh.Signature.Header, wh.Signature.Algorithm, wh.Signature.Prefix,
+		mapping, wh.DedupHeader, wh.Disabled,
+	).Scan(&wh.WorkflowID, &wh.CreatedAt, &wh.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrNotFound, wh.ID)
+	}
+	return err
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, projectID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM webhooks WHERE project_id=$1 AND id=$2`, projectID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhook(row scanner) (*Webhook, error) {
+	var wh Webhook
+	var mapping []byte
+	err := row.Scan(
+		&wh.ID, &wh.ProjectID, &wh.WorkflowID, &wh.Name, &wh.SecretName,
+		&wh.Signature.Header, &wh.Signature.Algorithm, &wh.Signature.Prefix,
+		&mapping, &wh.DedupHeader, &wh.Disabled, &wh.CreatedAt, &wh.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal(mapping, &wh.Inputs)
+	return &wh, nil
+}
@@ -0,0 +1,199 @@
+// Package webhook defines inbound webhook triggers: a URL a third party
+// (GitHub, PagerDuty, an internal service) posts to, whose signed payload
+// is mapped to a workflow's inputs and starts an execution.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/signing"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/expr"
+)
+
+// ErrNotFound is returned for a webhook that does not exist.
+var ErrNotFound = errors.New("webhook not found")
+
+// ErrSignature is returned when a request's signature is missing or wrong.
+var ErrSignature = errors.New("invalid webhook signature")
+
+// Signature defaults follow GitHub's X-Hub-Signature-256.
+const (
+	DefaultSignatureHeader    = "X-Hub-Signature-256"
+	DefaultSignatureAlgorithm = "sha256"
+	DefaultSignaturePrefix    = "sha256="
+)
+
+// Webhook starts a workflow when a signed request arrives at its URL.
+type Webhook struct {
+	ID         string
+	ProjectID  string
+	WorkflowID string
+	Name       string
+	// SecretName is the project secret holding the HMAC signing key
+	SecretName string
+	Signature  Signature
+	// Inputs maps workflow inputs to ${{ }} expressions over the request:
+	// payload (the decoded JSON body), headers (lower-case names) and
+	// query. Without a mapping a JSON object body is used as the inputs.
+	Inputs map[string]string
+	// DedupHeader names a header carrying a unique delivery ID, e.g.
+	// X-GitHub-Delivery; redeliveries start no new execution
+	DedupHeader string
+	Disabled    bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Signature describes where a sender puts the HMAC of the raw body.
+type Signature struct {
+	Header    string
+	Algorithm string
+	// Prefix precedes the hex digest, e.g. "sha256=" or PagerDuty's "v1="
+	Prefix string
+}
+
+// Store persists webhook definitions.
+type Store interface {
+	Create(ctx context.Context, wh *Webhook) error
+	// Get looks a webhook up by ID alone: the ID is what its URL carries
+	Get(ctx context.Context, id string) (*Webhook, error)
+	List(ctx context.Context, projectID, workflowID string) ([]*Webhook, error)
+	Update(ctx context.Context, wh *Webhook) error
+	Delete(ctx context.Context, projectID, id string) error
+}
+
+// scopeRoots are the variables input mappings can use.
+var scopeRoots = map[string]bool{"payload": true, "headers": true, "query": true}
+
+// ApplyDefaults fills in the signature defaults.
+func (w *Webhook) ApplyDefaults() {
+	if w.Signature.Header == "" {
+		w.Signature.Header = DefaultSignatureHeader
+		if w.Signature.Prefix == "" {
+			w.Signature.Prefix = DefaultSignaturePrefix
+		}
+	}
+	if w.Signature.Algorithm == "" {
+		w.Signature.Algorithm = DefaultSignatureAlgorithm
+	}
+}
+
+// Validate checks a webhook after ApplyDefaults.
+func (w *Webhook) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if w.WorkflowID == "" {
+		return fmt.Errorf("workflow_id is required")
+	}
+	if w.SecretName == "" {
+		return fmt.Errorf("secret_name is required: webhooks must be signed")
+	}
+	if _, err := signing.Hash(w.Signature.Algorithm); err != nil {
+		return err
+	}
+
+	for input, tpl := range w.Inputs {
+		exprs, err := expr.TemplateExpressions(tpl)
+		if err != nil {
+			return fmt.Errorf("inputs.%s: %w", input, err)
+		}
+		for _, e := range exprs {
+			for _, ref := range e.References() {
+				if !scopeRoots[ref[0]] {
+					return fmt.Errorf("inputs.%s: unknown variable %q, use payload, headers or query", input, ref[0])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// VerifySignature checks the request's signature header against the HMAC
+// of body. The header may carry several comma-separated signatures, as
+// senders do while rotating keys; one match is enough.
+func (w *Webhook) VerifySignature(h http.Header, body, key []byte) error {
+	newHash, err := signing.Hash(w.Signature.Algorithm)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(newHash, key)
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, sig := range strings.Split(h.Get(w.Signature.Header), ",") {
+		sig = strings.TrimSpace(sig)
+		if !strings.HasPrefix(sig, w.Signature.Prefix) {
+			continue
+		}
+		got, err := hex.DecodeString(strings.TrimPrefix(sig, w.Signature.Prefix))
+		if err == nil && hmac.Equal(got, expected) {
+			return nil
+		}
+	}
+	return ErrSignature
+}
+
+// MapInputs evaluates the input mapping against a request. Mappings that
+// evaluate to null are left out, so the workflow's defaults apply.
+func (w *Webhook) MapInputs(payload interface{}, h http.Header, query url.Values) (map[string]interface{}, error) {
+	if len(w.Inputs) == 0 {
+		if m, ok := payload.(map[string]interface{}); ok {
+			return m, nil
+		}
+		return map[string]interface{}{}, nil
+	}
+
+	scope := map[string]interface{}{
+		"payload": payload,
+		"headers": flatten(h, strings.ToLower),
+		"query":   flatten(query, nil),
+	}
+
+	inputs := make(map[string]interface{}, len(w.Inputs))
+	for input, tpl := range w.Inputs {
+		v, err := expr.RenderString(tpl, scope)
+		if err != nil {
+			return nil, fmt.Errorf("inputs.%s: %w", input, err)
+		}
+		if v != nil {
+			inputs[input] = v
+		}
+	}
+	return inputs, nil
+}
+
+// ClientRequestID derives the execution's idempotency key from the dedup
+// header. It is empty when the webhook does not deduplicate.
+func (w *Webhook) ClientRequestID(h http.Header) (string, error) {
+	if w.DedupHeader == "" {
+		return "", nil
+	}
+	id := h.Get(w.DedupHeader)
+	if id == "" {
+		return "", fmt.Errorf("missing %s header", w.DedupHeader)
+	}
+	return fmt.Sprintf("webhook:%s:%s", w.ID, id), nil
+}
+
+// flatten keeps the first value of each key.
+func flatten(values map[string][]string, key func(string) string) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if key != nil {
+			k = key(k)
+		}
+		if len(v) > 0 {
+			out[k] = v[0]
+		}
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func sign(newHash func() hash.Hash, key, body []byte) string {
+	mac := hmac.New(newHash, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	key := []byte("s3cret")
+	body := []byte(`{"action":"opened"}`)
+	sha256Sig := sign(sha256.New, key, body)
+	wrongSig := sign(sha256.New, []byte("other"), body)
+
+	github := Signature{}
+	pagerDuty := Signature{Header: "X-PagerDuty-Signature", Prefix: "v1="}
+
+	tests := []struct {
+		name   string
+		sig    Signature
+		header string
+		value  string
+		body   []byte
+		ok     bool
+	}{
+		{
+			name:   "github default",
+			sig:    github,
+			header: DefaultSignatureHeader,
+			value:  "sha256=" + sha256Sig,
+			ok:     true,
+		},
+		{
+			name:   "upper-case hex",
+			sig:    github,
+			header: DefaultSignatureHeader,
+			value:  "sha256=" + strings.ToUpper(sha256Sig),
+			ok:     true,
+		},
+		{
+			name:   "missing header",
+			sig:    github,
+			header: "X-Other",
+			value:  "sha256=" + sha256Sig,
+		},
+		{
+			name:   "missing prefix",
+			sig:    github,
+			header: DefaultSignatureHeader,
+			value:  sha256Sig,
+		},
+		{
+			name:   "wrong key",
+			sig:    github,
+			header: DefaultSignatureHeader,
+			value:  "sha256=" + wrongSig,
+		},
+		{
+			name:   "tampered body",
+			sig:    github,
+			header: DefaultSignatureHeader,
+			value:  "sha256=" + sha256Sig,
+			body:   []byte(`{"action":"closed"}`),
+		},
+		{
+			name:   "not hex",
+			sig:    github,
+			header: DefaultSignatureHeader,
+			value:  "sha256=zz",
+		},
+		{
+			name:   "rotating keys, second matches",
+			sig:    pagerDuty,
+			header: "X-PagerDuty-Signature",
+			value:  "v1=" + wrongSig + ", v1=" + sha256Sig,
+			ok:     true,
+		},
+		{
+			name:   "rotating keys, none match",
+			sig:    pagerDuty,
+			header: "X-PagerDuty-Signature",
+			value:  "v1=" + wrongSig + ",v1=00",
+		},
+		{
+			name:   "sha512",
+			sig:    Signature{Header: "X-Sig", Algorithm: "sha512"},
+			header: "X-Sig",
+			value:  sign(sha512.New, key, body),
+			ok:     true,
+		},
+		{
+			name:   "sha1",
+			sig:    Signature{Header: "X-Hub-Signature", Algorithm: "sha1", Prefix: "sha1="},
+			header: "X-Hub-Signature",
+			value:  "sha1=" + sign(sha1.New, key, body),
+			ok:     true,
+		},
+		{
+			name:   "digest of another algorithm",
+			sig:    Signature{Header: "X-Sig", Algorithm: "sha512"},
+			header: "X-Sig",
+			value:  sha256Sig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Webhook{Signature: tt.sig}
+			w.ApplyDefaults()
+
+			h := http.Header{}
+			h.Set(tt.header, tt.value)
+			b := body
+			if tt.body != nil {
+				b = tt.body
+			}
+
+			err := w.VerifySignature(h, b, key)
+			if tt.ok && err != nil {
+				t.Fatalf("VerifySignature: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrSignature) {
+				t.Fatalf("VerifySignature = %v, want ErrSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureUnknownAlgorithm(t *testing.T) {
+	w := &Webhook{Signature: Signature{Header: "X-Sig", Algorithm: "md5"}}
+
+	err := w.VerifySignature(http.Header{"X-Sig": {"00"}}, nil, []byte("k"))
+	if err == nil || errors.Is(err, ErrSignature) {
+		t.Fatalf("VerifySignature = %v, want an unsupported algorithm error", err)
+	}
+}
@@ -1,11 +1,70 @@
 package api
 
 type Definition struct {
-	Nodes map[string]Node `yaml:"nodes"`
+	// Inputs declares the inputs an execution accepts; StartWorkflow
+	// rejects inputs that do not match and fills in defaults
+	Inputs map[string]InputSpec `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+	Nodes  map[string]Node      `yaml:"nodes"`
+	// Outputs selects what an execution returns, e.g.
+	// `record_id: ${{ steps.create_dns.outputs.record_id }}`. Without it the
+	// outputs of every node are returned keyed by node ID.
+	Outputs map[string]interface{} `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+	// Defaults sets the timeout and retry policy of nodes that do not set
+	// their own, nor get one from their module
+	Defaults *NodeDefaults `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+}
+
+// NodeDefaults is the timeout and retry policy a workflow or a module
+// gives its nodes. Each field set overrides the one below it: node, then
+// module, then workflow, then the built-in policy of a one-minute timeout
+// and five attempts, 5s apart and doubling.
+type NodeDefaults struct {
+	Timeout string       `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Retry   *RetryPolicy `yaml:"retry,omitempty" json:"retry,omitempty"`
+}
+
+// RetryPolicy controls how often a failed node is attempted again. Unset
+// fields fall back field by field.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt; 1 disables retries
+	MaxAttempts int `yaml:"max_attempts,omitempty" json:"max_attempts,omitempty"`
+	// InitialInterval is the delay before the first retry, e.g. "5s"
+	InitialInterval string `yaml:"initial_interval,omitempty" json:"initial_interval,omitempty"`
+	// Backoff multiplies the delay after every retry; at least 1
+	Backoff float64 `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+	// MaxInterval caps the delay, e.g. "1m"
+	MaxInterval string `yaml:"max_interval,omitempty" json:"max_interval,omitempty"`
+	// NonRetryableErrors lists error types that fail the node at once,
+	// e.g. "ContractViolation"
+	NonRetryableErrors []string `yaml:"non_retryable_errors,omitempty" json:"non_retryable_errors,omitempty"`
+}
+
+// InputSpec is the JSON Schema subset supported for workflow inputs.
+type InputSpec struct {
+	// Type is one of string, number, integer, boolean, object, array;
+	// empty accepts any value
+	Type        string        `yaml:"type,omitempty" json:"type,omitempty"`
+	Description string        `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool          `yaml:"required,omitempty" json:"required,omitempty"`
+	Default     interface{}   `yaml:"default,omitempty" json:"default,omitempty"`
+	Enum        []interface{} `yaml:"enum,omitempty" json:"enum,omitempty"`
+	// Pattern is a regular expression string inputs must match
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
 }
 
 type Node struct {
 	Uses      string                 `yaml:"uses" json:"uses"`
 	DependsOn []string               `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
 	With      map[string]interface{} `yaml:"with,omitempty" json:"with,omitempty"`
+	// If is an expression evaluated before the node runs; the node is
+	// skipped when it is false, e.g. `steps.check.outputs.exists == false`
+	If string `yaml:"if,omitempty" json:"if,omitempty"`
+	// ForEach is an expression yielding a list; the node runs once per
+	// element with `item` and `index` available to its templates
+	ForEach string `yaml:"for_each,omitempty" json:"for_each,omitempty"`
+	// MaxParallel caps concurrently running for_each instances (0 = no cap)
+	MaxParallel int `yaml:"max_parallel,omitempty" json:"max_parallel,omitempty"`
+	// Timeout bounds each attempt at the node, e.g. "30s" or "10m"
+	Timeout string       `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Retry   *RetryPolicy `yaml:"retry,omitempty" json:"retry,omitempty"`
 }
@@ -13,6 +13,10 @@ type Node struct {
 	Executor string
 	Uses     string
 	With     map[string]interface{}
+	If       string
+
+	ForEach     string
+	MaxParallel int
 }
 
 type Graph struct {
@@ -31,6 +35,10 @@ func Build(def *api.Definition) *Graph {
 			Children: []NodeID{},
 			Uses:     n.Uses,
 			With:     n.With,
+			If:       n.If,
+
+			ForEach:     n.ForEach,
+			MaxParallel: n.MaxParallel,
 		}
 	}
 
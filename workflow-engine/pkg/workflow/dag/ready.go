@@ -1,5 +1,10 @@
 package dag
 
+import "sort"
+
+// Ready returns the nodes whose dependencies are all completed, sorted by ID
+// so callers that need deterministic scheduling (e.g. Temporal workflows)
+// can rely on the order.
 func Ready(g Graph, completed map[NodeID]bool) []NodeID {
 	ready := []NodeID{}
 
@@ -21,5 +26,24 @@ func Ready(g Graph, completed map[NodeID]bool) []NodeID {
 		}
 	}
 
+	sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+
 	return ready
 }
+
+// SkippedByUpstream reports whether node must be skipped because one of its
+// dependencies was skipped. Skipped nodes count as completed for Ready, so
+// without propagation a node whose inputs never materialised would still
+// run. A node with its own `if` opts out and decides for itself, e.g. with
+// `steps.create.status == 'SKIPPED'`.
+func SkippedByUpstream(node *Node, skipped map[NodeID]bool) bool {
+	if node.If != "" {
+		return false
+	}
+	for _, d := range node.Depends {
+		if skipped[d] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package execution
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/config"
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+// Engines an execution can run on, as recorded in executions.engine.
+const (
+	EngineTemporal = "temporal"
+	EngineEmbedded = "embedded"
+)
+
+// ErrNotStarted is returned for an execution the backend never started;
+// nothing ran, so a caller cancelling it cancels the row itself.
+var ErrNotStarted = errors.New("execution was not started")
+
+// ExecutionBackend runs executions. TemporalEngine hands them to a
+// Temporal cluster; EmbeddedEngine runs them itself, with all state in
+// Postgres, for setups that do not operate Temporal. Both run the same
+// nodes the same way.
+type ExecutionBackend interface {
+	// Start runs a PENDING execution; retry seeds a retried run.
+	Start(ctx context.Context, exec *execution.Execution, retry *runner.RetryState) error
+
+	// Cancel asks an execution to stop. It waits for its running nodes
+	// and marks itself CANCELLED.
+	Cancel(ctx context.Context, exec *execution.Execution) error
+
+	// Signal delivers runner.SignalPause, which stops an execution from
+	// starting new nodes while running ones finish, or
+	// runner.SignalResume, which continues it.
+	Signal(ctx context.Context, exec *execution.Execution, signal string) error
+
+	// Describe returns the backend's view of an execution, or
+	// ErrNotStarted if the backend has no record of it.
+	Describe(ctx context.Context, exec *execution.Execution) (*Description, error)
+}
+
+// Description is an execution as its backend sees it.
+type Description struct {
+	// Status maps the backend's state onto an execution state
+	Status execution.ExecutionStatus
+	// BackendStatus is the backend's own name for it, e.g. TimedOut
+	BackendStatus string
+	RunID         string
+	// Error explains why a closed run failed or was cancelled
+	Error string
+}
+
+// NewBackend returns the backend cfg.Engine selects. The embedded engine
+// only runs nodes while its Run loop is going, usually in the worker.
+func NewBackend(
+	cfg config.Config,
+	db *sql.DB,
+	store execution.ExecutionStore,
+) (ExecutionBackend, error) {
+
+	switch cfg.Engine {
+	case EngineTemporal:
+		return NewTemporalEngine(store), nil
+	case EngineEmbedded:
+		return NewEmbeddedEngine(NewPostgresNodeQueue(db), cfg.EngineWorkers), nil
+	}
+	return nil, fmt.Errorf("unknown execution engine %q", cfg.Engine)
+}
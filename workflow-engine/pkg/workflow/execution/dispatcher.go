@@ -0,0 +1,207 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+// Defaults of the outbox dispatcher.
+const (
+	DefaultDispatchBatch    = 50
+	DefaultDispatchLeaseTTL = time.Minute
+)
+
+// DefaultDispatchRetryPolicy spaces out the starts of an execution whose
+// backend is failing, e.g. while Temporal is unreachable, for about five
+// minutes before the execution is marked FAILED.
+var DefaultDispatchRetryPolicy = runner.RetryPolicy{
+	InitialInterval:    time.Second,
+	BackoffCoefficient: 2,
+	MaximumInterval:    time.Minute,
+	MaximumAttempts:    10,
+}
+
+// dispatchMetrics is published on /debug/vars by processes that serve
+// expvar:
+//
+//   - dispatched, retried and failed count the starts that succeeded,
+//     failed and were retried, and ran out of attempts
+//   - lag_seconds is the time the latest dispatched execution waited in
+//     the outbox
+//   - oldest_pending_seconds is the age of the oldest undispatched entry,
+//     or 0 when the outbox is drained
+var dispatchMetrics = expvar.NewMap("execution_dispatcher")
+
+// Dispatcher starts the executions waiting in the outbox on a backend.
+// Outbox entries are written with their executions, so an execution whose
+// creator died before starting it is started by whichever dispatcher runs
+// next. Any number of dispatchers may share the database.
+type Dispatcher struct {
+	store   execution.Store
+	backend ExecutionBackend
+	retry   runner.RetryPolicy
+
+	batch        int
+	leaseTTL     time.Duration
+	pollInterval time.Duration
+
+	wake chan struct{}
+}
+
+func NewDispatcher(store execution.Store, backend ExecutionBackend) *Dispatcher {
+	return &Dispatcher{
+		store:        store,
+		backend:      backend,
+		retry:        DefaultDispatchRetryPolicy,
+		batch:        DefaultDispatchBatch,
+		leaseTTL:     DefaultDispatchLeaseTTL,
+		pollInterval: DefaultPollInterval,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Run dispatches entries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// Notify wakes Run up for an early dispatch, e.g. right after Enqueue.
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) tick(ctx context.Context) {
+	defer d.observeLag(ctx)
+
+	for {
+		entries, err := d.store.Outbox().Claim(ctx, d.batch, d.leaseTTL)
+		if err != nil {
+			log.Printf("dispatcher: claiming outbox entries: %v\n", err)
+			return
+		}
+		for _, entry := range entries {
+			if err := d.dispatch(ctx, entry); err != nil {
+				log.Printf("dispatcher: execution %s: %v\n", entry.ExecutionID, err)
+			}
+		}
+		if len(entries) < d.batch {
+			return
+		}
+	}
+}
+
+// dispatch starts one execution. An execution that left PENDING, e.g. was
+// cancelled before it started, only has its entry closed. A failed start
+// is retried after the policy's backoff; once the attempts run out the
+// execution is marked FAILED.
+func (d *Dispatcher) dispatch(ctx context.Context, entry execution.OutboxEntry) error {
+	outbox := d.store.Outbox()
+
+	exec, err := d.store.Executions().Get(ctx, entry.ProjectID, entry.ExecutionID)
+	if err != nil {
+		return err
+	}
+	if exec.Status != execution.ExecutionPending {
+		return outbox.MarkDispatched(ctx, exec.ID)
+	}
+
+	retry, err := d.retryState(ctx, exec, entry)
+	if err == nil {
+		err = d.backend.Start(ctx, exec, retry)
+	}
+	if err == nil {
+		dispatchMetrics.Add("dispatched", 1)
+		lag := new(expvar.Float)
+		lag.Set(time.Since(entry.CreatedAt).Seconds())
+		dispatchMetrics.Set("lag_seconds", lag)
+		return outbox.MarkDispatched(ctx, exec.ID)
+	}
+
+	attempt := entry.Attempts + 1
+	if attempt < int(d.retry.MaximumAttempts) {
+		dispatchMetrics.Add("retried", 1)
+		at := time.Now().Add(d.retry.Backoff(attempt))
+		if err := outbox.Retry(ctx, exec.ID, at, err.Error()); err != nil {
+			return err
+		}
+		return fmt.Errorf("start attempt %d failed: %w", attempt, err)
+	}
+
+	dispatchMetrics.Add("failed", 1)
+	if err := d.store.Executions().MarkFailed(ctx, exec.ID, map[string]any{
+		"message": fmt.Sprintf("Failed to start workflow after %d attempts: %v", attempt, err),
+	}); err != nil && !errors.Is(err, execution.ErrStateChanged) {
+		return err
+	}
+	return outbox.MarkDispatched(ctx, exec.ID)
+}
+
+// observeLag updates oldest_pending_seconds.
+func (d *Dispatcher) observeLag(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	createdAt, ok, err := d.store.Outbox().OldestPending(ctx)
+	if err != nil {
+		log.Printf("dispatcher: reading outbox lag: %v\n", err)
+		return
+	}
+
+	pending := new(expvar.Float)
+	if ok {
+		pending.Set(time.Since(createdAt).Seconds())
+	}
+	dispatchMetrics.Set("oldest_pending_seconds", pending)
+}
+
+// retryState seeds a retried run with the nodes Enqueue copied from the
+// original and with its `with` overrides.
+func (d *Dispatcher) retryState(
+	ctx context.Context,
+	exec *execution.Execution,
+	entry execution.OutboxEntry,
+) (*runner.RetryState, error) {
+
+	if exec.RetryOf == nil {
+		return nil, nil
+	}
+
+	nodes, err := d.store.Nodes().ListByExecution(ctx, exec.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := &runner.RetryState{
+		RetryOf:     exec.RetryOf.String(),
+		StepOutputs: map[string]map[string]interface{}{},
+		NodeInputs:  entry.NodeInputs,
+	}
+	for _, n := range nodes {
+		if n.Status == execution.NodeSucceeded {
+			retry.StepOutputs[n.NodeID] = n.Output
+		}
+	}
+	return retry, nil
+}
@@ -0,0 +1,351 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+// Defaults of the embedded engine.
+const (
+	DefaultWorkers      = 8
+	DefaultLeaseTTL     = 30 * time.Second
+	DefaultPollInterval = time.Second
+)
+
+// EmbeddedEngine runs executions in-process, without Temporal. All of a
+// run's state lives in Postgres: each tick re-reads the node rows, so an
+// engine that restarts, or another engine sharing the database, carries on
+// where the last one stopped. Nodes are leased to the worker running them
+// and are run again once a lease expires.
+//
+// Nodes run through runner.RunNode and are scheduled from a
+// runner.DAGState, with the node's runner.NodePolicy, so a run behaves as
+// it would on Temporal. The runner package's stores and module registry
+// must be set, as for a worker.
+type EmbeddedEngine struct {
+	queue NodeQueue
+	// owner identifies this engine in node leases
+	owner        string
+	leaseTTL     time.Duration
+	pollInterval time.Duration
+
+	slots chan struct{}
+	wake  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewEmbeddedEngine returns an engine running up to workers nodes at once.
+func NewEmbeddedEngine(queue NodeQueue, workers int) *EmbeddedEngine {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	host, _ := os.Hostname()
+
+	return &EmbeddedEngine{
+		queue:        queue,
+		owner:        fmt.Sprintf("%s:%d:%s", host, os.Getpid(), uuid.NewString()[:8]),
+		leaseTTL:     DefaultLeaseTTL,
+		pollInterval: DefaultPollInterval,
+		slots:        make(chan struct{}, workers),
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Start adopts the run; nodes that succeeded in the original of a retried
+// run were copied into it, and its `with` overrides are on the row.
+func (e *EmbeddedEngine) Start(
+	ctx context.Context,
+	exec *execution.Execution,
+	retry *runner.RetryState,
+) error {
+
+	if err := e.queue.Adopt(ctx, exec.ID); err != nil {
+		return err
+	}
+	e.notify()
+	return nil
+}
+
+// Cancel flags the run; the next tick cancels its running nodes, waits
+// for them and marks the execution CANCELLED.
+func (e *EmbeddedEngine) Cancel(ctx context.Context, exec *execution.Execution) error {
+	if err := e.queue.RequestCancel(ctx, exec.ID); err != nil {
+		return err
+	}
+	e.notify()
+	return nil
+}
+
+// Signal needs no delivery for a pause: ticks and claims read the PAUSED
+// state the caller records.
+func (e *EmbeddedEngine) Signal(ctx context.Context, exec *execution.Execution, signal string) error {
+	switch signal {
+	case runner.SignalPause:
+		return nil
+	case runner.SignalResume:
+		e.notify()
+		return nil
+	}
+	return fmt.Errorf("unknown signal %q", signal)
+}
+
+// Describe reads the run's row, which is all the state the engine has.
+func (e *EmbeddedEngine) Describe(ctx context.Context, exec *execution.Execution) (*Description, error) {
+	run, err := e.queue.Run(ctx, exec.ID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Engine != EngineEmbedded || run.Status == execution.ExecutionPending {
+		return nil, ErrNotStarted
+	}
+	return &Description{
+		Status:        run.Status,
+		BackendStatus: string(run.Status),
+	}, nil
+}
+
+// Run advances runs and works their nodes until ctx is done, then waits
+// for running nodes to stop. Their leases are released, so another engine
+// picks them up.
+func (e *EmbeddedEngine) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		e.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.wg.Wait()
+			return ctx.Err()
+		case <-ticker.C:
+		case <-e.wake:
+		}
+	}
+}
+
+// notify wakes Run up for an early tick.
+func (e *EmbeddedEngine) notify() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (e *EmbeddedEngine) tick(ctx context.Context) {
+	runs, err := e.queue.Runs(ctx)
+	if err != nil {
+		log.Printf("embedded engine: listing runs: %v\n", err)
+		return
+	}
+	for _, run := range runs {
+		if err := e.advance(ctx, run.ID); err != nil {
+			log.Printf("embedded engine: execution %s: %v\n", run.ID, err)
+		}
+	}
+
+	free := cap(e.slots) - len(e.slots)
+	if free == 0 {
+		return
+	}
+	nodes, err := e.queue.Claim(ctx, e.owner, free, e.leaseTTL)
+	if err != nil {
+		log.Printf("embedded engine: claiming nodes: %v\n", err)
+		return
+	}
+	for i := range nodes {
+		n := nodes[i]
+		e.slots <- struct{}{}
+		e.wg.Add(1)
+
+		go func() {
+			defer e.wg.Done()
+			defer func() {
+				<-e.slots
+				e.notify()
+			}()
+			e.work(ctx, &n)
+		}()
+	}
+}
+
+// work runs one attempt at a claimed node. A failed attempt is queued
+// again after the retry policy's backoff until the attempts run out.
+func (e *EmbeddedEngine) work(ctx context.Context, n *QueuedNode) {
+	// bookkeeping outlives a shutdown
+	bg := context.WithoutCancel(ctx)
+	executionID := n.ExecutionID.String()
+
+	defer func() {
+		logEngineErr(n, e.queue.Release(bg, n.ExecutionID, n.NodeID, e.owner))
+	}()
+
+	run, def, state, err := e.load(ctx, n.ExecutionID)
+	if err != nil {
+		logEngineErr(n, err)
+		at := time.Now().Add(runner.DefaultNodePolicy().Retry.InitialInterval)
+		logEngineErr(n, e.queue.Retry(bg, n.ExecutionID, n.NodeID, e.owner, at))
+		return
+	}
+
+	// a for_each instance runs with its node's policy
+	parent, _, _ := runner.ParseInstanceID(n.NodeID)
+	nodePolicy, err := runner.ResolveNodePolicy(ctx, run.ProjectID, def, string(parent))
+	if err != nil {
+		logEngineErr(n, runner.MarkNodeFailed(bg, executionID, n.NodeID, err.Error()))
+		return
+	}
+	policy := &nodePolicy.Retry
+	attempt := runner.NodeAttempt{
+		Attempt:     n.Attempt,
+		MaxAttempts: int(policy.MaximumAttempts),
+		Timeout:     nodePolicy.Timeout,
+	}
+	// an earlier attempt started, and failed or lost its worker
+	if n.StartedAt != nil {
+		attempt.Attempt++
+	}
+	if attempt.MaxAttempts > 0 && attempt.Attempt > attempt.MaxAttempts {
+		msg := fmt.Sprintf("node lost its worker after %d attempts", n.Attempt)
+		logEngineErr(n, runner.MarkNodeFailed(bg, executionID, n.NodeID, msg))
+		return
+	}
+
+	req, err := nodeRequest(run, state, n.NodeID)
+	if err != nil {
+		logEngineErr(n, runner.MarkNodeFailed(bg, executionID, n.NodeID, err.Error()))
+		return
+	}
+
+	nodeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := e.keepLease(nodeCtx, cancel, n)
+	defer stop()
+
+	_, err = runner.RunNode(nodeCtx, req, attempt)
+	if err == nil || nodeCtx.Err() != nil {
+		return
+	}
+	if attempt.MaxAttempts > 0 && attempt.Attempt >= attempt.MaxAttempts {
+		return
+	}
+	if !retryable(policy, err) {
+		return
+	}
+
+	delay := policy.Backoff(attempt.Attempt)
+	var nodeErr *runner.NodeError
+	if errors.As(err, &nodeErr) && nodeErr.NextRetryDelay > 0 {
+		delay = nodeErr.NextRetryDelay
+	}
+	at := time.Now().Add(delay)
+	logEngineErr(n, e.queue.Retry(bg, n.ExecutionID, n.NodeID, e.owner, at))
+}
+
+// keepLease renews the node's lease while it runs and cancels it once the
+// lease is lost, or the run was cancelled or finished.
+func (e *EmbeddedEngine) keepLease(ctx context.Context, cancel context.CancelFunc, n *QueuedNode) func() {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(e.leaseTTL / 6)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ok, err := e.queue.Renew(ctx, n.ExecutionID, n.NodeID, e.owner, e.leaseTTL)
+				if err != nil {
+					// the lease is still good for a while; try again
+					logEngineErr(n, err)
+					continue
+				}
+				if !ok {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// nodeRequest builds the request for a node or a for_each instance.
+func nodeRequest(run *EmbeddedRun, state *runner.DAGState, nodeID string) (runner.NodeRequest, error) {
+	req := runner.NodeRequest{
+		ExecutionID: run.ID.String(),
+		ProjectID:   run.ProjectID,
+		Inputs:      run.Inputs,
+		StepOutputs: state.StepOutputs,
+	}
+
+	if node, ok := state.Graph.Nodes[dag.NodeID(nodeID)]; ok {
+		req.Node = node
+		return req, nil
+	}
+	id, index, instance := runner.ParseInstanceID(nodeID)
+	node, ok := state.Graph.Nodes[id]
+	if !ok || !instance {
+		return req, fmt.Errorf("unknown node %s", nodeID)
+	}
+
+	items, err := state.ForEachItems(node)
+	if err != nil {
+		return req, fmt.Errorf("node %s: %w", id, err)
+	}
+	if index >= len(items) {
+		return req, fmt.Errorf("node %s: for_each has no item %d", id, index)
+	}
+
+	req.Node = node
+	req.Iteration = &runner.Iteration{Item: items[index], Index: index}
+	return req, nil
+}
+
+// retryable mirrors Temporal: non-retryable node errors, e.g. contract
+// violations, and the policy's non-retryable types fail at once. Other
+// errors are typed by their Go type name, as Temporal types them.
+func retryable(policy *runner.RetryPolicy, err error) bool {
+	var errType string
+	var nodeErr *runner.NodeError
+	if errors.As(err, &nodeErr) {
+		if nodeErr.NonRetryable {
+			return false
+		}
+		errType = nodeErr.Type
+	} else {
+		t := reflect.TypeOf(err)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		errType = t.Name()
+	}
+	for _, t := range policy.NonRetryableErrorTypes {
+		if errType == t {
+			return false
+		}
+	}
+	return true
+}
+
+func logEngineErr(n *QueuedNode, err error) {
+	if err != nil {
+		log.Printf("embedded engine: execution %s node %s: %v\n", n.ExecutionID, n.NodeID, err)
+	}
+}
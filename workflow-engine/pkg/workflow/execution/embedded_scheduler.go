@@ -0,0 +1,364 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+// advance moves a run forward from its node rows, as dagScheduler does on
+// Temporal: it skips nodes, enqueues ready ones, expands and settles
+// for_each nodes, and finishes the execution once nothing is left to do.
+// Any engine may advance any run; the lock keeps two from doing so at once.
+func (e *EmbeddedEngine) advance(ctx context.Context, executionID uuid.UUID) error {
+	unlock, ok, err := e.queue.Lock(ctx, executionID)
+	if err != nil || !ok {
+		return err
+	}
+	defer unlock()
+
+	run, err := e.queue.Run(ctx, executionID)
+	if err != nil {
+		return err
+	}
+	if run.Status != execution.ExecutionRunning && run.Status != execution.ExecutionPaused {
+		// another engine finished it
+		return nil
+	}
+	id := run.ID.String()
+
+	def, err := runner.LoadExecutionDefinition(ctx, run.ProjectID, run.WorkflowID, id)
+	if err != nil {
+		return runner.MarkExecutionFailed(ctx, id, err.Error())
+	}
+	rows, err := e.queue.Nodes(ctx, run.ID)
+	if err != nil {
+		return err
+	}
+	state := newDAGState(run, def, rows)
+
+	nodes := make(map[string]*QueuedNode, len(rows))
+	for i := range rows {
+		nodes[rows[i].NodeID] = &rows[i]
+	}
+
+	if run.CancelRequested {
+		// running nodes observe the cancellation through their leases
+		for _, n := range nodes {
+			if n.Leased {
+				return nil
+			}
+		}
+		return runner.MarkExecutionCancelled(ctx, id, unfinished(state, nodes))
+	}
+
+	for _, nodeID := range sortedNodeIDs(state.Graph) {
+		if n := nodes[string(nodeID)]; n != nil && n.Status == execution.NodeFailed && !n.Leased {
+			msg := fmt.Sprintf("node %s failed: %s", nodeID, errorMessage(n.Error))
+			return runner.MarkExecutionFailed(ctx, id, msg)
+		}
+	}
+
+	a := &advancer{
+		engine: e,
+		run:    run,
+		def:    def,
+		state:  state,
+		nodes:  nodes,
+		paused: run.Status == execution.ExecutionPaused,
+	}
+	if err := a.dispatchReady(ctx); err != nil {
+		return runner.MarkExecutionFailed(ctx, id, err.Error())
+	}
+
+	if len(state.Completed) == len(state.Graph.Nodes) {
+		outputs, err := state.Outputs(def)
+		if err != nil {
+			return runner.MarkExecutionFailed(ctx, id, err.Error())
+		}
+		return runner.MarkExecutionSucceeded(ctx, id, outputs)
+	}
+	if !a.inflight && !a.paused {
+		return runner.MarkExecutionFailed(ctx, id, "deadlock detected in DAG")
+	}
+	return nil
+}
+
+// advancer holds one tick's view of a run.
+type advancer struct {
+	engine *EmbeddedEngine
+	run    *EmbeddedRun
+	def    *api.Definition
+	state  *runner.DAGState
+	nodes  map[string]*QueuedNode
+	paused bool
+
+	// policies caches the policies resolved this tick
+	policies map[string]runner.NodePolicy
+
+	// inflight is set once any node is queued, running or retrying
+	inflight bool
+}
+
+// dispatchReady handles every ready node. Skipping or settling a node can
+// make its dependents ready, so it repeats until nothing changed.
+func (a *advancer) dispatchReady(ctx context.Context) error {
+	for {
+		changed, err := a.dispatchPass(ctx)
+		if err != nil || !changed {
+			return err
+		}
+	}
+}
+
+func (a *advancer) dispatchPass(ctx context.Context) (bool, error) {
+	changed := false
+	executionID := a.run.ID.String()
+
+	for _, id := range dag.Ready(*a.state.Graph, a.state.Completed) {
+		node := a.state.Graph.Nodes[id]
+
+		if n := a.nodes[string(id)]; n != nil {
+			if node.ForEach == "" {
+				a.inflight = true
+				continue
+			}
+			settled, err := a.advanceForEach(ctx, node)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || settled
+			continue
+		}
+		if a.paused {
+			continue
+		}
+
+		reason, skip, err := a.state.SkipReason(node)
+		if err != nil {
+			return false, fmt.Errorf("node %s: %w", id, err)
+		}
+		if skip {
+			// Recording the skip is bookkeeping; the run continues if it fails
+			_ = runner.MarkNodeSkipped(ctx, executionID, string(id), reason)
+			a.state.MarkSkipped(id)
+			changed = true
+			continue
+		}
+
+		if node.ForEach != "" {
+			items, err := a.state.ForEachItems(node)
+			if err != nil {
+				return false, fmt.Errorf("node %s: %w", id, err)
+			}
+			if err := runner.MarkFanOutStarted(ctx, executionID, string(id), len(items)); err != nil {
+				return false, err
+			}
+			a.nodes[string(id)] = &QueuedNode{}
+
+			settled, err := a.advanceForEach(ctx, node)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || settled
+			continue
+		}
+
+		if err := a.enqueue(ctx, string(id)); err != nil {
+			return false, err
+		}
+	}
+
+	return changed, nil
+}
+
+// advanceForEach queues a for_each node's instances in element order, at
+// most MaxParallel at a time, and settles the node once they all
+// succeeded, or once a failed one's in-flight siblings finished. It
+// reports whether the node completed.
+func (a *advancer) advanceForEach(ctx context.Context, node *dag.Node) (bool, error) {
+	items, err := a.state.ForEachItems(node)
+	if err != nil {
+		return false, fmt.Errorf("node %s: %w", node.ID, err)
+	}
+
+	limit := node.MaxParallel
+	if limit <= 0 || limit > len(items) {
+		limit = len(items)
+	}
+
+	results := make([]interface{}, len(items))
+	succeeded, inflight := 0, 0
+	failed := ""
+	var pending []int
+
+	for i := range items {
+		instance := runner.InstanceID(node.ID, i)
+		n := a.nodes[instance]
+		switch {
+		case n == nil:
+			pending = append(pending, i)
+		case n.Status == execution.NodeSucceeded:
+			results[i] = n.Output
+			succeeded++
+		case n.Status == execution.NodeFailed && !n.Leased:
+			if failed == "" {
+				failed = fmt.Sprintf("%s: %s", instance, errorMessage(n.Error))
+			}
+		default:
+			inflight++
+		}
+	}
+
+	executionID := a.run.ID.String()
+	id := string(node.ID)
+
+	if failed != "" || succeeded == len(items) {
+		if inflight > 0 {
+			a.inflight = true
+			return false, nil
+		}
+		if failed != "" {
+			// the parent is FAILED now and fails the run on the next tick
+			a.inflight = true
+			return false, runner.MarkFanOutCompleted(ctx, executionID, id, nil, failed)
+		}
+
+		outputs := map[string]interface{}{runner.ForEachOutputKey: results}
+		if err := runner.MarkFanOutCompleted(ctx, executionID, id, outputs, ""); err != nil {
+			return false, err
+		}
+		a.state.StepOutputs[id] = outputs
+		a.state.Completed[node.ID] = true
+		return true, nil
+	}
+
+	a.inflight = true
+	if a.paused {
+		return false, nil
+	}
+	for _, i := range pending {
+		if inflight >= limit {
+			break
+		}
+		if err := a.enqueue(ctx, runner.InstanceID(node.ID, i)); err != nil {
+			return false, err
+		}
+		inflight++
+	}
+	return false, nil
+}
+
+func (a *advancer) enqueue(ctx context.Context, nodeID string) error {
+	parent, _, _ := runner.ParseInstanceID(nodeID)
+	policy, ok := a.policies[string(parent)]
+	if !ok {
+		var err error
+		if policy, err = runner.ResolveNodePolicy(ctx, a.run.ProjectID, a.def, string(parent)); err != nil {
+			return err
+		}
+		if a.policies == nil {
+			a.policies = map[string]runner.NodePolicy{}
+		}
+		a.policies[string(parent)] = policy
+	}
+
+	maxAttempts := int(policy.Retry.MaximumAttempts)
+	if err := a.engine.queue.Enqueue(ctx, a.run.ID, nodeID, maxAttempts); err != nil {
+		return err
+	}
+	a.nodes[nodeID] = &QueuedNode{}
+	a.inflight = true
+	return nil
+}
+
+// load reads a run and its definition and rebuilds its DAG state from the
+// node rows.
+func (e *EmbeddedEngine) load(
+	ctx context.Context,
+	executionID uuid.UUID,
+) (*EmbeddedRun, *api.Definition, *runner.DAGState, error) {
+
+	run, err := e.queue.Run(ctx, executionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	def, err := runner.LoadExecutionDefinition(ctx, run.ProjectID, run.WorkflowID, run.ID.String())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rows, err := e.queue.Nodes(ctx, executionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return run, def, newDAGState(run, def, rows), nil
+}
+
+// newDAGState marks the nodes whose rows succeeded or were skipped as
+// completed, then applies the run's `with` overrides.
+func newDAGState(run *EmbeddedRun, def *api.Definition, rows []QueuedNode) *runner.DAGState {
+	state := runner.NewDAGState(dag.Build(def), run.Inputs)
+
+	for _, n := range rows {
+		id := dag.NodeID(n.NodeID)
+		if _, ok := state.Graph.Nodes[id]; !ok {
+			continue
+		}
+		switch n.Status {
+		case execution.NodeSucceeded:
+			state.Completed[id] = true
+			state.StepOutputs[n.NodeID] = n.Output
+		case execution.NodeSkipped:
+			state.MarkSkipped(id)
+		}
+	}
+
+	state.Seed(&runner.RetryState{NodeInputs: run.NodeInputs})
+	return state
+}
+
+// unfinished returns the sorted IDs of nodes, and of for_each instances,
+// that did not settle.
+func unfinished(state *runner.DAGState, nodes map[string]*QueuedNode) []string {
+	ids := []string{}
+	for id := range state.Graph.Nodes {
+		if !state.Completed[id] {
+			ids = append(ids, string(id))
+		}
+	}
+	for nodeID, n := range nodes {
+		if _, _, ok := runner.ParseInstanceID(nodeID); !ok {
+			continue
+		}
+		switch n.Status {
+		case execution.NodeSucceeded, execution.NodeFailed, execution.NodeSkipped:
+		default:
+			ids = append(ids, nodeID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sortedNodeIDs(g *dag.Graph) []dag.NodeID {
+	ids := g.NodeIDs()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// errorMessage extracts the message of a node's recorded error.
+func errorMessage(errPayload map[string]any) string {
+	if msg, ok := errPayload["message"].(string); ok {
+		return msg
+	}
+	b, _ := json.Marshal(errPayload)
+	return string(b)
+}
@@ -0,0 +1,484 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+// memQueue is a NodeQueue holding one run in memory. It is the runner's
+// node store as well, so what the runner records is what advance reads
+// back on the next tick.
+type memQueue struct {
+	// unused methods panic
+	execution.NodeStore
+
+	run   *EmbeddedRun
+	nodes map[string]*QueuedNode
+	// enqueued lists node IDs in the order they were queued
+	enqueued []string
+}
+
+func (q *memQueue) Adopt(ctx context.Context, executionID uuid.UUID) error {
+	if q.run.Status == execution.ExecutionPending {
+		q.run.Status = execution.ExecutionRunning
+		q.run.Engine = EngineEmbedded
+	}
+	return nil
+}
+
+func (q *memQueue) RequestCancel(ctx context.Context, executionID uuid.UUID) error {
+	q.run.CancelRequested = true
+	return nil
+}
+
+func (q *memQueue) Runs(ctx context.Context) ([]*EmbeddedRun, error) {
+	return []*EmbeddedRun{q.run}, nil
+}
+
+func (q *memQueue) Run(ctx context.Context, executionID uuid.UUID) (*EmbeddedRun, error) {
+	run := *q.run
+	return &run, nil
+}
+
+func (q *memQueue) Lock(ctx context.Context, executionID uuid.UUID) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+func (q *memQueue) Nodes(ctx context.Context, executionID uuid.UUID) ([]QueuedNode, error) {
+	rows := make([]QueuedNode, 0, len(q.nodes))
+	for _, n := range q.nodes {
+		rows = append(rows, *n)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].NodeID < rows[j].NodeID })
+	return rows, nil
+}
+
+func (q *memQueue) Enqueue(ctx context.Context, executionID uuid.UUID, nodeID string, maxAttempts int) error {
+	if _, ok := q.nodes[nodeID]; ok {
+		return nil
+	}
+	q.setNode(nodeID, execution.NodePending)
+	q.nodes[nodeID].MaxAttempts = maxAttempts
+	q.enqueued = append(q.enqueued, nodeID)
+	return nil
+}
+
+func (q *memQueue) Claim(ctx context.Context, owner string, limit int, ttl time.Duration) ([]QueuedNode, error) {
+	var claimed []QueuedNode
+	for _, id := range q.enqueued {
+		n := q.nodes[id]
+		if len(claimed) == limit || n.Leased || n.Status != execution.NodePending {
+			continue
+		}
+		n.Leased = true
+		claimed = append(claimed, *n)
+	}
+	return claimed, nil
+}
+
+func (q *memQueue) Renew(ctx context.Context, executionID uuid.UUID, nodeID, owner string, ttl time.Duration) (bool, error) {
+	return q.nodes[nodeID].Leased, nil
+}
+
+func (q *memQueue) Retry(ctx context.Context, executionID uuid.UUID, nodeID, owner string, at time.Time) error {
+	q.nodes[nodeID].Leased = false
+	q.nodes[nodeID].Status = execution.NodeRetrying
+	return nil
+}
+
+func (q *memQueue) Release(ctx context.Context, executionID uuid.UUID, nodeID, owner string) error {
+	q.nodes[nodeID].Leased = false
+	return nil
+}
+
+func (q *memQueue) Upsert(ctx context.Context, node *execution.ExecutionNode) error {
+	q.setNode(node.NodeID, node.Status)
+	return nil
+}
+
+func (q *memQueue) MarkRunning(ctx context.Context, executionID uuid.UUID, nodeID string) error {
+	q.setNode(nodeID, execution.NodeRunning)
+	return nil
+}
+
+func (q *memQueue) MarkSucceeded(ctx context.Context, executionID uuid.UUID, nodeID string, output map[string]any) error {
+	q.setNode(nodeID, execution.NodeSucceeded).Output = output
+	return nil
+}
+
+func (q *memQueue) MarkFailed(ctx context.Context, executionID uuid.UUID, nodeID string, err map[string]any) error {
+	q.setNode(nodeID, execution.NodeFailed).Error = err
+	return nil
+}
+
+func (q *memQueue) MarkSkipped(ctx context.Context, executionID uuid.UUID, nodeID string, reason map[string]any) error {
+	q.setNode(nodeID, execution.NodeSkipped).Error = reason
+	return nil
+}
+
+func (q *memQueue) setNode(nodeID string, status execution.NodeStatus) *QueuedNode {
+	n, ok := q.nodes[nodeID]
+	if !ok {
+		n = &QueuedNode{ExecutionNode: execution.ExecutionNode{ExecutionID: q.run.ID, NodeID: nodeID}}
+		q.nodes[nodeID] = n
+	}
+	n.Status = status
+	return n
+}
+
+// settle records the outcome of a node a worker ran.
+func (q *memQueue) settle(nodeID string, status execution.NodeStatus, payload map[string]any) {
+	n := q.setNode(nodeID, status)
+	n.Leased = false
+	if status == execution.NodeSucceeded {
+		n.Output = payload
+	} else {
+		n.Error = payload
+	}
+}
+
+// memExecutions is the runner's execution store for the run of a memQueue.
+type memExecutions struct {
+	// unused methods panic
+	execution.ExecutionStore
+
+	q   *memQueue
+	def string
+
+	outputs map[string]any
+	reason  map[string]any
+}
+
+func (s *memExecutions) GetDefinition(ctx context.Context, executionID uuid.UUID) (string, error) {
+	return s.def, nil
+}
+
+func (s *memExecutions) MarkCompleted(ctx context.Context, executionID uuid.UUID, outputs map[string]any) error {
+	s.q.run.Status = execution.ExecutionSucceeded
+	s.outputs = outputs
+	return nil
+}
+
+func (s *memExecutions) MarkFailed(ctx context.Context, executionID uuid.UUID, err map[string]any) error {
+	s.q.run.Status = execution.ExecutionFailed
+	s.reason = err
+	return nil
+}
+
+func (s *memExecutions) MarkCancelled(ctx context.Context, executionID uuid.UUID, reason map[string]any) error {
+	s.q.run.Status = execution.ExecutionCancelled
+	s.reason = reason
+	return nil
+}
+
+// newTestEngine returns an engine over a RUNNING run of the workflow def.
+// Modules are not looked up, so every node runs with DefaultNodePolicy.
+func newTestEngine(t *testing.T, def string, inputs map[string]interface{}) (*EmbeddedEngine, *memQueue, *memExecutions) {
+	t.Helper()
+
+	q := &memQueue{
+		run: &EmbeddedRun{
+			ID:     uuid.New(),
+			Engine: EngineEmbedded,
+			Status: execution.ExecutionRunning,
+			Inputs: inputs,
+		},
+		nodes: map[string]*QueuedNode{},
+	}
+	execs := &memExecutions{q: q, def: def}
+
+	runner.SetExecutionStore(execs)
+	runner.SetNodeStore(q)
+	t.Cleanup(func() {
+		runner.SetExecutionStore(nil)
+		runner.SetNodeStore(nil)
+	})
+
+	return NewEmbeddedEngine(q, 1), q, execs
+}
+
+// tick advances the run once and returns the nodes queued by it.
+func tick(t *testing.T, e *EmbeddedEngine, q *memQueue) []string {
+	t.Helper()
+	before := len(q.enqueued)
+	if err := e.advance(context.Background(), q.run.ID); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	return q.enqueued[before:]
+}
+
+func assertQueued(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("queued %v, want %v", got, want)
+	}
+}
+
+func assertStatus(t *testing.T, q *memQueue, want execution.ExecutionStatus) {
+	t.Helper()
+	if q.run.Status != want {
+		t.Fatalf("execution is %s, want %s", q.run.Status, want)
+	}
+}
+
+func TestAdvanceQueuesReadyNodes(t *testing.T) {
+	e, q, execs := newTestEngine(t, `
+nodes:
+  a:
+    uses: test.a
+  b:
+    uses: test.b
+    depends_on: [a]
+  c:
+    uses: test.c
+`, nil)
+
+	assertQueued(t, tick(t, e, q), "a", "c")
+	if q.nodes["a"].MaxAttempts != int(runner.DefaultNodePolicy().Retry.MaximumAttempts) {
+		t.Errorf("a is queued with %d attempts", q.nodes["a"].MaxAttempts)
+	}
+
+	// nothing new while a runs
+	q.nodes["a"].Leased = true
+	assertQueued(t, tick(t, e, q))
+
+	q.settle("a", execution.NodeSucceeded, map[string]any{"id": "a1"})
+	assertQueued(t, tick(t, e, q), "b")
+
+	q.settle("b", execution.NodeSucceeded, map[string]any{"id": "b1"})
+	q.settle("c", execution.NodeSucceeded, map[string]any{"id": "c1"})
+	assertQueued(t, tick(t, e, q))
+	assertStatus(t, q, execution.ExecutionSucceeded)
+
+	want := map[string]any{
+		"a": map[string]any{"id": "a1"},
+		"b": map[string]any{"id": "b1"},
+		"c": map[string]any{"id": "c1"},
+	}
+	if !reflect.DeepEqual(execs.outputs, want) {
+		t.Errorf("outputs = %v, want %v", execs.outputs, want)
+	}
+}
+
+func TestAdvancePaused(t *testing.T) {
+	e, q, _ := newTestEngine(t, `
+nodes:
+  a:
+    uses: test.a
+  b:
+    uses: test.b
+    depends_on: [a]
+`, nil)
+
+	q.run.Status = execution.ExecutionPaused
+	assertQueued(t, tick(t, e, q))
+	assertStatus(t, q, execution.ExecutionPaused)
+
+	q.run.Status = execution.ExecutionRunning
+	assertQueued(t, tick(t, e, q), "a")
+
+	// a running node finishes while paused, but its dependents wait
+	q.run.Status = execution.ExecutionPaused
+	q.settle("a", execution.NodeSucceeded, nil)
+	assertQueued(t, tick(t, e, q))
+
+	q.run.Status = execution.ExecutionRunning
+	assertQueued(t, tick(t, e, q), "b")
+}
+
+func TestAdvanceSkipsNodes(t *testing.T) {
+	e, q, _ := newTestEngine(t, `
+nodes:
+  check:
+    uses: test.check
+  create:
+    uses: test.create
+    depends_on: [check]
+    if: steps.check.outputs.exists == false
+  notify:
+    uses: test.notify
+    depends_on: [create]
+  report:
+    uses: test.report
+    depends_on: [create]
+    if: steps.create.status == 'SKIPPED'
+`, nil)
+
+	assertQueued(t, tick(t, e, q), "check")
+
+	q.settle("check", execution.NodeSucceeded, map[string]any{"exists": true})
+	assertQueued(t, tick(t, e, q), "report")
+
+	for _, id := range []string{"create", "notify"} {
+		if q.nodes[id].Status != execution.NodeSkipped {
+			t.Errorf("%s is %s, want SKIPPED", id, q.nodes[id].Status)
+		}
+	}
+
+	q.settle("report", execution.NodeSucceeded, nil)
+	tick(t, e, q)
+	assertStatus(t, q, execution.ExecutionSucceeded)
+}
+
+func TestAdvanceFailedNodeFailsRun(t *testing.T) {
+	e, q, execs := newTestEngine(t, `
+nodes:
+  a:
+    uses: test.a
+  b:
+    uses: test.b
+`, nil)
+
+	assertQueued(t, tick(t, e, q), "a", "b")
+
+	// a failed attempt whose node is being retried is still leased
+	q.settle("a", execution.NodeFailed, map[string]any{"message": "boom"})
+	q.nodes["a"].Leased = true
+	tick(t, e, q)
+	assertStatus(t, q, execution.ExecutionRunning)
+
+	q.nodes["a"].Leased = false
+	tick(t, e, q)
+	assertStatus(t, q, execution.ExecutionFailed)
+	if execs.reason["message"] != "node a failed: boom" {
+		t.Errorf("reason = %v", execs.reason)
+	}
+}
+
+const forEachDef = `
+inputs:
+  users:
+    type: array
+nodes:
+  assign:
+    uses: test.assign
+    for_each: inputs.users
+    max_parallel: %d
+`
+
+func newForEachEngine(t *testing.T, maxParallel int) (*EmbeddedEngine, *memQueue, *memExecutions) {
+	t.Helper()
+	return newTestEngine(t, fmt.Sprintf(forEachDef, maxParallel), map[string]interface{}{
+		"users": []interface{}{"ann", "bob", "cid"},
+	})
+}
+
+func TestAdvanceForEachMaxParallel(t *testing.T) {
+	e, q, execs := newForEachEngine(t, 2)
+
+	assertQueued(t, tick(t, e, q), "assign[0]", "assign[1]")
+	if q.nodes["assign"].Status != execution.NodeRunning {
+		t.Errorf("parent is %s, want RUNNING", q.nodes["assign"].Status)
+	}
+
+	// a slot frees up only once an instance settled
+	q.nodes["assign[0]"].Leased = true
+	assertQueued(t, tick(t, e, q))
+
+	q.settle("assign[1]", execution.NodeSucceeded, map[string]any{"user": "bob"})
+	assertQueued(t, tick(t, e, q), "assign[2]")
+
+	// outputs keep element order whatever order instances finish in
+	q.settle("assign[2]", execution.NodeSucceeded, map[string]any{"user": "cid"})
+	q.settle("assign[0]", execution.NodeSucceeded, map[string]any{"user": "ann"})
+	tick(t, e, q)
+	assertStatus(t, q, execution.ExecutionSucceeded)
+
+	items := []interface{}{
+		map[string]any{"user": "ann"},
+		map[string]any{"user": "bob"},
+		map[string]any{"user": "cid"},
+	}
+	if got := q.nodes["assign"]; got.Status != execution.NodeSucceeded || !reflect.DeepEqual(got.Output[runner.ForEachOutputKey], items) {
+		t.Errorf("parent is %s with %v, want SUCCEEDED with %v", got.Status, got.Output, items)
+	}
+	want := map[string]any{"assign": map[string]interface{}{runner.ForEachOutputKey: items}}
+	if !reflect.DeepEqual(execs.outputs, want) {
+		t.Errorf("outputs = %v, want %v", execs.outputs, want)
+	}
+}
+
+func TestAdvanceForEachFailureDrainsSiblings(t *testing.T) {
+	e, q, execs := newForEachEngine(t, 0)
+
+	assertQueued(t, tick(t, e, q), "assign[0]", "assign[1]", "assign[2]")
+
+	q.settle("assign[0]", execution.NodeFailed, map[string]any{"message": "boom"})
+	q.nodes["assign[1]"].Leased = true
+	q.settle("assign[2]", execution.NodeSucceeded, nil)
+
+	// the parent waits for the instance still running
+	tick(t, e, q)
+	if q.nodes["assign"].Status != execution.NodeRunning {
+		t.Fatalf("parent is %s while an instance runs, want RUNNING", q.nodes["assign"].Status)
+	}
+	assertStatus(t, q, execution.ExecutionRunning)
+
+	q.settle("assign[1]", execution.NodeSucceeded, nil)
+	tick(t, e, q)
+	if q.nodes["assign"].Status != execution.NodeFailed {
+		t.Fatalf("parent is %s, want FAILED", q.nodes["assign"].Status)
+	}
+
+	tick(t, e, q)
+	assertStatus(t, q, execution.ExecutionFailed)
+	if execs.reason["message"] != "node assign failed: assign[0]: boom" {
+		t.Errorf("reason = %v", execs.reason)
+	}
+}
+
+func TestAdvanceForEachFailureQueuesNoMore(t *testing.T) {
+	e, q, _ := newForEachEngine(t, 1)
+
+	assertQueued(t, tick(t, e, q), "assign[0]")
+
+	q.settle("assign[0]", execution.NodeFailed, map[string]any{"message": "boom"})
+	assertQueued(t, tick(t, e, q))
+	tick(t, e, q)
+	assertStatus(t, q, execution.ExecutionFailed)
+}
+
+func TestAdvanceCancelWaitsForLeasedNodes(t *testing.T) {
+	e, q, execs := newForEachEngine(t, 2)
+
+	assertQueued(t, tick(t, e, q), "assign[0]", "assign[1]")
+	q.nodes["assign[0]"].Leased = true
+	q.settle("assign[1]", execution.NodeSucceeded, nil)
+
+	q.run.CancelRequested = true
+	assertQueued(t, tick(t, e, q))
+	assertStatus(t, q, execution.ExecutionRunning)
+
+	// the leased node observed the cancellation and stopped
+	q.nodes["assign[0]"].Leased = false
+	assertQueued(t, tick(t, e, q))
+	assertStatus(t, q, execution.ExecutionCancelled)
+
+	if execs.reason["message"] != "execution cancelled" {
+		t.Errorf("reason = %v", execs.reason)
+	}
+	for id, want := range map[string]execution.NodeStatus{
+		"assign":    execution.NodeSkipped,
+		"assign[0]": execution.NodeSkipped,
+		"assign[1]": execution.NodeSucceeded,
+	} {
+		if got := q.nodes[id].Status; got != want {
+			t.Errorf("%s is %s, want %s", id, got, want)
+		}
+	}
+	if _, ok := q.nodes["assign[2]"]; ok {
+		t.Error("assign[2] was queued after the cancellation")
+	}
+}
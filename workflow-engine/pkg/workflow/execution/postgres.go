@@ -0,0 +1,373 @@
+package execution
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+)
+
+type PostgresNodeQueue struct {
+	DB *sql.DB
+}
+
+func NewPostgresNodeQueue(db *sql.DB) *PostgresNodeQueue {
+	return &PostgresNodeQueue{DB: db}
+}
+
+func (q *PostgresNodeQueue) Adopt(ctx context.Context, executionID uuid.UUID) error {
+	_, err := q.DB.ExecContext(ctx, `
+		UPDATE executions
+		SET
+			engine = $2,
+			state = $3,
+			started_at = now(),
+			updated_at = now()
+		WHERE id = $1 AND state = $4
+	`,
+		executionID,
+		EngineEmbedded,
+		execution.ExecutionRunning,
+		execution.ExecutionPending,
+	)
+	return err
+}
+
+func (q *PostgresNodeQueue) RequestCancel(ctx context.Context, executionID uuid.UUID) error {
+	res, err := q.DB.ExecContext(ctx, `
+		UPDATE executions
+		SET
+			cancel_requested_at = COALESCE(cancel_requested_at, now()),
+			updated_at = now()
+		WHERE id = $1 AND engine = $2 AND state IN ($3, $4)
+	`,
+		executionID,
+		EngineEmbedded,
+		execution.ExecutionRunning,
+		execution.ExecutionPaused,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotStarted
+	}
+	return nil
+}
+
+const runColumns = `
+	id, project_id, workflow_id, engine, state, inputs, node_inputs,
+	cancel_requested_at IS NOT NULL
+`
+
+func (q *PostgresNodeQueue) Runs(ctx context.Context) ([]*EmbeddedRun, error) {
+	rows, err := q.DB.QueryContext(ctx, `
+		SELECT `+runColumns+`
+		FROM executions
+		WHERE engine = $1 AND state IN ($2, $3)
+		ORDER BY created_at
+	`,
+		EngineEmbedded,
+		execution.ExecutionRunning,
+		execution.ExecutionPaused,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*EmbeddedRun
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (q *PostgresNodeQueue) Run(ctx context.Context, executionID uuid.UUID) (*EmbeddedRun, error) {
+	row := q.DB.QueryRowContext(ctx, `
+		SELECT `+runColumns+`
+		FROM executions
+		WHERE id = $1
+	`, executionID)
+	return scanRun(row)
+}
+
+// Lock takes a session-level advisory lock on a connection of its own,
+// held until unlock is called.
+func (q *PostgresNodeQueue) Lock(ctx context.Context, executionID uuid.UUID) (func(), bool, error) {
+	conn, err := q.DB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := "embedded-run:" + executionID.String()
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(hashtext($1))`, key)
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
+func (q *PostgresNodeQueue) Nodes(ctx context.Context, executionID uuid.UUID) ([]QueuedNode, error) {
+	rows, err := q.DB.QueryContext(ctx, `
+		SELECT
+			execution_id, node_id, executor_type, status,
+			attempt, max_attempts, output, error, started_at,
+			lease_owner IS NOT NULL AND lease_expires_at > now()
+		FROM execution_nodes
+		WHERE execution_id = $1
+	`, executionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []QueuedNode
+	for rows.Next() {
+		var n QueuedNode
+		var output, errJSON []byte
+		var started sql.NullTime
+
+		if err := rows.Scan(
+			&n.ExecutionID,
+			&n.NodeID,
+			&n.ExecutorType,
+			&n.Status,
+			&n.Attempt,
+			&n.MaxAttempts,
+			&output,
+			&errJSON,
+			&started,
+			&n.Leased,
+		); err != nil {
+			return nil, err
+		}
+
+		_ = json.Unmarshal(output, &n.Output)
+		_ = json.Unmarshal(errJSON, &n.Error)
+		if started.Valid {
+			n.StartedAt = &started.Time
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+func (q *PostgresNodeQueue) Enqueue(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	maxAttempts int,
+) error {
+
+	_, err := q.DB.ExecContext(ctx, `
+		INSERT INTO execution_nodes (
+			id, execution_id, node_id,
+			executor_type, status,
+			attempt, max_attempts
+		)
+		VALUES ($1,$2,$3,'',$4,1,$5)
+		ON CONFLICT (execution_id, node_id) DO NOTHING
+	`,
+		uuid.New(),
+		executionID,
+		nodeID,
+		execution.NodePending,
+		maxAttempts,
+	)
+	return err
+}
+
+// Claim skips rows other engines are claiming, so concurrent engines never
+// lease the same node. for_each parent rows only track their instances and
+// are never claimed.
+func (q *PostgresNodeQueue) Claim(
+	ctx context.Context,
+	owner string,
+	limit int,
+	ttl time.Duration,
+) ([]QueuedNode, error) {
+
+	rows, err := q.DB.QueryContext(ctx, `
+		UPDATE execution_nodes n
+		SET
+			lease_owner = $1,
+			lease_expires_at = now() + make_interval(secs => $3)
+		WHERE n.id IN (
+			SELECT c.id
+			FROM execution_nodes c
+			JOIN executions e ON e.id = c.execution_id
+			WHERE e.engine = $4
+				AND e.state = $5
+				AND e.cancel_requested_at IS NULL
+				AND c.status IN ($6, $7, $8)
+				AND c.executor_type <> 'for_each'
+				AND (c.lease_expires_at IS NULL OR c.lease_expires_at < now())
+				AND (c.available_at IS NULL OR c.available_at <= now())
+			ORDER BY c.available_at NULLS FIRST
+			LIMIT $2
+			FOR UPDATE OF c SKIP LOCKED
+		)
+		RETURNING
+			n.execution_id, n.node_id, n.executor_type, n.status,
+			n.attempt, n.max_attempts, n.started_at
+	`,
+		owner,
+		limit,
+		ttl.Seconds(),
+		EngineEmbedded,
+		execution.ExecutionRunning,
+		execution.NodePending,
+		execution.NodeRetrying,
+		execution.NodeRunning,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []QueuedNode
+	for rows.Next() {
+		n := QueuedNode{Leased: true}
+		var started sql.NullTime
+
+		if err := rows.Scan(
+			&n.ExecutionID,
+			&n.NodeID,
+			&n.ExecutorType,
+			&n.Status,
+			&n.Attempt,
+			&n.MaxAttempts,
+			&started,
+		); err != nil {
+			return nil, err
+		}
+		if started.Valid {
+			n.StartedAt = &started.Time
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+func (q *PostgresNodeQueue) Renew(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	owner string,
+	ttl time.Duration,
+) (bool, error) {
+
+	res, err := q.DB.ExecContext(ctx, `
+		UPDATE execution_nodes n
+		SET lease_expires_at = now() + make_interval(secs => $4)
+		FROM executions e
+		WHERE n.execution_id = $1 AND n.node_id = $2 AND n.lease_owner = $3
+			AND e.id = n.execution_id
+			AND e.state IN ($5, $6)
+			AND e.cancel_requested_at IS NULL
+	`,
+		executionID,
+		nodeID,
+		owner,
+		ttl.Seconds(),
+		execution.ExecutionRunning,
+		execution.ExecutionPaused,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (q *PostgresNodeQueue) Retry(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	owner string,
+	at time.Time,
+) error {
+
+	_, err := q.DB.ExecContext(ctx, `
+		UPDATE execution_nodes
+		SET
+			status = CASE WHEN status = $5 THEN $6 ELSE status END,
+			lease_owner = NULL,
+			lease_expires_at = NULL,
+			available_at = $4
+		WHERE execution_id = $1 AND node_id = $2 AND lease_owner = $3
+	`,
+		executionID,
+		nodeID,
+		owner,
+		at,
+		execution.NodeFailed,
+		execution.NodeRetrying,
+	)
+	return err
+}
+
+func (q *PostgresNodeQueue) Release(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	owner string,
+) error {
+
+	_, err := q.DB.ExecContext(ctx, `
+		UPDATE execution_nodes
+		SET
+			lease_owner = NULL,
+			lease_expires_at = NULL
+		WHERE execution_id = $1 AND node_id = $2 AND lease_owner = $3
+	`, executionID, nodeID, owner)
+	return err
+}
+
+func scanRun(row interface {
+	Scan(dest ...any) error
+}) (*EmbeddedRun, error) {
+
+	var run EmbeddedRun
+	var inputs, nodeInputs []byte
+
+	if err := row.Scan(
+		&run.ID,
+		&run.ProjectID,
+		&run.WorkflowID,
+		&run.Engine,
+		&run.Status,
+		&inputs,
+		&nodeInputs,
+		&run.CancelRequested,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("execution not found")
+		}
+		return nil, err
+	}
+
+	_ = json.Unmarshal(inputs, &run.Inputs)
+	_ = json.Unmarshal(nodeInputs, &run.NodeInputs)
+	return &run, nil
+}
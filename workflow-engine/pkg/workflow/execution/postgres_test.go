@@ -0,0 +1,228 @@
+package execution
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+)
+
+// The PostgresNodeQueue tests run against the migrated database
+// TEST_DATABASE_URL names, and are skipped without one. Each test works on
+// an execution of its own, which is deleted afterwards.
+
+type pgTestRun struct {
+	q  *PostgresNodeQueue
+	id uuid.UUID
+}
+
+func newPostgresTestRun(t *testing.T) *pgTestRun {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	id := uuid.New()
+	_, err = db.Exec(`
+		INSERT INTO executions (
+			id, project_id, workflow_id, client_request_id,
+			temporal_workflow_id, state, engine
+		)
+		VALUES ($1, 'test', 'queue-test', $2, $2, $3, $4)
+	`, id, id.String(), execution.ExecutionRunning, EngineEmbedded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = db.Exec(`DELETE FROM executions WHERE id = $1`, id) })
+
+	return &pgTestRun{q: NewPostgresNodeQueue(db), id: id}
+}
+
+// claim claims as owner and returns the IDs of this run's nodes it got;
+// other runs in the database may be claimed along the way.
+func (r *pgTestRun) claim(t *testing.T, owner string) []string {
+	t.Helper()
+	nodes, err := r.q.Claim(context.Background(), owner, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	var ids []string
+	for _, n := range nodes {
+		if n.ExecutionID == r.id {
+			ids = append(ids, n.NodeID)
+		} else {
+			_ = r.q.Release(context.Background(), n.ExecutionID, n.NodeID, owner)
+		}
+	}
+	return ids
+}
+
+func (r *pgTestRun) renew(t *testing.T, nodeID, owner string) bool {
+	t.Helper()
+	ok, err := r.q.Renew(context.Background(), r.id, nodeID, owner, time.Minute)
+	if err != nil {
+		t.Fatalf("Renew: %v", err)
+	}
+	return ok
+}
+
+func (r *pgTestRun) exec(t *testing.T, query string, args ...any) {
+	t.Helper()
+	if _, err := r.q.DB.Exec(query, append([]any{r.id}, args...)...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (r *pgTestRun) node(t *testing.T, nodeID string) QueuedNode {
+	t.Helper()
+	nodes, err := r.q.Nodes(context.Background(), r.id)
+	if err != nil {
+		t.Fatalf("Nodes: %v", err)
+	}
+	for _, n := range nodes {
+		if n.NodeID == nodeID {
+			return n
+		}
+	}
+	t.Fatalf("node %s not found", nodeID)
+	return QueuedNode{}
+}
+
+func TestPostgresNodeQueueLease(t *testing.T) {
+	r := newPostgresTestRun(t)
+	ctx := context.Background()
+
+	if err := r.q.Enqueue(ctx, r.id, "a", 3); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if got := r.claim(t, "w1"); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("w1 claimed %v, want [a]", got)
+	}
+	if !r.node(t, "a").Leased {
+		t.Error("the claimed node is not leased")
+	}
+
+	// a live lease keeps the node from other workers
+	if got := r.claim(t, "w2"); len(got) != 0 {
+		t.Fatalf("w2 claimed %v while w1 holds the lease", got)
+	}
+	if r.renew(t, "a", "w2") {
+		t.Error("w2 renewed w1's lease")
+	}
+	if !r.renew(t, "a", "w1") {
+		t.Error("w1 could not renew its lease")
+	}
+
+	// w1 died: once its lease expired, another worker recovers the node
+	r.exec(t, `UPDATE execution_nodes SET lease_expires_at = now() - interval '1 second' WHERE execution_id = $1`)
+	if r.node(t, "a").Leased {
+		t.Error("a node with an expired lease is leased")
+	}
+	if got := r.claim(t, "w2"); len(got) != 1 {
+		t.Fatalf("w2 claimed %v, want the node with the expired lease", got)
+	}
+	if r.renew(t, "a", "w1") {
+		t.Error("w1 renewed a lease it lost")
+	}
+
+	// a late release by the old owner leaves the new lease alone
+	if err := r.q.Release(ctx, r.id, "a", "w1"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if !r.renew(t, "a", "w2") {
+		t.Error("w1's release ended w2's lease")
+	}
+
+	if err := r.q.Release(ctx, r.id, "a", "w2"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if got := r.claim(t, "w3"); len(got) != 1 {
+		t.Fatalf("w3 claimed %v, want the released node", got)
+	}
+}
+
+func TestPostgresNodeQueueRetry(t *testing.T) {
+	r := newPostgresTestRun(t)
+	ctx := context.Background()
+
+	if err := r.q.Enqueue(ctx, r.id, "a", 3); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	r.claim(t, "w1")
+	r.exec(t, `UPDATE execution_nodes SET status = $2 WHERE execution_id = $1`, execution.NodeFailed)
+
+	// only the lease owner can queue the node again
+	if err := r.q.Retry(ctx, r.id, "a", "w2", time.Now()); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	if n := r.node(t, "a"); n.Status != execution.NodeFailed || !n.Leased {
+		t.Fatalf("node is %s, leased %v after another worker's retry", n.Status, n.Leased)
+	}
+
+	if err := r.q.Retry(ctx, r.id, "a", "w1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	if n := r.node(t, "a"); n.Status != execution.NodeRetrying || n.Leased {
+		t.Fatalf("node is %s, leased %v, want RETRYING and free", n.Status, n.Leased)
+	}
+
+	// the backoff holds the node back until it is available again
+	if got := r.claim(t, "w2"); len(got) != 0 {
+		t.Fatalf("w2 claimed %v during the backoff", got)
+	}
+	r.exec(t, `UPDATE execution_nodes SET available_at = now() - interval '1 second' WHERE execution_id = $1`)
+	if got := r.claim(t, "w2"); len(got) != 1 {
+		t.Fatalf("w2 claimed %v, want the node after its backoff", got)
+	}
+}
+
+func TestPostgresNodeQueueInactiveRun(t *testing.T) {
+	r := newPostgresTestRun(t)
+	ctx := context.Background()
+
+	if err := r.q.Enqueue(ctx, r.id, "a", 3); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	r.claim(t, "w1")
+
+	// a paused run keeps its running nodes but starts no new ones
+	r.exec(t, `UPDATE executions SET state = $2 WHERE id = $1`, execution.ExecutionPaused)
+	if !r.renew(t, "a", "w1") {
+		t.Error("a paused run's node lost its lease")
+	}
+	if err := r.q.Release(ctx, r.id, "a", "w1"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if got := r.claim(t, "w1"); len(got) != 0 {
+		t.Fatalf("claimed %v from a paused run", got)
+	}
+
+	// a cancelled run's nodes stop at their next renewal
+	r.exec(t, `UPDATE executions SET state = $2 WHERE id = $1`, execution.ExecutionRunning)
+	r.claim(t, "w1")
+	if err := r.q.RequestCancel(ctx, r.id); err != nil {
+		t.Fatalf("RequestCancel: %v", err)
+	}
+	if r.renew(t, "a", "w1") {
+		t.Error("a cancelled run's node kept its lease")
+	}
+	if err := r.q.Release(ctx, r.id, "a", "w1"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if got := r.claim(t, "w1"); len(got) != 0 {
+		t.Fatalf("claimed %v from a cancelled run", got)
+	}
+}
@@ -0,0 +1,73 @@
+package execution
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+)
+
+// EmbeddedRun is an execution running on the embedded engine.
+type EmbeddedRun struct {
+	ID         uuid.UUID
+	ProjectID  string
+	WorkflowID string
+	// Engine is the backend the execution was started on
+	Engine string
+	Status execution.ExecutionStatus
+	Inputs map[string]interface{}
+	// NodeInputs holds the `with` overrides of a retried run
+	NodeInputs map[string]map[string]interface{}
+	// CancelRequested is set until the run has observed its cancellation
+	CancelRequested bool
+}
+
+// QueuedNode is a node row as the embedded engine sees it.
+type QueuedNode struct {
+	execution.ExecutionNode
+
+	// Leased is true while a live worker holds the node
+	Leased bool
+}
+
+// NodeQueue keeps the state of embedded runs in Postgres. Node rows double
+// as the work queue: a worker claims a node by leasing it, and a node
+// whose lease expired is claimed again, so work survives a crash.
+type NodeQueue interface {
+	// Adopt moves a PENDING execution to RUNNING on the embedded engine.
+	// An execution that is no longer PENDING is left alone.
+	Adopt(ctx context.Context, executionID uuid.UUID) error
+
+	// RequestCancel flags a RUNNING or PAUSED run for cancellation. It
+	// returns ErrNotStarted for a run the engine has not adopted.
+	RequestCancel(ctx context.Context, executionID uuid.UUID) error
+
+	// Runs returns the RUNNING and PAUSED embedded runs.
+	Runs(ctx context.Context) ([]*EmbeddedRun, error)
+	Run(ctx context.Context, executionID uuid.UUID) (*EmbeddedRun, error)
+
+	// Lock serialises advancing a run across engines sharing the
+	// database. ok is false if another engine holds the lock.
+	Lock(ctx context.Context, executionID uuid.UUID) (unlock func(), ok bool, err error)
+
+	Nodes(ctx context.Context, executionID uuid.UUID) ([]QueuedNode, error)
+
+	// Enqueue creates a PENDING row for a node unless it has one.
+	Enqueue(ctx context.Context, executionID uuid.UUID, nodeID string, maxAttempts int) error
+
+	// Claim leases up to limit runnable nodes of RUNNING runs to owner.
+	Claim(ctx context.Context, owner string, limit int, ttl time.Duration) ([]QueuedNode, error)
+
+	// Renew extends owner's lease. It returns false once the lease is
+	// lost or the run is no longer active, and the node should stop.
+	Renew(ctx context.Context, executionID uuid.UUID, nodeID, owner string, ttl time.Duration) (bool, error)
+
+	// Retry releases owner's lease and makes the node runnable again at
+	// `at`; a FAILED node becomes RETRYING.
+	Retry(ctx context.Context, executionID uuid.UUID, nodeID, owner string, at time.Time) error
+
+	// Release gives up owner's lease.
+	Release(ctx context.Context, executionID uuid.UUID, nodeID, owner string) error
+}
@@ -2,28 +2,324 @@ package execution
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
-	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	enums "go.temporal.io/api/enums/v1"
 
-	"go.temporal.io/sdk/client"
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
 )
 
-func Reconcile(ctx context.Context, store execution.ExecutionStore, temporal client.Client) {
-	execs, err := store.ListRunning(ctx)
+// DefaultReconcileInterval is how often the reconciler passes over the
+// active executions.
+const DefaultReconcileInterval = time.Minute
+
+var continuedAsNew = enums.WORKFLOW_EXECUTION_STATUS_CONTINUED_AS_NEW.String()
+
+// Reconciler keeps execution rows in line with their Temporal runs. A
+// workflow records its own progress through activities, and that
+// bookkeeping is best effort: a run that timed out or was terminated, or
+// a start whose MarkRunning was lost, leaves a row PENDING, RUNNING or
+// PAUSED for good. Each pass describes the run of every such execution,
+// corrects the row and appends an event for each correction.
+//
+// Embedded runs keep all their state in Postgres and are not reconciled.
+// The temporal package's stores must be set, as for a worker.
+type Reconciler struct {
+	store    execution.Store
+	backend  ExecutionBackend
+	interval time.Duration
+}
+
+func NewReconciler(store execution.Store, backend ExecutionBackend) *Reconciler {
+	return &Reconciler{
+		store:    store,
+		backend:  backend,
+		interval: DefaultReconcileInterval,
+	}
+}
+
+// Run reconciles every interval until ctx is done.
+func (r *Reconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.ReconcileOnce(ctx); err != nil {
+			log.Printf("reconciler: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReconcileOnce passes over the active executions once. An execution that
+// fails to reconcile is logged and tried again on the next pass.
+func (r *Reconciler) ReconcileOnce(ctx context.Context) error {
+	execs, err := r.store.Executions().ListActive(ctx, EngineTemporal)
+	if err != nil {
+		return fmt.Errorf("listing active executions: %w", err)
+	}
+
+	for _, exec := range execs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := r.reconcile(ctx, exec); err != nil {
+			log.Printf("reconciler: execution %s: %v\n", exec.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, exec *execution.Execution) error {
+	d, err := r.backend.Describe(ctx, exec)
+	if errors.Is(err, ErrNotStarted) {
+		if exec.Status == execution.ExecutionPending {
+			// the dispatcher starts it, or fails it once it gives up
+			return nil
+		}
+		d = &Description{
+			Status:        execution.ExecutionFailed,
+			BackendStatus: "NotFound",
+			Error:         "workflow run not found in Temporal",
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if d.BackendStatus == continuedAsNew {
+		if d, err = r.followContinuedAsNew(ctx, exec); err != nil {
+			return err
+		}
+	}
+
+	// the workflow may have recorded its end since the row was listed
+	current, err := r.store.Executions().Get(ctx, exec.ProjectID, exec.ID)
+	if err != nil {
+		return err
+	}
+	switch current.Status {
+	case execution.ExecutionPending, execution.ExecutionRunning, execution.ExecutionPaused:
+	default:
+		return nil
+	}
+
+	switch d.Status {
+	case execution.ExecutionRunning:
+		if current.Status != execution.ExecutionPending {
+			return nil
+		}
+		err := r.store.Executions().MarkRunning(ctx, current.ID, d.RunID)
+		if errors.Is(err, execution.ErrStateChanged) {
+			// the dispatcher or the workflow got there first
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("Execution was running as run %s", d.RunID)
+		r.appendEvent(ctx, current, nil, msg, string(current.Status), d)
+		return nil
+
+	case execution.ExecutionSucceeded, execution.ExecutionFailed, execution.ExecutionCancelled:
+		return r.close(ctx, current, d)
+	}
+	return nil
+}
+
+// followContinuedAsNew describes the run the workflow continued in and
+// records it on the row.
+func (r *Reconciler) followContinuedAsNew(ctx context.Context, exec *execution.Execution) (*Description, error) {
+	latest := *exec
+	latest.TemporalRunID = ""
+
+	d, err := r.backend.Describe(ctx, &latest)
+	if err != nil {
+		return nil, err
+	}
+	if d.RunID == exec.TemporalRunID {
+		return d, nil
+	}
+
+	if err := r.store.Executions().UpdateRunID(ctx, exec.ID, d.RunID); err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("Workflow continued as new in run %s", d.RunID)
+	r.appendEvent(ctx, exec, nil, msg, string(exec.Status), d)
+	exec.TemporalRunID = d.RunID
+	return d, nil
+}
+
+// close records a run that ended without recording it. The node rows are
+// repaired first, so a failure leaves the execution for the next pass.
+func (r *Reconciler) close(ctx context.Context, exec *execution.Execution, d *Description) error {
+	def, err := runner.LoadExecutionDefinition(ctx, exec.ProjectID, exec.WorkflowID, exec.ID.String())
+	if err != nil {
+		return err
+	}
+	rows, err := r.store.Nodes().ListByExecution(ctx, exec.ID)
 	if err != nil {
-		log.Println("Reconcile: failed to list running executions:", err)
-		return
+		return err
+	}
+
+	executions := r.store.Executions()
+	switch d.Status {
+	case execution.ExecutionSucceeded:
+		outputs, err := runOutputs(exec, def, rows)
+		if err != nil {
+			// the run completed; only its outputs are lost
+			log.Printf("reconciler: execution %s: outputs: %v\n", exec.ID, err)
+		}
+		if err := r.repairNodes(ctx, exec, def, rows, d); err != nil {
+			return err
+		}
+		if err := executions.MarkCompleted(ctx, exec.ID, outputs); err != nil {
+			return finishedMeanwhile(err)
+		}
+
+	case execution.ExecutionFailed:
+		if err := r.repairNodes(ctx, exec, def, rows, d); err != nil {
+			return err
+		}
+		msg := d.Error
+		if msg == "" {
+			msg = "workflow run " + d.BackendStatus
+		}
+		if err := executions.MarkFailed(ctx, exec.ID, map[string]any{
+			"message":         msg,
+			"temporal_status": d.BackendStatus,
+		}); err != nil {
+			return finishedMeanwhile(err)
+		}
+
+	case execution.ExecutionCancelled:
+		if err := r.repairNodes(ctx, exec, def, rows, d); err != nil {
+			return err
+		}
+		if err := executions.MarkCancelled(ctx, exec.ID, map[string]any{"message": "execution cancelled"}); err != nil {
+			return finishedMeanwhile(err)
+		}
+	}
+
+	msg := fmt.Sprintf("Execution %s in Temporal (%s); marked %s", d.BackendStatus, d.Error, d.Status)
+	if d.Error == "" {
+		msg = fmt.Sprintf("Execution %s in Temporal; marked %s", d.BackendStatus, d.Status)
+	}
+	r.appendEvent(ctx, exec, nil, msg, string(exec.Status), d)
+	return nil
+}
+
+// finishedMeanwhile drops the ErrStateChanged of an execution the run's
+// own bookkeeping finished after it was read.
+func finishedMeanwhile(err error) error {
+	if errors.Is(err, execution.ErrStateChanged) {
+		return nil
+	}
+	return err
+}
+
+// repairNodes settles the nodes a closed run left unrecorded: nodes with
+// no row are recorded SKIPPED, unfinished rows become FAILED in a failed
+// run and SKIPPED otherwise.
+func (r *Reconciler) repairNodes(
+	ctx context.Context,
+	exec *execution.Execution,
+	def *api.Definition,
+	rows []execution.ExecutionNode,
+	d *Description,
+) error {
+
+	nodes := r.store.Nodes()
+	reason := map[string]any{
+		"message": fmt.Sprintf("node did not finish: execution %s in Temporal", d.BackendStatus),
 	}
 
-	for _, e := range execs {
-		we := temporal.GetWorkflow(ctx, e.TemporalWorkflowID, "")
-		var status string
-		err := we.Get(ctx, &status)
+	seen := map[string]bool{}
+	for _, n := range rows {
+		seen[n.NodeID] = true
+
+		switch n.Status {
+		case execution.NodePending, execution.NodeRunning, execution.NodeRetrying:
+		default:
+			continue
+		}
+
+		to := execution.NodeSkipped
+		var err error
+		if d.Status == execution.ExecutionFailed {
+			to = execution.NodeFailed
+			err = nodes.MarkFailed(ctx, exec.ID, n.NodeID, reason)
+		} else {
+			err = nodes.MarkSkipped(ctx, exec.ID, n.NodeID, reason)
+		}
 		if err != nil {
-			store.MarkFailed(ctx, e.ID, map[string]any{"message": err.Error()})
-		} else if status == "COMPLETED" {
-			store.MarkCompleted(ctx, e.ID, nil)
+			return err
 		}
+
+		nodeID := n.NodeID
+		msg := fmt.Sprintf("Node was %s; marked %s", n.Status, to)
+		r.appendEvent(ctx, exec, &nodeID, msg, string(n.Status), d)
+	}
+
+	for _, id := range sortedNodeIDs(dag.Build(def)) {
+		nodeID := string(id)
+		if seen[nodeID] {
+			continue
+		}
+		if err := nodes.MarkSkipped(ctx, exec.ID, nodeID, reason); err != nil {
+			return err
+		}
+		r.appendEvent(ctx, exec, &nodeID, "Node had no record; marked SKIPPED", "", d)
+	}
+	return nil
+}
+
+// appendEvent records a correction. Events are bookkeeping; the
+// correction stands if appending fails.
+func (r *Reconciler) appendEvent(
+	ctx context.Context,
+	exec *execution.Execution,
+	nodeID *string,
+	msg string,
+	from string,
+	d *Description,
+) {
+
+	eventType := execution.EventExecutionReconciled
+	if nodeID != nil {
+		eventType = execution.EventNodeReconciled
+	}
+
+	_ = r.store.Events().Append(ctx, &execution.ExecutionEvent{
+		ExecutionID: exec.ID,
+		NodeID:      nodeID,
+		EventType:   eventType,
+		Message:     msg,
+		Payload: map[string]any{
+			"from":            from,
+			"temporal_status": d.BackendStatus,
+			"temporal_run_id": d.RunID,
+		},
+	})
+}
+
+// runOutputs renders the workflow outputs from the node rows, as the run
+// would have on completing.
+func runOutputs(exec *execution.Execution, def *api.Definition, rows []execution.ExecutionNode) (map[string]interface{}, error) {
+	queued := make([]QueuedNode, len(rows))
+	for i, n := range rows {
+		queued[i] = QueuedNode{ExecutionNode: n}
 	}
+	run := &EmbeddedRun{ID: exec.ID, Inputs: exec.Inputs}
+	return newDAGState(run, def, queued).Outputs(def)
 }
@@ -0,0 +1,174 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	enums "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/temporal"
+)
+
+// TemporalEngine runs each execution as a WorkflowExecution in its
+// project's Temporal namespace.
+type TemporalEngine struct {
+	store execution.ExecutionStore
+}
+
+func NewTemporalEngine(store execution.ExecutionStore) *TemporalEngine {
+	return &TemporalEngine{store: store}
+}
+
+// Start is idempotent: the execution's TemporalWorkflowID is never reused,
+// so starting it again, e.g. after a dispatcher died before recording the
+// start, finds the run the first start created instead of a second one.
+func (e *TemporalEngine) Start(
+	ctx context.Context,
+	exec *execution.Execution,
+	retry *runner.RetryState,
+) error {
+
+	tc, err := temporal.GetClientForProject(exec.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Temporal: %w", err)
+	}
+
+	opts := client.StartWorkflowOptions{
+		ID:                                       exec.TemporalWorkflowID,
+		TaskQueue:                                temporal.TaskQueue,
+		WorkflowIDReusePolicy:                    enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}
+
+	we, err := tc.Client.ExecuteWorkflow(
+		ctx,
+		opts,
+		temporal.WorkflowExecution,
+		exec.ID.String(),
+		exec.ProjectID,
+		exec.WorkflowID,
+		exec.Inputs,
+		retry,
+	)
+	runID := ""
+	var started *serviceerror.WorkflowExecutionAlreadyStarted
+	switch {
+	case errors.As(err, &started):
+		runID = started.RunId
+	case err != nil:
+		return err
+	default:
+		runID = we.GetRunID()
+	}
+
+	err = e.store.MarkRunning(ctx, exec.ID, runID)
+	if !errors.Is(err, execution.ErrStateChanged) {
+		// The workflow is running either way; the reconciler catches the
+		// row up if this failed
+		return nil
+	}
+
+	// The row left PENDING meanwhile: the run already finished, or the
+	// execution was cancelled before it started and the run must stop
+	_ = e.store.UpdateRunID(ctx, exec.ID, runID)
+	current, err := e.store.Get(ctx, exec.ProjectID, exec.ID)
+	if err != nil || current.Status != execution.ExecutionCancelled {
+		return nil
+	}
+	if err := tc.Client.CancelWorkflow(ctx, exec.TemporalWorkflowID, runID); err != nil {
+		return fmt.Errorf("failed to cancel workflow of cancelled execution: %w", err)
+	}
+	return nil
+}
+
+// Cancel requests cancellation of the workflow, which observes it, waits
+// for running nodes and marks the execution CANCELLED itself.
+func (e *TemporalEngine) Cancel(ctx context.Context, exec *execution.Execution) error {
+	tc, err := temporal.GetClientForProject(exec.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Temporal: %w", err)
+	}
+
+	err = tc.Client.CancelWorkflow(ctx, exec.TemporalWorkflowID, exec.TemporalRunID)
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return ErrNotStarted
+	}
+	return err
+}
+
+func (e *TemporalEngine) Signal(ctx context.Context, exec *execution.Execution, signal string) error {
+	tc, err := temporal.GetClientForProject(exec.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Temporal: %w", err)
+	}
+
+	if err := tc.Client.SignalWorkflow(
+		ctx,
+		exec.TemporalWorkflowID,
+		exec.TemporalRunID,
+		signal,
+		nil,
+	); err != nil {
+		return fmt.Errorf("failed to signal workflow: %w", err)
+	}
+	return nil
+}
+
+// Describe reports a paused execution as RUNNING; pausing is the
+// workflow's own state, not Temporal's.
+func (e *TemporalEngine) Describe(ctx context.Context, exec *execution.Execution) (*Description, error) {
+	tc, err := temporal.GetClientForProject(exec.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Temporal: %w", err)
+	}
+
+	resp, err := tc.Client.DescribeWorkflowExecution(ctx, exec.TemporalWorkflowID, exec.TemporalRunID)
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return nil, ErrNotStarted
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info := resp.GetWorkflowExecutionInfo()
+	d := &Description{
+		Status:        workflowStatus(info.GetStatus()),
+		BackendStatus: info.GetStatus().String(),
+		RunID:         info.GetExecution().GetRunId(),
+	}
+
+	switch info.GetStatus() {
+	case enums.WORKFLOW_EXECUTION_STATUS_FAILED,
+		enums.WORKFLOW_EXECUTION_STATUS_TIMED_OUT,
+		enums.WORKFLOW_EXECUTION_STATUS_TERMINATED,
+		enums.WORKFLOW_EXECUTION_STATUS_CANCELED:
+		// the run is closed, so its result is at hand
+		if err := tc.Client.GetWorkflow(ctx, exec.TemporalWorkflowID, d.RunID).Get(ctx, nil); err != nil {
+			d.Error = err.Error()
+		}
+	}
+	return d, nil
+}
+
+// workflowStatus maps a Temporal workflow status onto an execution state.
+// A run that continued as new goes on in its successor.
+func workflowStatus(status enums.WorkflowExecutionStatus) execution.ExecutionStatus {
+	switch status {
+	case enums.WORKFLOW_EXECUTION_STATUS_COMPLETED:
+		return execution.ExecutionSucceeded
+	case enums.WORKFLOW_EXECUTION_STATUS_FAILED,
+		enums.WORKFLOW_EXECUTION_STATUS_TIMED_OUT,
+		enums.WORKFLOW_EXECUTION_STATUS_TERMINATED:
+		return execution.ExecutionFailed
+	case enums.WORKFLOW_EXECUTION_STATUS_CANCELED:
+		return execution.ExecutionCancelled
+	}
+	return execution.ExecutionRunning
+}
@@ -0,0 +1,386 @@
+package container
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultDockerHost is the local Docker socket. containerd users can point
+// DockerHost at a Docker-compatible socket such as nerdctl's or Podman's.
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// cleanupTimeout bounds the kill and remove calls made after ctx is done.
+const cleanupTimeout = 30 * time.Second
+
+// DockerRuntime runs containers through the Docker Engine API.
+type DockerRuntime struct {
+	client *http.Client
+	base   string
+}
+
+// NewDockerRuntime connects to host, e.g. "unix:///var/run/docker.sock" or
+// "tcp://127.0.0.1:2375". An empty host uses DefaultDockerHost.
+func NewDockerRuntime(host string) (*DockerRuntime, error) {
+	if host == "" {
+		host = DefaultDockerHost
+	}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &DockerRuntime{
+			client: &http.Client{Transport: transport},
+			base:   "http://docker",
+		}, nil
+	case "tcp", "http":
+		return &DockerRuntime{
+			client: &http.Client{},
+			base:   "http://" + u.Host,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported docker host scheme %q", u.Scheme)
+}
+
+// Run pulls the image if needed, runs the container with the spec's limits,
+// streams its logs and removes it once it exited.
+func (d *DockerRuntime) Run(ctx context.Context, spec *Spec, logs LogFunc) (*Result, error) {
+	nanoCPUs, err := ParseCPU(spec.CPU)
+	if err != nil {
+		return nil, err
+	}
+	memory, err := ParseMemory(spec.Memory)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.ensureImage(ctx, spec.Image); err != nil {
+		return nil, err
+	}
+
+	env := envList(spec.Env)
+	env = append(env, InputsEnv+"="+string(spec.Inputs))
+
+	create := map[string]interface{}{
+		"Image":        spec.Image,
+		"Env":          env,
+		"OpenStdin":    true,
+		"StdinOnce":    true,
+		"AttachStdin":  true,
+		"AttachStdout": false,
+		"AttachStderr": false,
+		"Labels":       map[string]string{"workflow-engine": "true"},
+		"HostConfig": map[string]interface{}{
+			"NanoCpus": nanoCPUs,
+			"Memory":   memory,
+		},
+	}
+	if len(spec.Command) > 0 {
+		create["Cmd"] = spec.Command
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := d.do(ctx, http.MethodPost, "/containers/create?name="+url.QueryEscape(spec.Name), create, &created); err != nil {
+		return nil, err
+	}
+	id := created.ID
+
+	defer func() {
+		cctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		_ = d.do(cctx, http.MethodDelete, "/containers/"+id+"?force=1", nil, nil)
+	}()
+
+	stdin, err := d.attachStdin(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil); err != nil {
+		stdin.Close()
+		return nil, err
+	}
+
+	// closing the attach stream closes the container's stdin (StdinOnce)
+	_, _ = stdin.Write(spec.Inputs)
+	stdin.Close()
+
+	logsDone := make(chan []byte, 1)
+	go func() {
+		logsDone <- d.followLogs(ctx, id, logs)
+	}()
+
+	var waited struct {
+		StatusCode int `json:"StatusCode"`
+	}
+	if err := d.do(ctx, http.MethodPost, "/containers/"+id+"/wait?condition=not-running", nil, &waited); err != nil {
+		if ctx.Err() != nil {
+			kctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+			_ = d.do(kctx, http.MethodPost, "/containers/"+id+"/kill", nil, nil)
+			cancel()
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	stdout := <-logsDone
+
+	outputs, err := d.readFile(ctx, id, OutputsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		ExitCode: waited.StatusCode,
+		Stdout:   stdout,
+		Outputs:  outputs,
+	}, nil
+}
+
+func (d *DockerRuntime) ensureImage(ctx context.Context, image string) error {
+	err := d.do(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil)
+	if err == nil {
+		return nil
+	}
+	var apiErr *dockerError
+	if !errors.As(err, &apiErr) || apiErr.status != http.StatusNotFound {
+		return err
+	}
+
+	resp, err := d.request(ctx, http.MethodPost, "/images/create?fromImage="+url.QueryEscape(image), nil)
+	if err != nil {
+		return fmt.Errorf("pull %s: %w", image, err)
+	}
+	defer resp.Body.Close()
+
+	// the pull reports progress, and failures, as a stream of JSON messages
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("pull %s: %w", image, err)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("pull %s: %s", image, msg.Error)
+		}
+	}
+}
+
+// attachStdin hijacks an attach connection for the container's stdin.
+func (d *DockerRuntime) attachStdin(ctx context.Context, id string) (io.WriteCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		d.base+"/containers/"+id+"/attach?stream=1&stdin=1", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("attach: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		return nil, readDockerError(resp)
+	}
+
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("attach: connection cannot be hijacked")
+	}
+	return conn, nil
+}
+
+// followLogs streams the container's output to logs until it exits and
+// returns everything written to stdout.
+func (d *DockerRuntime) followLogs(ctx context.Context, id string, logs LogFunc) []byte {
+	resp, err := d.request(ctx, http.MethodGet, "/containers/"+id+"/logs?follow=1&stdout=1&stderr=1", nil)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var stdout bytes.Buffer
+	outLines := &LineWriter{Stream: Stdout, Logs: logs}
+	errLines := &LineWriter{Stream: Stderr, Logs: logs}
+
+	// non-TTY output is multiplexed: an 8-byte header carries the stream
+	// (1 stdout, 2 stderr) and the big-endian frame length
+	r := bufio.NewReader(resp.Body)
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			break
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.ReadFull(r, frame); err != nil {
+			break
+		}
+		if header[0] == 2 {
+			_, _ = errLines.Write(frame)
+			continue
+		}
+		stdout.Write(frame)
+		_, _ = outLines.Write(frame)
+	}
+
+	outLines.Flush()
+	errLines.Flush()
+	return stdout.Bytes()
+}
+
+// readFile returns a single file from the container, or nil if it does not
+// exist.
+func (d *DockerRuntime) readFile(ctx context.Context, id, path string) ([]byte, error) {
+	resp, err := d.request(ctx, http.MethodGet, "/containers/"+id+"/archive?path="+url.QueryEscape(path), nil)
+	if err != nil {
+		var apiErr *dockerError
+		if errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	tr := tar.NewReader(resp.Body)
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// do sends a JSON request and decodes a JSON response into out, if set.
+func (d *DockerRuntime) do(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := d.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (d *DockerRuntime) request(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, d.base+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, readDockerError(resp)
+	}
+	return resp, nil
+}
+
+type dockerError struct {
+	status  int
+	message string
+}
+
+func (e *dockerError) Error() string {
+	return fmt.Sprintf("docker: %s (status %d)", e.message, e.status)
+}
+
+func readDockerError(resp *http.Response) error {
+	var body struct {
+		Message string `json:"message"`
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(raw, &body) != nil || body.Message == "" {
+		body.Message = strings.TrimSpace(string(raw))
+	}
+	return &dockerError{status: resp.StatusCode, message: body.Message}
+}
+
+// LineWriter splits a byte stream into lines for a LogFunc. Call Flush
+// once the stream ended to emit a trailing partial line.
+type LineWriter struct {
+	Stream string
+	Logs   LogFunc
+	buf    []byte
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+}
+
+func (w *LineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *LineWriter) emit(line []byte) {
+	if w.Logs != nil {
+		w.Logs(w.Stream, strings.TrimSuffix(string(line), "\r"))
+	}
+}
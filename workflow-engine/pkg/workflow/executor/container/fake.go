@@ -0,0 +1,34 @@
+package container
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeRuntime records every run instead of starting a container. By default
+// it echoes the inputs back on stdout; set Handler to script a result.
+type FakeRuntime struct {
+	Handler func(ctx context.Context, spec *Spec, logs LogFunc) (*Result, error)
+
+	mu   sync.Mutex
+	runs []Spec
+}
+
+// Run records spec and returns the handler's result.
+func (f *FakeRuntime) Run(ctx context.Context, spec *Spec, logs LogFunc) (*Result, error) {
+	f.mu.Lock()
+	f.runs = append(f.runs, *spec)
+	f.mu.Unlock()
+
+	if f.Handler != nil {
+		return f.Handler(ctx, spec, logs)
+	}
+	return &Result{Stdout: spec.Inputs}, nil
+}
+
+// Runs returns the specs passed to Run so far.
+func (f *FakeRuntime) Runs() []Spec {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Spec(nil), f.runs...)
+}
@@ -0,0 +1,288 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	pollInterval      = time.Second
+	// jobTTL lets the cluster collect Jobs the runtime failed to delete.
+	jobTTL = 300
+)
+
+// KubernetesRuntime runs each container as a batch/v1 Job. Kubernetes has
+// no stdin or file copy for Jobs, so inputs arrive only through InputsEnv
+// and outputs are read from the pod log.
+type KubernetesRuntime struct {
+	client    *http.Client
+	base      string
+	token     string
+	namespace string
+}
+
+// NewInClusterKubernetesRuntime uses the pod's service account to create
+// Jobs in namespace. An empty namespace means the pod's own.
+func NewInClusterKubernetesRuntime(namespace string) (*KubernetesRuntime, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running inside a Kubernetes cluster")
+	}
+
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("read service account token: %w", err)
+	}
+
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("read service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid service account CA")
+	}
+
+	if namespace == "" {
+		ns, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("read service account namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(ns))
+	}
+
+	return &KubernetesRuntime{
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+		base:      "https://" + net.JoinHostPort(host, port),
+		token:     strings.TrimSpace(string(token)),
+		namespace: namespace,
+	}, nil
+}
+
+// Run creates a Job for the spec, streams its pod log and deletes the Job
+// with its pod once the container terminated.
+func (k *KubernetesRuntime) Run(ctx context.Context, spec *Spec, logs LogFunc) (*Result, error) {
+	job, err := k.jobManifest(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := "/apis/batch/v1/namespaces/" + k.namespace + "/jobs"
+	if err := k.do(ctx, http.MethodPost, jobs, job, nil); err != nil {
+		return nil, err
+	}
+	defer func() {
+		cctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		_ = k.do(cctx, http.MethodDelete, jobs+"/"+spec.Name+"?propagationPolicy=Background", nil, nil)
+	}()
+
+	pod, err := k.waitForPod(ctx, spec.Name, func(p *podStatus) bool {
+		return p.Status.Phase != "Pending"
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := k.followLogs(ctx, pod.Metadata.Name, logs)
+
+	pod, err = k.waitForPod(ctx, spec.Name, func(p *podStatus) bool {
+		return p.Status.Phase == "Succeeded" || p.Status.Phase == "Failed"
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exitCode := 0
+	for _, cs := range pod.Status.ContainerStatuses {
+		if t := cs.State.Terminated; t != nil {
+			exitCode = t.ExitCode
+		}
+	}
+	if exitCode == 0 && pod.Status.Phase == "Failed" {
+		exitCode = 1
+	}
+
+	return &Result{ExitCode: exitCode, Stdout: stdout}, nil
+}
+
+func (k *KubernetesRuntime) jobManifest(spec *Spec) (map[string]interface{}, error) {
+	env := []map[string]string{{"name": InputsEnv, "value": string(spec.Inputs)}}
+	for name, value := range spec.Env {
+		env = append(env, map[string]string{"name": name, "value": value})
+	}
+
+	limits := map[string]string{}
+	if spec.CPU != "" {
+		if _, err := ParseCPU(spec.CPU); err != nil {
+			return nil, err
+		}
+		limits["cpu"] = spec.CPU
+	}
+	if spec.Memory != "" {
+		q, err := kubernetesQuantity(spec.Memory)
+		if err != nil {
+			return nil, err
+		}
+		limits["memory"] = q
+	}
+
+	c := map[string]interface{}{
+		"name":      "module",
+		"image":     spec.Image,
+		"env":       env,
+		"resources": map[string]interface{}{"limits": limits, "requests": limits},
+	}
+	// like Docker's Cmd, the command replaces the image's arguments
+	if len(spec.Command) > 0 {
+		c["args"] = spec.Command
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":   spec.Name,
+			"labels": map[string]string{"app.kubernetes.io/managed-by": "workflow-engine"},
+		},
+		"spec": map[string]interface{}{
+			"backoffLimit":            0,
+			"ttlSecondsAfterFinished": jobTTL,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"restartPolicy": "Never",
+					"containers":    []interface{}{c},
+				},
+			},
+		},
+	}, nil
+}
+
+type podStatus struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		Phase             string `json:"phase"`
+		ContainerStatuses []struct {
+			State struct {
+				Terminated *struct {
+					ExitCode int `json:"exitCode"`
+				} `json:"terminated"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// waitForPod polls the Job's pod until done reports true.
+func (k *KubernetesRuntime) waitForPod(ctx context.Context, job string, done func(*podStatus) bool) (*podStatus, error) {
+	path := "/api/v1/namespaces/" + k.namespace + "/pods?labelSelector=" + url.QueryEscape("job-name="+job)
+
+	for {
+		var pods struct {
+			Items []podStatus `json:"items"`
+		}
+		if err := k.do(ctx, http.MethodGet, path, nil, &pods); err != nil {
+			return nil, err
+		}
+		if len(pods.Items) > 0 && done(&pods.Items[0]) {
+			return &pods.Items[0], nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// followLogs streams the pod log, which interleaves stdout and stderr, and
+// returns it.
+func (k *KubernetesRuntime) followLogs(ctx context.Context, pod string, logs LogFunc) []byte {
+	resp, err := k.request(ctx, http.MethodGet,
+		"/api/v1/namespaces/"+k.namespace+"/pods/"+pod+"/log?follow=true", nil)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		out.WriteString(line)
+		out.WriteByte('\n')
+		if logs != nil {
+			logs(Stdout, line)
+		}
+	}
+	return out.Bytes()
+}
+
+func (k *KubernetesRuntime) do(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := k.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (k *KubernetesRuntime) request(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, k.base+path, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var status struct {
+			Message string `json:"message"`
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(raw, &status) != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(raw))
+		}
+		return nil, fmt.Errorf("kubernetes %s %s: %s (status %d)", method, path, status.Message, resp.StatusCode)
+	}
+	return resp, nil
+}
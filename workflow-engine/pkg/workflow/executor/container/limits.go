@@ -0,0 +1,75 @@
+package container
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseCPU converts a CPU limit such as "500m" or "1.5" to nano-CPUs.
+// An empty limit means unlimited and yields 0.
+func ParseCPU(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	if m, ok := strings.CutSuffix(s, "m"); ok {
+		n, err := strconv.ParseInt(m, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid cpu %q", s)
+		}
+		return n * 1e6, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid cpu %q", s)
+	}
+	return int64(f * 1e9), nil
+}
+
+// memoryUnits lists the accepted suffixes, longest first. Kubernetes-style
+// Ki/Mi/Gi and Docker-style k/m/g are binary; K/M/G are decimal.
+var memoryUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30}, {"t", 1 << 40},
+	{"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+	{"b", 1}, {"B", 1},
+}
+
+// ParseMemory converts a memory limit such as "256Mi", "1G" or "512m" to
+// bytes. An empty limit means unlimited and yields 0.
+func ParseMemory(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	num, factor := s, int64(1)
+	for _, u := range memoryUnits {
+		if n, ok := strings.CutSuffix(s, u.suffix); ok {
+			num, factor = n, u.factor
+			break
+		}
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid memory %q", s)
+	}
+	return int64(f * float64(factor)), nil
+}
+
+// kubernetesQuantity rewrites a Docker-style memory limit for a Kubernetes
+// resource list, which reads "512m" as 0.512 bytes.
+func kubernetesQuantity(memory string) (string, error) {
+	n, err := ParseMemory(memory)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(n, 10), nil
+}
@@ -0,0 +1,74 @@
+package container
+
+import "testing"
+
+func TestParseCPU(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "500m", want: 5e8},
+		{in: "1", want: 1e9},
+		{in: "1.5", want: 1.5e9},
+		{in: " 250m ", want: 2.5e8},
+		{in: "0.1", want: 1e8},
+		{in: "0", wantErr: true},
+		{in: "0m", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "1.5m", wantErr: true},
+		{in: "two", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCPU(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseCPU(%q) = %d, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCPU(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "1024", want: 1024},
+		{in: "256Mi", want: 256 << 20},
+		{in: "1Gi", want: 1 << 30},
+		{in: "64Ki", want: 64 << 10},
+		{in: "512m", want: 512 << 20},
+		{in: "2g", want: 2 << 30},
+		{in: "1G", want: 1e9},
+		{in: "500M", want: 5e8},
+		{in: "1.5Gi", want: 3 << 29},
+		{in: "100b", want: 100},
+		{in: " 1Ki ", want: 1024},
+		{in: "0", wantErr: true},
+		{in: "-1Mi", wantErr: true},
+		{in: "Mi", wantErr: true},
+		{in: "10Xi", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMemory(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMemory(%q) = %d, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMemory(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
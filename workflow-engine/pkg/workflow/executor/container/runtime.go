@@ -0,0 +1,103 @@
+// Package container runs module containers on a pluggable runtime: a local
+// Docker-compatible socket, a Kubernetes Job, or a fake for tests.
+//
+// A container receives the node inputs as JSON in the WORKFLOW_INPUTS
+// environment variable and, where the runtime supports it, on stdin. It
+// reports outputs as JSON on stdout or by writing OutputsPath.
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// InputsEnv holds the node inputs as a JSON object.
+	InputsEnv = "WORKFLOW_INPUTS"
+	// OutputsPath is read after the container exits; when present it
+	// takes precedence over stdout.
+	OutputsPath = "/workflow/outputs.json"
+)
+
+// Stream names passed to a LogFunc.
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+)
+
+// LogFunc receives container output one line at a time.
+type LogFunc func(stream, line string)
+
+// Spec describes a single container run.
+type Spec struct {
+	// Name is unique per run and is used for the container or Job name
+	Name    string
+	Image   string
+	Command []string
+	Env     map[string]string
+	// Inputs is the JSON-encoded node inputs
+	Inputs []byte
+	CPU    string
+	Memory string
+}
+
+// Result is what a container left behind once it exited.
+type Result struct {
+	ExitCode int
+	Stdout   []byte
+	// Outputs is the content of OutputsPath, nil when it was not written
+	Outputs []byte
+}
+
+// Runtime runs a container to completion. Cancelling ctx stops the
+// container; the runtime removes it in every case.
+type Runtime interface {
+	Run(ctx context.Context, spec *Spec, logs LogFunc) (*Result, error)
+}
+
+// ParseOutputs extracts the node outputs from a result: the outputs file if
+// one was written, else stdout as a JSON object, else the last stdout line
+// that is a JSON object. Output that is not JSON is returned as
+// {"stdout": "..."}.
+func ParseOutputs(res *Result) (map[string]interface{}, error) {
+	if res.Outputs != nil {
+		var out map[string]interface{}
+		if err := json.Unmarshal(res.Outputs, &out); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", OutputsPath, err)
+		}
+		return out, nil
+	}
+
+	stdout := bytes.TrimSpace(res.Stdout)
+	if len(stdout) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(stdout, &out); err == nil {
+		return out, nil
+	}
+
+	lines := bytes.Split(stdout, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := bytes.TrimSpace(lines[i])
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		if err := json.Unmarshal(line, &out); err == nil {
+			return out, nil
+		}
+	}
+
+	return map[string]interface{}{"stdout": string(stdout)}, nil
+}
+
+func envList(env map[string]string) []string {
+	out := make([]string, 0, len(env))
+	for k, v := range env {
+		out = append(out, k+"="+v)
+	}
+	return out
+}
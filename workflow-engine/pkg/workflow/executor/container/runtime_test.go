@@ -0,0 +1,79 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     Result
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "outputs file wins over stdout",
+			res:  Result{Stdout: []byte(`{"a": 1}`), Outputs: []byte(`{"b": 2}`)},
+			want: map[string]interface{}{"b": float64(2)},
+		},
+		{
+			name: "empty outputs file wins over stdout",
+			res:  Result{Stdout: []byte(`{"a": 1}`), Outputs: []byte(`{}`)},
+			want: map[string]interface{}{},
+		},
+		{
+			name:    "invalid outputs file",
+			res:     Result{Stdout: []byte(`{"a": 1}`), Outputs: []byte(`nope`)},
+			wantErr: true,
+		},
+		{
+			name: "stdout object",
+			res:  Result{Stdout: []byte("  {\"a\": 1,\n \"b\": \"x\"}\n")},
+			want: map[string]interface{}{"a": float64(1), "b": "x"},
+		},
+		{
+			name: "last object line of stdout",
+			res:  Result{Stdout: []byte("{\"step\": 1}\nworking\n{\"step\": 2}\ndone\n")},
+			want: map[string]interface{}{"step": float64(2)},
+		},
+		{
+			name: "lines that are not valid JSON are skipped",
+			res:  Result{Stdout: []byte("{\"ok\": true}\n{broken\n")},
+			want: map[string]interface{}{"ok": true},
+		},
+		{
+			name: "plain text",
+			res:  Result{Stdout: []byte("hello\nworld\n")},
+			want: map[string]interface{}{"stdout": "hello\nworld"},
+		},
+		{
+			name: "JSON that is not an object",
+			res:  Result{Stdout: []byte(`[1, 2]`)},
+			want: map[string]interface{}{"stdout": "[1, 2]"},
+		},
+		{
+			name: "no output",
+			res:  Result{Stdout: []byte(" \n")},
+			want: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutputs(&tt.res)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOutputs: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
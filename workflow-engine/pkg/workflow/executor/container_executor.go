@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	service "github.com/prashantsinghb/workflow-engine/api/service"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor/container"
+)
+
+// maxErrorOutput caps how much container output is quoted in an error.
+const maxErrorOutput = 512
+
+// ContainerExecutor executes modules with a container spec on a
+// container.Runtime
+type ContainerExecutor struct {
+	modules *registry.ModuleRegistry
+	runtime container.Runtime
+}
+
+// NewContainerExecutor creates a new container executor
+func NewContainerExecutor(modules *registry.ModuleRegistry, runtime container.Runtime) *ContainerExecutor {
+	return &ContainerExecutor{
+		modules: modules,
+		runtime: runtime,
+	}
+}
+
+// Execute runs the module's image to completion and returns the JSON it
+// reported. Command and env values may use templates like the HTTP body.
+func (e *ContainerExecutor) Execute(
+	ctx context.Context,
+	node *dag.Node,
+	inputs map[string]interface{},
+) (map[string]interface{}, error) {
+
+	projectID, ok := ProjectID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("projectID missing in context")
+	}
+
+	// Resolve module
+	mod, err := e.modules.GetModule(ctx, projectID, node.Uses, "")
+	if err != nil {
+		return nil, fmt.Errorf("module resolve failed: %w", err)
+	}
+
+	// Load container spec
+	spec, err := e.modules.GetStore().GetContainerSpec(ctx, mod.ID)
+	if err != nil || spec == nil {
+		return nil, fmt.Errorf("container spec not found for module %s", mod.Name)
+	}
+
+	return e.run(ctx, spec, inputs)
+}
+
+// run renders spec for inputs and runs it on the runtime.
+func (e *ContainerExecutor) run(
+	ctx context.Context,
+	spec *service.ContainerRegistryModuleSpec,
+	inputs map[string]interface{},
+) (map[string]interface{}, error) {
+
+	scope := TemplateScope(ctx, inputs)
+
+	command, err := RenderArgs(spec.Command, scope)
+	if err != nil {
+		return nil, fmt.Errorf("render command failed: %w", err)
+	}
+	env, err := RenderEnv(spec.Env, scope)
+	if err != nil {
+		return nil, fmt.Errorf("render env failed: %w", err)
+	}
+
+	inputsJSON, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, fmt.Errorf("encode inputs failed: %w", err)
+	}
+
+	res, err := e.runtime.Run(ctx, &container.Spec{
+		Name:    "wf-" + uuid.NewString(),
+		Image:   spec.Image,
+		Command: command,
+		Env:     env,
+		Inputs:  inputsJSON,
+		CPU:     spec.Cpu,
+		Memory:  spec.Memory,
+	}, LogFunc(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("container %s: %w", spec.Image, err)
+	}
+
+	if res.ExitCode != 0 {
+		return nil, fmt.Errorf(
+			"container %s exited with code %d: %s",
+			spec.Image, res.ExitCode, tail(res.Stdout, maxErrorOutput),
+		)
+	}
+
+	return container.ParseOutputs(res)
+}
+
+// tail returns the last n bytes of out, trimmed.
+func tail(out []byte, n int) string {
+	out = bytes.TrimSpace(out)
+	if len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return string(out)
+}
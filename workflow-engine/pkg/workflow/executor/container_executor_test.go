@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	service "github.com/prashantsinghb/workflow-engine/api/service"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor/container"
+)
+
+func TestContainerExecutorPassesInputs(t *testing.T) {
+	rt := &container.FakeRuntime{}
+	e := NewContainerExecutor(nil, rt)
+
+	spec := &service.ContainerRegistryModuleSpec{
+		Image:   "alpine:3",
+		Command: []string{"greet", "${{ inputs.name }}"},
+		Env:     map[string]string{"GREETING": "hello ${{ inputs.name }}"},
+		Cpu:     "500m",
+		Memory:  "256Mi",
+	}
+	inputs := map[string]interface{}{"name": "ada", "count": float64(2)}
+
+	out, err := e.run(context.Background(), spec, inputs)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// the fake echoes the inputs back on stdout
+	if !reflect.DeepEqual(out, inputs) {
+		t.Errorf("outputs = %v, want %v", out, inputs)
+	}
+
+	runs := rt.Runs()
+	if len(runs) != 1 {
+		t.Fatalf("got %d runs, want 1", len(runs))
+	}
+	run := runs[0]
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(run.Inputs, &got); err != nil {
+		t.Fatalf("inputs are not JSON: %v", err)
+	}
+	if !reflect.DeepEqual(got, inputs) {
+		t.Errorf("inputs = %v, want %v", got, inputs)
+	}
+	if want := []string{"greet", "ada"}; !reflect.DeepEqual(run.Command, want) {
+		t.Errorf("command = %q, want %q", run.Command, want)
+	}
+	if got := run.Env["GREETING"]; got != "hello ada" {
+		t.Errorf("env GREETING = %q, want %q", got, "hello ada")
+	}
+	if run.Image != "alpine:3" || run.CPU != "500m" || run.Memory != "256Mi" {
+		t.Errorf("spec = %+v", run)
+	}
+	if !strings.HasPrefix(run.Name, "wf-") {
+		t.Errorf("name = %q, want a wf- prefix", run.Name)
+	}
+}
+
+func TestContainerExecutorParsesOutputs(t *testing.T) {
+	rt := &container.FakeRuntime{
+		Handler: func(ctx context.Context, spec *container.Spec, logs container.LogFunc) (*container.Result, error) {
+			return &container.Result{
+				Stdout:  []byte(`{"from": "stdout"}`),
+				Outputs: []byte(`{"from": "file"}`),
+			}, nil
+		},
+	}
+	e := NewContainerExecutor(nil, rt)
+
+	out, err := e.run(context.Background(), &service.ContainerRegistryModuleSpec{Image: "alpine:3"}, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if out["from"] != "file" {
+		t.Errorf("outputs = %v, want the outputs file", out)
+	}
+}
+
+func TestContainerExecutorFailures(t *testing.T) {
+	errRuntime := errors.New("daemon unavailable")
+
+	tests := []struct {
+		name    string
+		result  *container.Result
+		err     error
+		wantErr string
+	}{
+		{
+			name:    "non-zero exit",
+			result:  &container.Result{ExitCode: 3, Stdout: []byte("starting\nboom\n")},
+			wantErr: "exited with code 3: starting\nboom",
+		},
+		{
+			name:    "long output is cut",
+			result:  &container.Result{ExitCode: 1, Stdout: []byte(strings.Repeat("x", 2*maxErrorOutput) + "end")},
+			wantErr: "exited with code 1: " + strings.Repeat("x", maxErrorOutput-3) + "end",
+		},
+		{
+			name:    "runtime error",
+			err:     errRuntime,
+			wantErr: "container alpine:3: daemon unavailable",
+		},
+		{
+			name:    "invalid outputs file",
+			result:  &container.Result{Outputs: []byte("not json")},
+			wantErr: "invalid " + container.OutputsPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &container.FakeRuntime{
+				Handler: func(ctx context.Context, spec *container.Spec, logs container.LogFunc) (*container.Result, error) {
+					return tt.result, tt.err
+				},
+			}
+			e := NewContainerExecutor(nil, rt)
+
+			_, err := e.run(context.Background(), &service.ContainerRegistryModuleSpec{Image: "alpine:3"}, nil)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want it to wrap %v", err, tt.err)
+			}
+		})
+	}
+}
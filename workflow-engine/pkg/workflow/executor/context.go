@@ -1,6 +1,10 @@
 package executor
 
-import "context"
+import (
+	"context"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/expr"
+)
 
 type ctxKey string
 
@@ -36,3 +40,56 @@ func StepOutputs(ctx context.Context) map[string]map[string]interface{} {
 	}
 	return nil
 }
+
+type iterationKeyType struct{}
+
+var iterationKey = iterationKeyType{}
+
+type iteration struct {
+	item  interface{}
+	index int
+}
+
+// WithIteration injects the current for_each element into context
+func WithIteration(ctx context.Context, item interface{}, index int) context.Context {
+	return context.WithValue(ctx, iterationKey, iteration{item: item, index: index})
+}
+
+// Iteration extracts the current for_each element from context
+func Iteration(ctx context.Context) (interface{}, int, bool) {
+	it, ok := ctx.Value(iterationKey).(iteration)
+	return it.item, it.index, ok
+}
+
+type logFuncKeyType struct{}
+
+var logFuncKey = logFuncKeyType{}
+
+// WithLogFunc injects a sink for output an executor streams while running,
+// e.g. container logs
+func WithLogFunc(ctx context.Context, fn func(stream, line string)) context.Context {
+	return context.WithValue(ctx, logFuncKey, fn)
+}
+
+// LogFunc extracts the log sink from context; it discards lines if none is set
+func LogFunc(ctx context.Context) func(stream, line string) {
+	if fn, ok := ctx.Value(logFuncKey).(func(stream, line string)); ok && fn != nil {
+		return fn
+	}
+	return func(string, string) {}
+}
+
+type secretsKeyType struct{}
+
+var secretsKey = secretsKeyType{}
+
+// WithSecrets injects the resolver for ${{ secrets.NAME }} references
+func WithSecrets(ctx context.Context, r expr.Resolver) context.Context {
+	return context.WithValue(ctx, secretsKey, r)
+}
+
+// Secrets extracts the secrets resolver from context
+func Secrets(ctx context.Context) (expr.Resolver, bool) {
+	r, ok := ctx.Value(secretsKey).(expr.Resolver)
+	return r, ok && r != nil
+}
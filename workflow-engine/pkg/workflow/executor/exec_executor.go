@@ -0,0 +1,215 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/module/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor/container"
+)
+
+// Output formats of an exec module.
+const (
+	ExecOutputAuto     = ""
+	ExecOutputJSON     = "json"
+	ExecOutputKeyValue = "key_value"
+	ExecOutputText     = "text"
+)
+
+// execWaitDelay bounds how long Execute waits for output pipes after the
+// command was killed, e.g. when it left a child process holding them.
+const execWaitDelay = 5 * time.Second
+
+// ExecExecutor executes modules by running a local command or script
+type ExecExecutor struct {
+	modules *registry.ModuleRegistry
+}
+
+// NewExecExecutor creates a new exec executor
+func NewExecExecutor(modules *registry.ModuleRegistry) *ExecExecutor {
+	return &ExecExecutor{modules: modules}
+}
+
+// Execute runs the module's command with the node inputs as JSON on stdin
+// and in WORKFLOW_INPUTS. Args, env and working directory may use templates
+// like the HTTP body. The command gets only PATH and HOME from the worker's
+// environment, so worker credentials do not leak into it.
+func (e *ExecExecutor) Execute(
+	ctx context.Context,
+	node *dag.Node,
+	inputs map[string]interface{},
+) (map[string]interface{}, error) {
+
+	projectID, ok := ProjectID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("projectID missing in context")
+	}
+
+	// Resolve module
+	mod, err := e.modules.GetModule(ctx, projectID, node.Uses, "")
+	if err != nil {
+		return nil, fmt.Errorf("module resolve failed: %w", err)
+	}
+
+	// Load exec spec
+	spec, err := e.modules.GetStore().GetExecSpec(ctx, mod.ID)
+	if err != nil || spec == nil {
+		return nil, fmt.Errorf("exec spec not found for module %s", mod.Name)
+	}
+
+	scope := TemplateScope(ctx, inputs)
+
+	args, err := RenderArgs(spec.Args, scope)
+	if err != nil {
+		return nil, fmt.Errorf("render args failed: %w", err)
+	}
+	env, err := RenderEnv(spec.Env, scope)
+	if err != nil {
+		return nil, fmt.Errorf("render env failed: %w", err)
+	}
+	dir, err := RenderArgs([]string{spec.WorkingDir}, scope)
+	if err != nil {
+		return nil, fmt.Errorf("render working_dir failed: %w", err)
+	}
+
+	inputsJSON, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, fmt.Errorf("encode inputs failed: %w", err)
+	}
+
+	if spec.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(spec.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	var cmd *exec.Cmd
+	if spec.Script != "" {
+		// args become the script's positional parameters $1, $2, ...
+		cmd = exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", spec.Script, mod.Name}, args...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, spec.Command, args...)
+	}
+	cmd.Dir = dir[0]
+	cmd.Env = execEnv(env, inputsJSON)
+	cmd.Stdin = bytes.NewReader(inputsJSON)
+	cmd.WaitDelay = execWaitDelay
+
+	logs := LogFunc(ctx)
+	outLines := &container.LineWriter{Stream: container.Stdout, Logs: logs}
+	errLines := &container.LineWriter{Stream: container.Stderr, Logs: logs}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&stdout, outLines)
+	cmd.Stderr = io.MultiWriter(&stderr, errLines)
+
+	err = cmd.Run()
+	outLines.Flush()
+	errLines.Flush()
+
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s timed out after %dms", mod.Name, spec.TimeoutMs)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf(
+				"%s exited with code %d: %s",
+				mod.Name, exitErr.ExitCode(), tail(stderr.Bytes(), maxErrorOutput),
+			)
+		}
+		return nil, fmt.Errorf("%s: %w", mod.Name, err)
+	}
+
+	return parseExecOutputs(spec.OutputFormat, stdout.Bytes())
+}
+
+func execEnv(env map[string]string, inputsJSON []byte) []string {
+	var out []string
+	for _, k := range []string{"PATH", "HOME"} {
+		if v, ok := os.LookupEnv(k); ok {
+			out = append(out, k+"="+v)
+		}
+	}
+	for k, v := range env {
+		out = append(out, k+"="+v)
+	}
+	return append(out, container.InputsEnv+"="+string(inputsJSON))
+}
+
+// parseExecOutputs reads a command's stdout in the module's output format.
+// Auto accepts a JSON object, then key=value lines, and otherwise returns
+// the text as {"stdout": "..."}.
+func parseExecOutputs(format string, stdout []byte) (map[string]interface{}, error) {
+	text := strings.TrimSpace(string(stdout))
+
+	switch format {
+	case ExecOutputJSON:
+		var out map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &out); err != nil {
+			return nil, fmt.Errorf("output is not a JSON object: %w", err)
+		}
+		return out, nil
+
+	case ExecOutputKeyValue:
+		out, err := parseKeyValues(text)
+		if err != nil {
+			return nil, err
+		}
+		return out, nil
+
+	case ExecOutputText:
+		return map[string]interface{}{"stdout": text}, nil
+
+	case ExecOutputAuto:
+		if text == "" {
+			return map[string]interface{}{}, nil
+		}
+		var out map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &out); err == nil {
+			return out, nil
+		}
+		if out, err := parseKeyValues(text); err == nil {
+			return out, nil
+		}
+		return map[string]interface{}{"stdout": text}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// parseKeyValues reads `key=value` lines; blank lines and lines starting
+// with # are ignored.
+func parseKeyValues(text string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("output line %d is not key=value: %q", i+1, line)
+		}
+		out[k] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
+
+// ValidateExecOutputFormat reports whether format is a known output format.
+func ValidateExecOutputFormat(format string) error {
+	switch format {
+	case ExecOutputAuto, ExecOutputJSON, ExecOutputKeyValue, ExecOutputText:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
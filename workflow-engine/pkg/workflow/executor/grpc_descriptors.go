@@ -0,0 +1,232 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	rpbalpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ParseGrpcMethod splits a fully-qualified method such as
+// "dns.v1.RecordService/CreateRecord" (a leading "/" or a "." before the
+// method name are accepted too) into service and method name.
+func ParseGrpcMethod(fullMethod string) (string, string, error) {
+	m := strings.TrimPrefix(fullMethod, "/")
+
+	i := strings.LastIndex(m, "/")
+	if i < 0 {
+		i = strings.LastIndex(m, ".")
+	}
+	if i <= 0 || i == len(m)-1 {
+		return "", "", fmt.Errorf("invalid gRPC method %q: expected package.Service/Method", fullMethod)
+	}
+	return m[:i], m[i+1:], nil
+}
+
+// GrpcMethodFromDescriptorSet finds a unary method in a serialized
+// google.protobuf.FileDescriptorSet, e.g. one produced by
+// `protoc --include_imports --descriptor_set_out`.
+func GrpcMethodFromDescriptorSet(raw []byte, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+	return findGrpcMethod(files, fullMethod)
+}
+
+func findGrpcMethod(files *protoregistry.Files, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	service, method, err := ParseGrpcMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", service, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", method, service)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is streaming; only unary methods are supported", fullMethod)
+	}
+	return md, nil
+}
+
+// grpcMethodFromReflection resolves a method through the server's
+// reflection service, falling back to v1alpha for older servers.
+func grpcMethodFromReflection(ctx context.Context, conn *grpc.ClientConn, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	service, _, err := ParseGrpcMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reflectionResolver{conn: conn, files: map[string]*descriptorpb.FileDescriptorProto{}}
+
+	raw, err := r.fileContainingSymbol(ctx, service)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %w", err)
+	}
+	if err := r.add(raw); err != nil {
+		return nil, err
+	}
+	if err := r.loadDependencies(ctx); err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range r.files {
+		set.File = append(set.File, fd)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %w", err)
+	}
+	return findGrpcMethod(files, fullMethod)
+}
+
+type reflectionResolver struct {
+	conn  *grpc.ClientConn
+	alpha bool
+	files map[string]*descriptorpb.FileDescriptorProto
+}
+
+func (r *reflectionResolver) add(raw [][]byte) error {
+	for _, b := range raw {
+		fd := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(b, fd); err != nil {
+			return fmt.Errorf("reflection: invalid file descriptor: %w", err)
+		}
+		r.files[fd.GetName()] = fd
+	}
+	return nil
+}
+
+// loadDependencies fetches imports the server did not send along, using
+// the compiled-in well-known types where possible.
+func (r *reflectionResolver) loadDependencies(ctx context.Context) error {
+	for {
+		var missing []string
+		for _, fd := range r.files {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := r.files[dep]; !ok {
+					missing = append(missing, dep)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+
+		for _, dep := range missing {
+			if _, ok := r.files[dep]; ok {
+				continue
+			}
+			if known, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+				r.files[dep] = protodesc.ToFileDescriptorProto(known)
+				continue
+			}
+			raw, err := r.fileByFilename(ctx, dep)
+			if err != nil {
+				return fmt.Errorf("reflection: loading %s: %w", dep, err)
+			}
+			if err := r.add(raw); err != nil {
+				return err
+			}
+			if _, ok := r.files[dep]; !ok {
+				return fmt.Errorf("reflection: server did not return %s", dep)
+			}
+		}
+	}
+}
+
+func (r *reflectionResolver) fileContainingSymbol(ctx context.Context, symbol string) ([][]byte, error) {
+	raw, err := r.query(ctx, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if status.Code(err) == codes.Unimplemented && !r.alpha {
+		r.alpha = true
+		return r.fileContainingSymbol(ctx, symbol)
+	}
+	return raw, err
+}
+
+func (r *reflectionResolver) fileByFilename(ctx context.Context, name string) ([][]byte, error) {
+	return r.query(ctx, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+	})
+}
+
+// query sends a single reflection request. The v1alpha messages are wire
+// compatible with v1, so requests are converted by re-encoding them.
+func (r *reflectionResolver) query(ctx context.Context, req *rpb.ServerReflectionRequest) ([][]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resp := &rpb.ServerReflectionResponse{}
+	if r.alpha {
+		alphaReq := &rpbalpha.ServerReflectionRequest{}
+		if err := convertMessage(req, alphaReq); err != nil {
+			return nil, err
+		}
+		stream, err := rpbalpha.NewServerReflectionClient(r.conn).ServerReflectionInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.Send(alphaReq); err != nil {
+			return nil, err
+		}
+		alphaResp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if err := convertMessage(alphaResp, resp); err != nil {
+			return nil, err
+		}
+	} else {
+		stream, err := rpb.NewServerReflectionClient(r.conn).ServerReflectionInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		if resp, err = stream.Recv(); err != nil {
+			return nil, err
+		}
+	}
+
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+	}
+	return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+}
+
+func convertMessage(from, to proto.Message) error {
+	b, err := proto.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(b, to)
+}
@@ -0,0 +1,226 @@
+package executor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/prashantsinghb/workflow-engine/api/service"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+)
+
+// GrpcExecutor executes modules by calling a unary gRPC method. Request
+// and response types are resolved at runtime, from the module's descriptor
+// set or through server reflection, so no generated code is needed.
+type GrpcExecutor struct {
+	modules *registry.ModuleRegistry
+
+	mu      sync.Mutex
+	conns   map[string]*grpc.ClientConn
+	methods map[string]protoreflect.MethodDescriptor
+}
+
+// NewGrpcExecutor creates a new gRPC executor
+func NewGrpcExecutor(modules *registry.ModuleRegistry) *GrpcExecutor {
+	return &GrpcExecutor{
+		modules: modules,
+		conns:   map[string]*grpc.ClientConn{},
+		methods: map[string]protoreflect.MethodDescriptor{},
+	}
+}
+
+// Execute builds the request from the module's request template (or the
+// node inputs when there is none), invokes the method and returns the
+// response as JSON-style outputs using the proto field names.
+func (e *GrpcExecutor) Execute(
+	ctx context.Context,
+	node *dag.Node,
+	inputs map[string]interface{},
+) (map[string]interface{}, error) {
+
+	projectID, ok := ProjectID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("projectID missing in context")
+	}
+
+	// Resolve module
+	mod, err := e.modules.GetModule(ctx, projectID, node.Uses, "")
+	if err != nil {
+		return nil, fmt.Errorf("module resolve failed: %w", err)
+	}
+
+	// Load gRPC spec
+	spec, err := e.modules.GetStore().GetGrpcSpec(ctx, mod.ID)
+	if err != nil || spec == nil {
+		return nil, fmt.Errorf("grpc spec not found for module %s", mod.Name)
+	}
+
+	conn, err := e.conn(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := e.method(ctx, conn, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := TemplateScope(ctx, inputs)
+
+	// Without a template the node inputs are the request; inputs the
+	// message does not declare, e.g. workflow-level ones, are dropped.
+	body := inputs
+	unmarshal := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if spec.RequestTemplate != nil {
+		body, err = RenderTemplate(spec.RequestTemplate.AsMap(), scope)
+		if err != nil {
+			return nil, fmt.Errorf("render template failed: %w", err)
+		}
+		unmarshal.DiscardUnknown = false
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req := dynamicpb.NewMessage(md.Input())
+	if err := unmarshal.Unmarshal(bodyBytes, req); err != nil {
+		return nil, fmt.Errorf("build %s request: %w", md.Input().FullName(), err)
+	}
+
+	headers, err := RenderEnv(spec.Metadata, scope)
+	if err != nil {
+		return nil, fmt.Errorf("render metadata failed: %w", err)
+	}
+	for k, v := range headers {
+		ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(k), v)
+	}
+
+	if spec.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(spec.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	fullMethod := "/" + string(md.Parent().FullName()) + "/" + string(md.Name())
+	resp := dynamicpb.NewMessage(md.Output())
+	if err := conn.Invoke(ctx, fullMethod, req, resp); err != nil {
+		st := status.Convert(err)
+		if st.Code() == codes.Unimplemented {
+			// the server may have been redeployed without the method
+			e.forgetMethod(spec)
+		}
+		return nil, fmt.Errorf("grpc %s: %s: %s", fullMethod, st.Code(), st.Message())
+	}
+
+	respBytes, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var output map[string]interface{}
+	if err := json.Unmarshal(respBytes, &output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// method resolves the spec's method descriptor. Reflection results are
+// cached per target; descriptor sets are cheap to parse on every call.
+func (e *GrpcExecutor) method(
+	ctx context.Context,
+	conn *grpc.ClientConn,
+	spec *service.GrpcModuleSpec,
+) (protoreflect.MethodDescriptor, error) {
+
+	if len(spec.DescriptorSet) > 0 {
+		return GrpcMethodFromDescriptorSet(spec.DescriptorSet, spec.Method)
+	}
+
+	key := spec.Target + " " + spec.Method
+	e.mu.Lock()
+	md, ok := e.methods[key]
+	e.mu.Unlock()
+	if ok {
+		return md, nil
+	}
+
+	md, err := grpcMethodFromReflection(ctx, conn, spec.Method)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s on %s: %w", spec.Method, spec.Target, err)
+	}
+
+	e.mu.Lock()
+	e.methods[key] = md
+	e.mu.Unlock()
+	return md, nil
+}
+
+func (e *GrpcExecutor) forgetMethod(spec *service.GrpcModuleSpec) {
+	e.mu.Lock()
+	delete(e.methods, spec.Target+" "+spec.Method)
+	e.mu.Unlock()
+}
+
+// conn returns a shared connection per target and TLS settings.
+func (e *GrpcExecutor) conn(spec *service.GrpcModuleSpec) (*grpc.ClientConn, error) {
+	creds, err := GrpcCredentials(spec.Tls)
+	if err != nil {
+		return nil, err
+	}
+
+	key := spec.Target
+	if t := spec.Tls; t != nil {
+		key = fmt.Sprintf("%s|%t|%t|%s|%s", spec.Target, t.Enabled, t.InsecureSkipVerify, t.ServerName, t.CaCert)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if c, ok := e.conns[key]; ok {
+		return c, nil
+	}
+
+	c, err := grpc.NewClient(spec.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("grpc dial %s: %w", spec.Target, err)
+	}
+	e.conns[key] = c
+	return c, nil
+}
+
+// GrpcCredentials builds transport credentials from a module's TLS
+// settings; no settings or Enabled=false means plaintext.
+func GrpcCredentials(t *service.GrpcTls) (credentials.TransportCredentials, error) {
+	if t == nil || !t.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	cfg := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CaCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(t.CaCert)) {
+			return nil, fmt.Errorf("invalid TLS CA certificate")
+		}
+		cfg.RootCAs = pool
+	}
+	return credentials.NewTLS(cfg), nil
+}
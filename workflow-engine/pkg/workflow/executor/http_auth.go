@@ -1,23 +1,49 @@
 package executor
 
 import (
+	"crypto/hmac"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/prashantsinghb/workflow-engine/api/service"
+	"github.com/prashantsinghb/workflow-engine/pkg/signing"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/expr"
 )
 
-func applyAuth(
-	req *http.Request,
-	auth *service.HttpAuth,
-	inputs map[string]interface{},
-) error {
+// OAuth2 grant types. An empty grant type means client credentials.
+const (
+	OAuth2ClientCredentials = "client_credentials"
+	OAuth2RefreshToken      = "refresh_token"
+)
 
-	if auth == nil || auth.Type == nil {
+// Defaults for HMAC request signing.
+const (
+	DefaultHmacAlgorithm       = "sha256"
+	DefaultHmacHeader          = "X-Signature"
+	DefaultHmacTimestampHeader = "X-Signature-Timestamp"
+)
+
+// httpAuth applies one module's auth settings to the requests of a single
+// node execution.
+type httpAuth struct {
+	auth   *service.HttpAuth
+	tokens *tokenCache
+	key    string
+
+	// token is the OAuth2 access token sent with the last request
+	token string
+}
+
+func (a *httpAuth) apply(req *http.Request, body []byte) error {
+	if a.auth == nil || a.auth.Type == nil {
 		return nil
 	}
 
-	switch v := auth.Type.(type) {
+	switch v := a.auth.Type.(type) {
 	case *service.HttpAuth_Bearer:
 		if v.Bearer != nil && v.Bearer.Token != "" {
 			req.Header.Set("Authorization", "Bearer "+v.Bearer.Token)
@@ -26,14 +52,192 @@ func applyAuth(
 		if v.ApiKey != nil && v.ApiKey.Header != "" && v.ApiKey.Value != "" {
 			req.Header.Set(v.ApiKey.Header, v.ApiKey.Value)
 		}
+	case *service.HttpAuth_Basic:
+		if v.Basic != nil {
+			req.SetBasicAuth(v.Basic.Username, v.Basic.Password)
+		}
 	case *service.HttpAuth_Oauth2:
-		// OAuth2 requires token exchange - simplified for now
-		if v.Oauth2 != nil && v.Oauth2.TokenUrl != "" {
-			// TODO: Implement OAuth2 token exchange
-			return fmt.Errorf("OAuth2 authentication not yet implemented")
+		if v.Oauth2 == nil {
+			return nil
+		}
+		tok, err := a.tokens.get(req.Context(), a.key, v.Oauth2)
+		if err != nil {
+			return err
+		}
+		a.token = tok.AccessToken
+		req.Header.Set("Authorization", tok.TokenType+" "+tok.AccessToken)
+	case *service.HttpAuth_Hmac:
+		if v.Hmac != nil {
+			return signRequest(req, body, v.Hmac, time.Now())
 		}
 	default:
 		return fmt.Errorf("unsupported auth type")
 	}
 	return nil
 }
+
+// renderAuth returns a copy of auth with the ${{ }} expressions of its
+// fields rendered, e.g. `token: ${{ secrets.API_TOKEN }}`. Messages are
+// rebuilt field by field; generated messages must not be copied by value.
+func renderAuth(auth *service.HttpAuth, scope map[string]interface{}) (*service.HttpAuth, error) {
+	if auth == nil || auth.Type == nil {
+		return auth, nil
+	}
+
+	var fields []*string
+	out := &service.HttpAuth{}
+	switch v := auth.Type.(type) {
+	case *service.HttpAuth_Bearer:
+		if v.Bearer == nil {
+			return auth, nil
+		}
+		c := &service.BearerAuth{Token: v.Bearer.Token}
+		fields = []*string{&c.Token}
+		out.Type = &service.HttpAuth_Bearer{Bearer: c}
+	case *service.HttpAuth_ApiKey:
+		if v.ApiKey == nil {
+			return auth, nil
+		}
+		c := &service.ApiKeyAuth{Header: v.ApiKey.Header, Value: v.ApiKey.Value}
+		fields = []*string{&c.Header, &c.Value}
+		out.Type = &service.HttpAuth_ApiKey{ApiKey: c}
+	case *service.HttpAuth_Basic:
+		if v.Basic == nil {
+			return auth, nil
+		}
+		c := &service.BasicAuth{Username: v.Basic.Username, Password: v.Basic.Password}
+		fields = []*string{&c.Username, &c.Password}
+		out.Type = &service.HttpAuth_Basic{Basic: c}
+	case *service.HttpAuth_Oauth2:
+		if v.Oauth2 == nil {
+			return auth, nil
+		}
+		c := &service.OAuth2Auth{
+			TokenUrl:     v.Oauth2.TokenUrl,
+			ClientId:     v.Oauth2.ClientId,
+			ClientSecret: v.Oauth2.ClientSecret,
+			Scope:        v.Oauth2.Scope,
+			GrantType:    v.Oauth2.GrantType,
+			RefreshToken: v.Oauth2.RefreshToken,
+			Audience:     v.Oauth2.Audience,
+		}
+		fields = []*string{&c.TokenUrl, &c.ClientId, &c.ClientSecret, &c.Scope, &c.RefreshToken, &c.Audience}
+		out.Type = &service.HttpAuth_Oauth2{Oauth2: c}
+	case *service.HttpAuth_Hmac:
+		if v.Hmac == nil {
+			return auth, nil
+		}
+		c := &service.HmacAuth{
+			Secret:          v.Hmac.Secret,
+			Algorithm:       v.Hmac.Algorithm,
+			Header:          v.Hmac.Header,
+			TimestampHeader: v.Hmac.TimestampHeader,
+		}
+		fields = []*string{&c.Secret}
+		out.Type = &service.HttpAuth_Hmac{Hmac: c}
+	default:
+		return auth, nil
+	}
+
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		values[i] = *f
+	}
+	rendered, err := RenderArgs(values, scope)
+	if err != nil {
+		return nil, fmt.Errorf("render auth failed: %w", err)
+	}
+	for i, f := range fields {
+		*f = rendered[i]
+	}
+	return out, nil
+}
+
+// reauthenticate drops the OAuth2 token a request was rejected with, so
+// the next request fetches a new one. It reports whether a retry can help.
+func (a *httpAuth) reauthenticate() bool {
+	if a.token == "" {
+		return false
+	}
+	a.tokens.invalidate(a.key, a.token)
+	a.token = ""
+	return true
+}
+
+// signRequest adds an HMAC signature over the timestamp, method, request
+// URI and body, each on its own line, as "<algorithm>=<hex digest>". The
+// unix timestamp is sent along so receivers can reject replays.
+func signRequest(req *http.Request, body []byte, cfg *service.HmacAuth, now time.Time) error {
+	algorithm := cfg.Algorithm
+	if algorithm == "" {
+		algorithm = DefaultHmacAlgorithm
+	}
+	newHash, err := signing.Hash(algorithm)
+	if err != nil {
+		return err
+	}
+	header := cfg.Header
+	if header == "" {
+		header = DefaultHmacHeader
+	}
+	tsHeader := cfg.TimestampHeader
+	if tsHeader == "" {
+		tsHeader = DefaultHmacTimestampHeader
+	}
+
+	ts := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(newHash, []byte(cfg.Secret))
+	mac.Write([]byte(ts + "\n" + req.Method + "\n" + req.URL.RequestURI() + "\n"))
+	mac.Write(body)
+
+	req.Header.Set(tsHeader, ts)
+	req.Header.Set(header, algorithm+"="+hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// ValidateHttpAuth checks that an auth config has what its type needs.
+func ValidateHttpAuth(auth *service.HttpAuth) error {
+	if auth == nil || auth.Type == nil {
+		return nil
+	}
+
+	switch v := auth.Type.(type) {
+	case *service.HttpAuth_Basic:
+		if v.Basic == nil || v.Basic.Username == "" {
+			return fmt.Errorf("basic auth requires a username")
+		}
+	case *service.HttpAuth_Oauth2:
+		o := v.Oauth2
+		if o == nil {
+			return fmt.Errorf("oauth2 auth requires a token_url")
+		}
+		if !expr.IsTemplate(o.TokenUrl) {
+			u, err := url.Parse(o.TokenUrl)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("oauth2 token_url must be an http(s) URL")
+			}
+		}
+		switch o.GrantType {
+		case "", OAuth2ClientCredentials:
+			if o.ClientId == "" {
+				return fmt.Errorf("oauth2 client_credentials requires a client_id")
+			}
+		case OAuth2RefreshToken:
+			if o.RefreshToken == "" {
+				return fmt.Errorf("oauth2 refresh_token grant requires a refresh_token")
+			}
+		default:
+			return fmt.Errorf("unsupported oauth2 grant type %q", o.GrantType)
+		}
+	case *service.HttpAuth_Hmac:
+		if v.Hmac == nil || v.Hmac.Secret == "" {
+			return fmt.Errorf("hmac auth requires a secret")
+		}
+		if v.Hmac.Algorithm != "" {
+			if _, err := signing.Hash(v.Hmac.Algorithm); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
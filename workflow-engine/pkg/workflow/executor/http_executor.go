@@ -7,26 +7,61 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/prashantsinghb/workflow-engine/api/service"
 	"github.com/prashantsinghb/workflow-engine/pkg/module/registry"
 	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
 )
 
+// HttpResponseKey holds the response status and headers in a node's
+// outputs, e.g. steps.create.outputs.http.status.
+const HttpResponseKey = "http"
+
+// HttpBodyKey holds a response body that is not a JSON object.
+const HttpBodyKey = "body"
+
+// maxRetryAfter caps the wait a Retry-After header asks for.
+const maxRetryAfter = time.Minute
+
 // HttpExecutor executes modules via HTTP
 type HttpExecutor struct {
 	modules *registry.ModuleRegistry
 	client  *http.Client
+	tokens  *tokenCache
 }
 
 // NewHttpExecutor creates a new HTTP executor
 func NewHttpExecutor(modules *registry.ModuleRegistry) *HttpExecutor {
+	client := &http.Client{}
 	return &HttpExecutor{
 		modules: modules,
-		client:  &http.Client{},
+		client:  client,
+		tokens:  newTokenCache(client),
 	}
 }
 
+// HttpStatusError is returned when the response status is not one of the
+// module's success codes. The executor sends one request per attempt at a
+// node; the node's retry policy decides whether another attempt follows.
+type HttpStatusError struct {
+	StatusCode int
+	Body       string
+	// Retryable is set for statuses in the module's retry_on_status;
+	// others fail the node at once
+	Retryable bool
+	// RetryAfter is the wait a Retry-After header asked for, if any
+	RetryAfter time.Duration
+}
+
+func (e *HttpStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
 // Execute performs the HTTP call based on module spec
 func (e *HttpExecutor) Execute(
 	ctx context.Context,
@@ -51,67 +86,199 @@ func (e *HttpExecutor) Execute(
 		return nil, fmt.Errorf("http spec not found for module %s", mod.Name)
 	}
 
-	// Render body template
-	templateCtx := map[string]interface{}{
-		"inputs": inputs,
-		"steps":  ctx.Value("steps"),
-	}
+	templateCtx := TemplateScope(ctx, inputs)
 
-	var bodyTemplate map[string]interface{}
-	if spec.BodyTemplate != nil {
-		bodyTemplate = spec.BodyTemplate.AsMap()
+	reqURL, err := renderURL(spec, templateCtx)
+	if err != nil {
+		return nil, err
 	}
 
-	bodyMap, err := RenderTemplate(bodyTemplate, templateCtx)
+	headers, err := RenderEnv(spec.Headers, templateCtx)
 	if err != nil {
-		return nil, fmt.Errorf("render template failed: %w", err)
+		return nil, fmt.Errorf("render headers failed: %w", err)
+	}
+
+	// Render body template; without one no body is sent
+	var bodyBytes []byte
+	if spec.BodyTemplate != nil {
+		bodyMap, err := RenderTemplate(spec.BodyTemplate.AsMap(), templateCtx)
+		if err != nil {
+			return nil, fmt.Errorf("render template failed: %w", err)
+		}
+		bodyBytes, _ = json.Marshal(bodyMap)
 	}
 
-	bodyBytes, _ := json.Marshal(bodyMap)
-	req, err := http.NewRequestWithContext(
-		ctx,
-		spec.Method,
-		spec.Url,
-		bytes.NewReader(bodyBytes),
-	)
+	authSpec, err := renderAuth(spec.Auth, templateCtx)
 	if err != nil {
 		return nil, err
 	}
+	auth := &httpAuth{auth: authSpec, tokens: e.tokens, key: mod.ID}
 
-	req.Header.Set("Content-Type", "application/json")
-	for k, v := range spec.Headers {
-		req.Header.Set(k, v)
+	newRequest := func() (*http.Request, error) {
+		var body io.Reader
+		if bodyBytes != nil {
+			body = bytes.NewReader(bodyBytes)
+		}
+		req, err := http.NewRequestWithContext(ctx, spec.Method, reqURL, body)
+		if err != nil {
+			return nil, err
+		}
+		if bodyBytes != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		// Apply authentication
+		if err := auth.apply(req, bodyBytes); err != nil {
+			return nil, err
+		}
+		return req, nil
 	}
 
-	// Apply authentication
-	if err := applyAuth(req, spec.Auth, inputs); err != nil {
+	status, respHeaders, respBytes, err := e.do(spec, newRequest, auth)
+	if err != nil {
 		return nil, err
 	}
 
+	if !isSuccess(spec, status) {
+		retryAfter, _ := retryAfter(respHeaders)
+		return nil, &HttpStatusError{
+			StatusCode: status,
+			Body:       tail(respBytes, maxErrorOutput),
+			Retryable:  slices.Contains(spec.RetryOnStatus, int32(status)),
+			RetryAfter: retryAfter,
+		}
+	}
+
+	return httpOutputs(status, respHeaders, respBytes), nil
+}
+
+// do sends the request. A 401 with an OAuth2 token is sent once more
+// right away with a new token; other failures are left to the node's
+// retry policy.
+func (e *HttpExecutor) do(
+	spec *service.HttpModuleSpec,
+	newRequest func() (*http.Request, error),
+	auth *httpAuth,
+) (int, http.Header, []byte, error) {
+
 	timeout := time.Duration(spec.TimeoutMs) * time.Millisecond
-	e.client.Timeout = timeout
 
-	var resp *http.Response
-	err = Retry(int(spec.RetryCount), 200*time.Millisecond, func() error {
-		resp, err = e.client.Do(req)
+	reauthenticated := false
+	for {
+		req, err := newRequest()
 		if err != nil {
-			return err
+			return 0, nil, nil, err
 		}
-		if resp.StatusCode >= 500 || resp.StatusCode == 429 {
-			return fmt.Errorf("retryable status %d", resp.StatusCode)
+
+		status, headers, body, err := e.send(req, timeout)
+		if err == nil && status == http.StatusUnauthorized && !reauthenticated && auth.reauthenticate() {
+			// the token was revoked or expired early
+			reauthenticated = true
+			continue
 		}
-		return nil
-	})
+		return status, headers, body, err
+	}
+}
+
+func (e *HttpExecutor) send(req *http.Request, timeout time.Duration) (int, http.Header, []byte, error) {
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := e.client.Do(req)
 	if err != nil {
-		return nil, err
+		return 0, nil, nil, err
 	}
 	defer resp.Body.Close()
 
-	respBytes, _ := io.ReadAll(resp.Body)
-	var output map[string]interface{}
-	if err := json.Unmarshal(respBytes, &output); err != nil {
-		return nil, err
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("read response: %w", err)
 	}
+	return resp.StatusCode, resp.Header, body, nil
+}
 
-	return output, nil
+// renderURL renders the URL template and appends the rendered query params
+// to any query the URL already has.
+func renderURL(spec *service.HttpModuleSpec, scope map[string]interface{}) (string, error) {
+	rendered, err := RenderArgs([]string{spec.Url}, scope)
+	if err != nil {
+		return "", fmt.Errorf("render url failed: %w", err)
+	}
+
+	u, err := url.Parse(rendered[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %w", rendered[0], err)
+	}
+
+	params, err := RenderEnv(spec.QueryParams, scope)
+	if err != nil {
+		return "", fmt.Errorf("render query params failed: %w", err)
+	}
+	if len(params) > 0 {
+		q := u.Query()
+		for k, v := range params {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+// isSuccess reports whether status is one of the module's success codes,
+// or any 2xx when none are set.
+func isSuccess(spec *service.HttpModuleSpec, status int) bool {
+	if len(spec.SuccessCodes) == 0 {
+		return status >= 200 && status < 300
+	}
+	return slices.Contains(spec.SuccessCodes, int32(status))
+}
+
+func hasKey(m map[string]interface{}, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// retryAfter reads a Retry-After header given in seconds.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	secs, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return min(time.Duration(secs)*time.Second, maxRetryAfter), true
+}
+
+// httpOutputs turns a response into node outputs. A JSON object body
+// provides the top-level outputs; any other body is kept under
+// HttpBodyKey, parsed if it is JSON. Status and headers are always
+// available under HttpResponseKey; a JSON object body with a field of
+// that name is kept under HttpBodyKey as a whole instead.
+func httpOutputs(status int, headers http.Header, body []byte) map[string]interface{} {
+	output := map[string]interface{}{}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 {
+		var parsed interface{}
+		if err := json.Unmarshal(trimmed, &parsed); err != nil {
+			output[HttpBodyKey] = string(body)
+		} else if obj, ok := parsed.(map[string]interface{}); ok && !hasKey(obj, HttpResponseKey) {
+			output = obj
+		} else {
+			output[HttpBodyKey] = parsed
+		}
+	}
+
+	respHeaders := make(map[string]interface{}, len(headers))
+	for k, v := range headers {
+		respHeaders[k] = strings.Join(v, ", ")
+	}
+	output[HttpResponseKey] = map[string]interface{}{
+		"status":  status,
+		"headers": respHeaders,
+	}
+	return output
 }
@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	service "github.com/prashantsinghb/workflow-engine/api/service"
+)
+
+func TestHttpExecutorSendsOncePerAttempt(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		token      string
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "retryable status", statuses: []int{503, 200}, wantStatus: 503, wantCalls: 1},
+		{name: "401 without a token", statuses: []int{401, 200}, wantStatus: 401, wantCalls: 1},
+		{name: "401 with a token is sent once more", statuses: []int{401, 200}, token: "expired", wantStatus: 200, wantCalls: 2},
+		{name: "only once more", statuses: []int{401, 401, 200}, token: "expired", wantStatus: 401, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer srv.Close()
+
+			e := NewHttpExecutor(nil)
+			spec := &service.HttpModuleSpec{RetryCount: 3, RetryOnStatus: []int32{503}}
+			auth := &httpAuth{tokens: e.tokens, key: "m", token: tt.token}
+			newRequest := func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, srv.URL, nil)
+			}
+
+			status, _, _, err := e.do(spec, newRequest, auth)
+			if err != nil {
+				t.Fatalf("do: %v", err)
+			}
+			if status != tt.wantStatus || calls != tt.wantCalls {
+				t.Errorf("status %d after %d calls, want %d after %d", status, calls, tt.wantStatus, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{header: "", ok: false},
+		{header: "5", want: 5 * time.Second, ok: true},
+		{header: "3600", want: maxRetryAfter, ok: true},
+		{header: "-1", ok: false},
+		{header: "Wed, 21 Oct 2026 07:28:00 GMT", ok: false},
+	}
+
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.header != "" {
+			h.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(h)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
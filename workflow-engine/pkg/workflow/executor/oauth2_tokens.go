@@ -0,0 +1,209 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prashantsinghb/workflow-engine/api/service"
+)
+
+// tokenRefreshMargin is how long before expiry a cached token is replaced.
+const tokenRefreshMargin = 30 * time.Second
+
+// tokenRequestTimeout bounds a single token endpoint call.
+const tokenRequestTimeout = 30 * time.Second
+
+type oauth2Token struct {
+	AccessToken string
+	TokenType   string
+	// RefreshAt is zero when the server did not say when the token
+	// expires; it is then used until a request is rejected with 401.
+	RefreshAt time.Time
+}
+
+// tokenCache keeps one OAuth2 access token per module, so executions of the
+// same module share it instead of calling the token endpoint every time.
+type tokenCache struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	entries map[string]*tokenEntry
+}
+
+type tokenEntry struct {
+	mu  sync.Mutex
+	cfg tokenConfig
+	tok *oauth2Token
+	// refreshToken is the latest refresh token; servers that rotate
+	// refresh tokens invalidate the configured one after first use.
+	refreshToken string
+}
+
+// tokenConfig is the part of a module's OAuth2 settings a cached token
+// depends on. Secrets are kept as hashes.
+type tokenConfig struct {
+	tokenURL     string
+	clientID     string
+	clientSecret [sha256.Size]byte
+	scope        string
+	grantType    string
+	refreshToken [sha256.Size]byte
+	audience     string
+}
+
+func newTokenConfig(cfg *service.OAuth2Auth) tokenConfig {
+	return tokenConfig{
+		tokenURL:     cfg.TokenUrl,
+		clientID:     cfg.ClientId,
+		clientSecret: sha256.Sum256([]byte(cfg.ClientSecret)),
+		scope:        cfg.Scope,
+		grantType:    cfg.GrantType,
+		refreshToken: sha256.Sum256([]byte(cfg.RefreshToken)),
+		audience:     cfg.Audience,
+	}
+}
+
+func newTokenCache(client *http.Client) *tokenCache {
+	return &tokenCache{client: client, entries: map[string]*tokenEntry{}}
+}
+
+// get returns a valid token for key, fetching one if there is none, it is
+// about to expire or the module's OAuth2 settings changed.
+func (c *tokenCache) get(ctx context.Context, key string, cfg *service.OAuth2Auth) (*oauth2Token, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &tokenEntry{}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+
+	// holding the entry lock while fetching makes concurrent callers wait
+	// for one token request instead of each sending their own
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if key := newTokenConfig(cfg); e.cfg != key {
+		e.cfg = key
+		e.tok = nil
+		e.refreshToken = cfg.RefreshToken
+	}
+	if e.tok != nil && (e.tok.RefreshAt.IsZero() || time.Now().Before(e.tok.RefreshAt)) {
+		return e.tok, nil
+	}
+
+	tok, refreshToken, err := c.fetch(ctx, cfg, e.refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	e.tok = tok
+	if refreshToken != "" {
+		e.refreshToken = refreshToken
+	}
+	return tok, nil
+}
+
+// invalidate drops key's token if it is still accessToken; a token fetched
+// meanwhile by another execution is kept.
+func (c *tokenCache) invalidate(key, accessToken string) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	e.mu.Lock()
+	if e.tok != nil && e.tok.AccessToken == accessToken {
+		e.tok = nil
+	}
+	e.mu.Unlock()
+}
+
+// fetch calls the token endpoint. Client credentials are sent with HTTP
+// basic auth as RFC 6749 section 2.3.1 requires servers to support.
+func (c *tokenCache) fetch(
+	ctx context.Context,
+	cfg *service.OAuth2Auth,
+	refreshToken string,
+) (*oauth2Token, string, error) {
+
+	form := url.Values{}
+	if cfg.GrantType == OAuth2RefreshToken {
+		form.Set("grant_type", OAuth2RefreshToken)
+		form.Set("refresh_token", refreshToken)
+	} else {
+		form.Set("grant_type", OAuth2ClientCredentials)
+	}
+	if cfg.Scope != "" {
+		form.Set("scope", cfg.Scope)
+	}
+	if cfg.Audience != "" {
+		form.Set("audience", cfg.Audience)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, "", fmt.Errorf("oauth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientId != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientId), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	requested := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("oauth2 token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, "", fmt.Errorf("oauth2 token response: %w", err)
+	}
+
+	var body struct {
+		AccessToken      string      `json:"access_token"`
+		TokenType        string      `json:"token_type"`
+		ExpiresIn        json.Number `json:"expires_in"`
+		RefreshToken     string      `json:"refresh_token"`
+		Error            string      `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	_ = json.Unmarshal(raw, &body)
+
+	if resp.StatusCode != http.StatusOK {
+		if body.Error != "" {
+			return nil, "", fmt.Errorf("oauth2 token request failed (status %d): %s %s",
+				resp.StatusCode, body.Error, body.ErrorDescription)
+		}
+		return nil, "", fmt.Errorf("oauth2 token request failed (status %d): %s",
+			resp.StatusCode, tail(raw, maxErrorOutput))
+	}
+	if body.AccessToken == "" {
+		return nil, "", fmt.Errorf("oauth2 token response has no access_token")
+	}
+
+	tok := &oauth2Token{AccessToken: body.AccessToken, TokenType: "Bearer"}
+	if body.TokenType != "" && !strings.EqualFold(body.TokenType, "bearer") {
+		tok.TokenType = body.TokenType
+	}
+	if secs, err := body.ExpiresIn.Int64(); err == nil && secs > 0 {
+		lifetime := time.Duration(secs) * time.Second
+		tok.RefreshAt = requested.Add(lifetime - min(tokenRefreshMargin, lifetime/2))
+	}
+	return tok, body.RefreshToken, nil
+}
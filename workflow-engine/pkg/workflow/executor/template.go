@@ -1,11 +1,14 @@
 package executor
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/valyala/fasttemplate"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/expr"
 )
 
 // flattenMap flattens a nested map into dot-notation keys for template access
@@ -42,36 +45,120 @@ func flattenMap(prefix string, m map[string]interface{}) map[string]interface{}
 	return result
 }
 
-// RenderTemplate renders a JSON template using {{ }} placeholders.
-// Context typically contains:
+// TemplateScope builds the variables available to templates: node inputs,
+// outputs of completed steps as steps.<id>.outputs, secrets.<name> when
+// the worker has a secrets resolver and, for for_each instances, item and
+// index.
+func TemplateScope(ctx context.Context, inputs map[string]interface{}) map[string]interface{} {
+	steps := map[string]interface{}{}
+	for id, out := range StepOutputs(ctx) {
+		steps[id] = map[string]interface{}{"outputs": out}
+	}
+
+	scope := map[string]interface{}{
+		"inputs": inputs,
+		"steps":  steps,
+	}
+	if item, index, ok := Iteration(ctx); ok {
+		scope["item"] = item
+		scope["index"] = index
+	}
+	if r, ok := Secrets(ctx); ok {
+		scope["secrets"] = r
+	}
+	return scope
+}
+
+// RenderTemplate renders a JSON template. String values may contain
+// ${{ }} expressions (see package expr), which keep the type of their
+// result when they make up the whole value, e.g. "${{ inputs.count }}"
+// renders as a number.
+//
+// The scope typically comes from TemplateScope:
 //
 //	{
 //	  "inputs": map[string]interface{},
 //	  "steps":  map[string]interface{},
 //	}
 //
-// Templates can access values using:
+// Values without ${{ }} fall back to the legacy {{ }} placeholders, which
+// always render as strings:
 //   - {{message}} - direct access to top-level inputs
 //   - {{inputs.message}} - nested access via dot notation
+//   - {{item}}, {{item.name}}, {{index}} - the current for_each element
 func RenderTemplate(
 	tpl map[string]interface{},
-	context map[string]interface{},
+	scope map[string]interface{},
 ) (map[string]interface{}, error) {
 
 	if tpl == nil {
 		return nil, nil
 	}
 
+	legacyVars := legacyTemplateVars(scope)
+
+	out, err := renderValue(tpl, scope, legacyVars)
+	if err != nil {
+		return nil, err
+	}
+	return out.(map[string]interface{}), nil
+}
+
+func renderValue(
+	v interface{},
+	scope map[string]interface{},
+	legacyVars map[string]interface{},
+) (interface{}, error) {
+
+	switch val := v.(type) {
+	case string:
+		if expr.IsTemplate(val) {
+			return expr.RenderString(val, scope)
+		}
+		if strings.Contains(val, "{{") {
+			return fasttemplate.New(val, "{{", "}}").ExecuteString(legacyVars), nil
+		}
+		return val, nil
+
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			r, err := renderValue(item, scope, legacyVars)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = r
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			r, err := renderValue(item, scope, legacyVars)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+
+	return v, nil
+}
+
+// legacyTemplateVars flattens the context into string values for {{ }}
+// placeholders.
+func legacyTemplateVars(scope map[string]interface{}) map[string]interface{} {
 	// Flatten the context to support nested access like {{inputs.message}}
 	flatContext := make(map[string]interface{})
-	
+
 	// First, add all top-level context values directly
-	for k, v := range context {
+	for k, v := range scope {
 		flatContext[k] = v
 	}
-	
+
 	// Then, flatten nested structures (like inputs and steps) with dot notation
-	if inputs, ok := context["inputs"].(map[string]interface{}); ok {
+	if inputs, ok := scope["inputs"].(map[string]interface{}); ok {
 		for k, v := range flattenMap("inputs", inputs) {
 			flatContext[k] = v
 		}
@@ -80,49 +167,55 @@ func RenderTemplate(
 			flatContext[k] = v
 		}
 	}
-	
-	if steps, ok := context["steps"].(map[string]interface{}); ok {
+
+	if steps, ok := scope["steps"].(map[string]interface{}); ok {
 		for k, v := range flattenMap("steps", steps) {
 			flatContext[k] = v
 		}
 	}
 
-	raw, err := json.Marshal(tpl)
-	if err != nil {
-		return nil, fmt.Errorf("template marshal failed: %w", err)
+	// for_each instances expose {{item}}, {{item.field}} and {{index}}
+	if item, ok := scope["item"].(map[string]interface{}); ok {
+		for k, v := range flattenMap("item", item) {
+			flatContext[k] = v
+		}
 	}
 
-	// Convert flatContext values to strings for fasttemplate
 	templateVars := make(map[string]interface{})
 	for k, v := range flatContext {
-		switch val := v.(type) {
-		case string:
-			templateVars[k] = val
-		case int, int32, int64:
-			templateVars[k] = fmt.Sprintf("%d", val)
-		case float32, float64:
-			templateVars[k] = fmt.Sprintf("%g", val)
-		case bool:
-			templateVars[k] = fmt.Sprintf("%t", val)
-		case nil:
-			templateVars[k] = ""
-		default:
-			// For complex types, marshal to JSON string
-			if jsonBytes, err := json.Marshal(val); err == nil {
-				templateVars[k] = string(jsonBytes)
-			} else {
-				templateVars[k] = fmt.Sprintf("%v", val)
-			}
-		}
+		templateVars[k] = expr.Stringify(v)
 	}
+	return templateVars
+}
 
-	t := fasttemplate.New(string(raw), "{{", "}}")
-	rendered := t.ExecuteString(templateVars)
+// RenderArgs renders each argument like a RenderTemplate leaf and converts
+// the result to a string, e.g. for a command line.
+func RenderArgs(args []string, scope map[string]interface{}) ([]string, error) {
+	legacyVars := legacyTemplateVars(scope)
 
-	var out map[string]interface{}
-	if err := json.Unmarshal([]byte(rendered), &out); err != nil {
-		return nil, fmt.Errorf("template unmarshal failed: %w", err)
+	out := make([]string, len(args))
+	for i, a := range args {
+		r, err := renderValue(a, scope, legacyVars)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		out[i] = expr.Stringify(r)
 	}
+	return out, nil
+}
+
+// RenderEnv renders each value like a RenderTemplate leaf and converts the
+// result to a string.
+func RenderEnv(env map[string]string, scope map[string]interface{}) (map[string]string, error) {
+	legacyVars := legacyTemplateVars(scope)
 
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		r, err := renderValue(v, scope, legacyVars)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		out[k] = expr.Stringify(r)
+	}
 	return out, nil
 }
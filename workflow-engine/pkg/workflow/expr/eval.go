@@ -0,0 +1,379 @@
+// Package expr implements the small expression language used by workflow
+// definitions, e.g. `if: steps.check.outputs.exists == false`.
+//
+// Expressions support literals (strings, numbers, true, false, null),
+// variable paths with `.field`, `.0` and `[index]` accessors, comparisons,
+// `!`, `&&`, `||`, parentheses and the functions default, join, toJSON,
+// base64 and now. Paths that do not resolve evaluate to null rather than
+// failing, so a condition can test for optional outputs.
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Expression is a parsed expression that can be evaluated many times.
+type Expression struct {
+	src  string
+	root node
+}
+
+// Parse compiles src. A surrounding `${{ }}` is accepted and ignored.
+func Parse(src string) (*Expression, error) {
+	body := strings.TrimSpace(src)
+	if strings.HasPrefix(body, "${{") && strings.HasSuffix(body, "}}") {
+		body = strings.TrimSpace(body[3 : len(body)-2])
+	}
+	if body == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	tokens, err := tokenize(body)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr(1)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+
+	return &Expression{src: src, root: root}, nil
+}
+
+func (e *Expression) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression against scope, whose keys are the roots
+// available to paths (e.g. "inputs", "steps").
+func (e *Expression) Eval(scope map[string]interface{}) (interface{}, error) {
+	return eval(e.root, scope)
+}
+
+// EvalBool evaluates the expression and reports whether the result is truthy.
+func (e *Expression) EvalBool(scope map[string]interface{}) (bool, error) {
+	v, err := e.Eval(scope)
+	if err != nil {
+		return false, err
+	}
+	return Truthy(v), nil
+}
+
+// References returns every variable path in the expression, truncated at
+// the first dynamic index. steps.a.outputs["x"] yields [steps a outputs x].
+func (e *Expression) References() [][]string {
+	var refs [][]string
+	collectRefs(e.root, &refs)
+	return refs
+}
+
+func collectRefs(n node, refs *[][]string) {
+	switch n := n.(type) {
+	case *pathNode:
+		ref := []string{n.root}
+		static := true
+		for _, a := range n.accessors {
+			if a.index != nil {
+				collectRefs(a.index, refs)
+				if lit, ok := a.index.(*literalNode); ok && static {
+					if s, ok := lit.value.(string); ok {
+						ref = append(ref, s)
+						continue
+					}
+				}
+				static = false
+				continue
+			}
+			if static {
+				ref = append(ref, a.field)
+			}
+		}
+		*refs = append(*refs, ref)
+	case *unaryNode:
+		collectRefs(n.operand, refs)
+	case *binaryNode:
+		collectRefs(n.left, refs)
+		collectRefs(n.right, refs)
+	case *callNode:
+		for _, a := range n.args {
+			collectRefs(a, refs)
+		}
+	}
+}
+
+// Calls reports whether the expression calls the named function.
+func (e *Expression) Calls(name string) bool {
+	return calls(e.root, name)
+}
+
+func calls(n node, name string) bool {
+	switch n := n.(type) {
+	case *pathNode:
+		for _, a := range n.accessors {
+			if a.index != nil && calls(a.index, name) {
+				return true
+			}
+		}
+	case *unaryNode:
+		return calls(n.operand, name)
+	case *binaryNode:
+		return calls(n.left, name) || calls(n.right, name)
+	case *callNode:
+		if n.name == name {
+			return true
+		}
+		for _, a := range n.args {
+			if calls(a, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Truthy reports whether v counts as true in a condition: false, null,
+// zero, the empty string and empty collections are false.
+func Truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	}
+	return true
+}
+
+func eval(n node, scope map[string]interface{}) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *pathNode:
+		return evalPath(n, scope)
+
+	case *unaryNode:
+		v, err := eval(n.operand, scope)
+		if err != nil {
+			return nil, err
+		}
+		return !Truthy(v), nil
+
+	case *binaryNode:
+		return evalBinary(n, scope)
+
+	case *callNode:
+		args := make([]interface{}, len(n.args))
+		for i, a := range n.args {
+			v, err := eval(a, scope)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return n.fn.call(args)
+	}
+
+	return nil, fmt.Errorf("unsupported expression node %T", n)
+}
+
+func evalPath(n *pathNode, scope map[string]interface{}) (interface{}, error) {
+	cur, ok := scope[n.root]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q", n.root)
+	}
+
+	for _, a := range n.accessors {
+		key := interface{}(a.field)
+		if a.index != nil {
+			k, err := eval(a.index, scope)
+			if err != nil {
+				return nil, err
+			}
+			key = k
+		}
+		if r, ok := cur.(Resolver); ok {
+			k, _ := key.(string)
+			v, err := r.Resolve(k)
+			if err != nil {
+				return nil, err
+			}
+			cur = v
+			continue
+		}
+		cur = lookup(cur, key)
+	}
+
+	return cur, nil
+}
+
+// Resolver is a scope value whose fields are looked up on demand, e.g.
+// secrets that are only decrypted when an expression uses them. Unlike a
+// map lookup, resolving an unknown field may fail.
+type Resolver interface {
+	Resolve(field string) (interface{}, error)
+}
+
+func lookup(container interface{}, key interface{}) interface{} {
+	switch c := container.(type) {
+	case map[string]interface{}:
+		if k, ok := key.(string); ok {
+			return c[k]
+		}
+		return nil
+	case []interface{}:
+		f, ok := toFloat(key)
+		if s, isField := key.(string); isField {
+			// a numeric path segment, e.g. items.0.name
+			n, err := strconv.Atoi(s)
+			f, ok = float64(n), err == nil
+		}
+		if !ok {
+			return nil
+		}
+		i := int(f)
+		if i < 0 || i >= len(c) {
+			return nil
+		}
+		return c[i]
+	}
+
+	rv := reflect.ValueOf(container)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		if k, ok := key.(string); ok {
+			v := rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()))
+			if v.IsValid() {
+				return v.Interface()
+			}
+		}
+	}
+	return nil
+}
+
+func evalBinary(n *binaryNode, scope map[string]interface{}) (interface{}, error) {
+	left, err := eval(n.left, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// short-circuit logical operators
+	switch n.op {
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+		right, err := eval(n.right, scope)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+		right, err := eval(n.right, scope)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	}
+
+	right, err := eval(n.right, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	cmp, err := compare(left, right)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.op, err)
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator %q", n.op)
+}
+
+func equal(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compare(a, b interface{}) (int, error) {
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			switch {
+			case fa < fb:
+				return -1, nil
+			case fa > fb:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			return strings.Compare(sa, sb), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %T and %T", a, b)
+}
+
+// toFloat normalises the numeric types produced by YAML and JSON decoding.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
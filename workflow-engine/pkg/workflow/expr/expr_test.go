@@ -0,0 +1,287 @@
+package expr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token
+	}{
+		{
+			src: "steps.check-db.outputs.exists == false",
+			want: []token{
+				{kind: tokIdent, text: "steps", pos: 0},
+				{kind: tokOp, text: ".", pos: 5},
+				{kind: tokIdent, text: "check-db", pos: 6},
+				{kind: tokOp, text: ".", pos: 14},
+				{kind: tokIdent, text: "outputs", pos: 15},
+				{kind: tokOp, text: ".", pos: 22},
+				{kind: tokIdent, text: "exists", pos: 23},
+				{kind: tokOp, text: "==", pos: 30},
+				{kind: tokIdent, text: "false", pos: 33},
+				{kind: tokEOF, pos: 38},
+			},
+		},
+		{
+			src: `a<=1.5&&!b`,
+			want: []token{
+				{kind: tokIdent, text: "a", pos: 0},
+				{kind: tokOp, text: "<=", pos: 1},
+				{kind: tokNumber, text: "1.5", pos: 3},
+				{kind: tokOp, text: "&&", pos: 6},
+				{kind: tokOp, text: "!", pos: 8},
+				{kind: tokIdent, text: "b", pos: 9},
+				{kind: tokEOF, pos: 10},
+			},
+		},
+		{
+			src: `x["it's"] != 'say "hi"'`,
+			want: []token{
+				{kind: tokIdent, text: "x", pos: 0},
+				{kind: tokOp, text: "[", pos: 1},
+				{kind: tokString, text: "it's", pos: 2},
+				{kind: tokOp, text: "]", pos: 8},
+				{kind: tokOp, text: "!=", pos: 10},
+				{kind: tokString, text: `say "hi"`, pos: 13},
+				{kind: tokEOF, pos: 23},
+			},
+		},
+		{
+			src: `items.0.name`,
+			want: []token{
+				{kind: tokIdent, text: "items", pos: 0},
+				{kind: tokOp, text: ".", pos: 5},
+				{kind: tokNumber, text: "0", pos: 6},
+				{kind: tokOp, text: ".", pos: 7},
+				{kind: tokIdent, text: "name", pos: 8},
+				{kind: tokEOF, pos: 12},
+			},
+		},
+		{
+			src: `'a\'b'`,
+			want: []token{
+				{kind: tokString, text: "a'b", pos: 0},
+				{kind: tokEOF, pos: 6},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := tokenize(tt.src)
+		if err != nil {
+			t.Errorf("tokenize(%q): %v", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) =\n%v\nwant\n%v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		wantErr string
+	}{
+		{src: `'open`, wantErr: "unterminated string at 0"},
+		{src: `a == "b`, wantErr: "unterminated string at 5"},
+		{src: `a + b`, wantErr: `unexpected character '+' at 2`},
+		{src: `a = b`, wantErr: `unexpected character '=' at 2`},
+		{src: `a & b`, wantErr: `unexpected character '&' at 2`},
+	}
+
+	for _, tt := range tests {
+		_, err := tokenize(tt.src)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("tokenize(%q) error = %v, want %q", tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	scope := map[string]interface{}{
+		"inputs": map[string]interface{}{
+			"region":  "eu-west-1",
+			"count":   3,
+			"empty":   "",
+			"tags":    []interface{}{"a", "b"},
+			"enabled": true,
+		},
+		"steps": map[string]interface{}{
+			"check": map[string]interface{}{
+				"outputs": map[string]interface{}{"exists": false, "n": float64(3)},
+			},
+			"list": map[string]interface{}{
+				"outputs": map[string]interface{}{
+					"items":   []interface{}{map[string]interface{}{"name": "first"}, map[string]interface{}{"name": "second"}},
+					"by_code": map[string]interface{}{"404": "not found"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{src: `${{ inputs.region }}`, want: "eu-west-1"},
+		{src: `inputs["region"]`, want: "eu-west-1"},
+		{src: `inputs.tags[1]`, want: "b"},
+		{src: `inputs.tags[5]`, want: nil},
+		{src: `inputs.missing.deeper`, want: nil},
+		{src: `steps.list.outputs.items.0.name`, want: "first"},
+		{src: `steps.list.outputs.items.1.name == steps.list.outputs.items[1].name`, want: true},
+		{src: `steps.list.outputs.items.2.name`, want: nil},
+		{src: `steps.list.outputs.by_code.404`, want: "not found"},
+		{src: `inputs.count < 3.5`, want: true},
+		{src: `steps.check.outputs.exists == false`, want: true},
+		{src: `inputs.count == steps.check.outputs.n`, want: true},
+		{src: `inputs.count != 3`, want: false},
+		{src: `inputs.count >= 3 && inputs.count < 4`, want: true},
+		{src: `'abc' < 'abd'`, want: true},
+		{src: `false || true && false`, want: false},
+		{src: `(false || true) && true`, want: true},
+		{src: `!inputs.empty`, want: true},
+		{src: `!!inputs.tags`, want: true},
+		{src: `null == inputs.missing`, want: true},
+		{src: `false && inputs.count < 'x'`, want: false},
+		{src: `default(inputs.empty, inputs.missing, 'us-east-1')`, want: "us-east-1"},
+		{src: `join(inputs.tags, '-')`, want: "a-b"},
+		{src: `toJSON(inputs.tags)`, want: `["a","b"]`},
+		{src: `base64('hi')`, want: "aGk="},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.src, err)
+			continue
+		}
+		got, err := e.Eval(scope)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Eval(%q) = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		wantErr string
+	}{
+		{src: ``, wantErr: "empty expression"},
+		{src: `${{ }}`, wantErr: "empty expression"},
+		{src: `a ==`, wantErr: "unexpected end of expression"},
+		{src: `a b`, wantErr: `unexpected "b" at 2`},
+		{src: `(a`, wantErr: `expected ")" at 2`},
+		{src: `a[0`, wantErr: `expected "]" at 3`},
+		{src: `a.`, wantErr: "expected field name at 2"},
+		{src: `== a`, wantErr: `unexpected "==" at 0`},
+		{src: `nope(1)`, wantErr: `unknown function "nope" at 0`},
+		{src: `join()`, wantErr: "join: wrong number of arguments (0)"},
+		{src: `now(1)`, wantErr: "now: wrong number of arguments (1)"},
+		{src: `join(a b)`, wantErr: `expected "," or ")" at 7`},
+		{src: `1.2.3`, wantErr: `invalid number "1.2.3" at 0`},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	scope := map[string]interface{}{"inputs": map[string]interface{}{"n": 1}}
+
+	tests := []struct {
+		src     string
+		wantErr string
+	}{
+		{src: `secrets.token`, wantErr: `unknown variable "secrets"`},
+		{src: `inputs.n < 'a'`, wantErr: "<: cannot compare int and string"},
+		{src: `join(inputs.n)`, wantErr: "join: expected a list, got int"},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.src, err)
+			continue
+		}
+		_, err = e.Eval(scope)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Eval(%q) error = %v, want %q", tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		src  string
+		want [][]string
+	}{
+		{src: `inputs.region`, want: [][]string{{"inputs", "region"}}},
+		{src: `steps.a.outputs["x"]`, want: [][]string{{"steps", "a", "outputs", "x"}}},
+		{
+			src:  `steps.a.outputs[inputs.key].y == secrets.token`,
+			want: [][]string{{"inputs", "key"}, {"steps", "a", "outputs"}, {"secrets", "token"}},
+		},
+		{src: `default(inputs.a, 'x')`, want: [][]string{{"inputs", "a"}}},
+		{src: `'literal'`, want: nil},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.src, err)
+			continue
+		}
+		if got := e.References(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("References(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestRenderString(t *testing.T) {
+	scope := map[string]interface{}{
+		"inputs": map[string]interface{}{"name": "ada", "n": float64(2), "tags": []interface{}{"a"}},
+	}
+
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{src: `plain`, want: "plain"},
+		{src: `${{ inputs.n }}`, want: float64(2)},
+		{src: `${{ inputs.tags }}`, want: []interface{}{"a"}},
+		{src: `hello ${{ inputs.name }}, n=${{ inputs.n }}`, want: "hello ada, n=2"},
+		{src: `${{ inputs.missing }}!`, want: "!"},
+		{src: `${{ 'a}}b' }}`, want: "a}}b"},
+		{src: `tags: ${{ inputs.tags }}`, want: `tags: ["a"]`},
+	}
+
+	for _, tt := range tests {
+		got, err := RenderString(tt.src, scope)
+		if err != nil {
+			t.Errorf("RenderString(%q): %v", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RenderString(%q) = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+
+	if _, err := RenderString(`${{ inputs.name`, scope); err == nil {
+		t.Error("expected an error for an unterminated ${{")
+	}
+}
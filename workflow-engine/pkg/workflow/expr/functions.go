@@ -0,0 +1,99 @@
+package expr
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type function struct {
+	minArgs int
+	maxArgs int // -1 for variadic
+	call    func(args []interface{}) (interface{}, error)
+}
+
+// functions available to expressions, e.g. default(inputs.region, 'eu-west-1')
+var functions = map[string]*function{
+	"default": {minArgs: 2, maxArgs: -1, call: fnDefault},
+	"join":    {minArgs: 1, maxArgs: 2, call: fnJoin},
+	"toJSON":  {minArgs: 1, maxArgs: 1, call: fnToJSON},
+	"base64":  {minArgs: 1, maxArgs: 1, call: fnBase64},
+	"now":     {minArgs: 0, maxArgs: 0, call: fnNow},
+}
+
+// default returns the first argument that is neither null nor "".
+func fnDefault(args []interface{}) (interface{}, error) {
+	for _, a := range args {
+		if s, ok := a.(string); a != nil && (!ok || s != "") {
+			return a, nil
+		}
+	}
+	return args[len(args)-1], nil
+}
+
+// join concatenates list elements with a separator (default ",").
+func fnJoin(args []interface{}) (interface{}, error) {
+	sep := ","
+	if len(args) == 2 {
+		sep = Stringify(args[1])
+	}
+
+	switch list := args[0].(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		parts := make([]string, len(list))
+		for i, v := range list {
+			parts[i] = Stringify(v)
+		}
+		return strings.Join(parts, sep), nil
+	case []string:
+		return strings.Join(list, sep), nil
+	}
+	return nil, fmt.Errorf("join: expected a list, got %T", args[0])
+}
+
+func fnToJSON(args []interface{}) (interface{}, error) {
+	b, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("toJSON: %w", err)
+	}
+	return string(b), nil
+}
+
+func fnBase64(args []interface{}) (interface{}, error) {
+	return base64.StdEncoding.EncodeToString([]byte(Stringify(args[0]))), nil
+}
+
+// now returns the current UTC time in RFC 3339. It is not deterministic and
+// is therefore rejected in `if` and `for_each`, which run in workflow code.
+func fnNow(args []interface{}) (interface{}, error) {
+	return time.Now().UTC().Format(time.RFC3339), nil
+}
+
+// Stringify converts a value for interpolation into surrounding text:
+// strings as-is, null as "", whole numbers without a fraction and
+// objects and lists as JSON.
+func Stringify(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	if f, ok := toFloat(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
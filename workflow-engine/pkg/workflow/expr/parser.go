@@ -0,0 +1,224 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// node is an element of a parsed expression tree.
+type node interface{}
+
+type literalNode struct {
+	value interface{}
+}
+
+// pathNode is a variable reference such as steps.check.outputs["exists"].
+// Each accessor is either a fixed field name or an index expression.
+type pathNode struct {
+	root      string
+	accessors []accessor
+}
+
+type accessor struct {
+	field string
+	index node
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+type callNode struct {
+	name string
+	fn   *function
+	args []node
+}
+
+// binding powers for infix operators
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(op string) error {
+	t := p.next()
+	if t.kind != tokOp || t.text != op {
+		return fmt.Errorf("expected %q at %d", op, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokOp || !ok || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseExpr(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if t := p.peek(); t.kind == tokOp && t.text == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "!", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", t.text, t.pos)
+		}
+		return &literalNode{value: f}, nil
+
+	case tokString:
+		return &literalNode{value: t.text}, nil
+
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if next := p.peek(); next.kind == tokOp && next.text == "(" {
+			return p.parseCall(t)
+		}
+		return p.parsePath(t.text)
+
+	case tokOp:
+		if t.text == "(" {
+			inner, err := p.parseExpr(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	}
+
+	if t.kind == tokEOF {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+func (p *parser) parsePath(root string) (node, error) {
+	path := &pathNode{root: root}
+
+	for {
+		t := p.peek()
+		if t.kind != tokOp {
+			return path, nil
+		}
+
+		switch t.text {
+		case ".":
+			p.next()
+			field := p.next()
+			if field.kind != tokIdent && field.kind != tokNumber {
+				return nil, fmt.Errorf("expected field name at %d", field.pos)
+			}
+			path.accessors = append(path.accessors, accessor{field: field.text})
+
+		case "[":
+			p.next()
+			index, err := p.parseExpr(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			path.accessors = append(path.accessors, accessor{index: index})
+
+		default:
+			return path, nil
+		}
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at %d", name.text, name.pos)
+	}
+	p.next() // (
+
+	call := &callNode{name: name.text, fn: fn}
+	if t := p.peek(); t.kind == tokOp && t.text == ")" {
+		p.next()
+	} else {
+		for {
+			arg, err := p.parseExpr(1)
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+
+			t := p.next()
+			if t.kind == tokOp && t.text == ")" {
+				break
+			}
+			if t.kind != tokOp || t.text != "," {
+				return nil, fmt.Errorf("expected \",\" or \")\" at %d", t.pos)
+			}
+		}
+	}
+
+	if len(call.args) < fn.minArgs || (fn.maxArgs >= 0 && len(call.args) > fn.maxArgs) {
+		return nil, fmt.Errorf("%s: wrong number of arguments (%d)", name.text, len(call.args))
+	}
+	return call, nil
+}
@@ -0,0 +1,189 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// segment is a run of literal text or an embedded ${{ }} expression.
+type segment struct {
+	text string
+	expr *Expression
+}
+
+// splitTemplate cuts s into literal text and ${{ }} expressions. The closing
+// "}}" is searched outside of quoted strings.
+func splitTemplate(s string) ([]segment, error) {
+	var segments []segment
+
+	for {
+		start := strings.Index(s, "${{")
+		if start < 0 {
+			if s != "" {
+				segments = append(segments, segment{text: s})
+			}
+			return segments, nil
+		}
+		if start > 0 {
+			segments = append(segments, segment{text: s[:start]})
+		}
+
+		end := closingBraces(s, start+3)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated ${{ in %q", s)
+		}
+
+		e, err := Parse(s[start+3 : end])
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", s[start:end+2], err)
+		}
+		segments = append(segments, segment{expr: e})
+
+		s = s[end+2:]
+	}
+}
+
+func closingBraces(s string, from int) int {
+	var quote byte
+	for i := from; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(s[i:], "}}"):
+			return i
+		}
+	}
+	return -1
+}
+
+// IsTemplate reports whether s contains a ${{ }} expression.
+func IsTemplate(s string) bool {
+	return strings.Contains(s, "${{")
+}
+
+// Render evaluates the ${{ }} expressions in v, walking into maps and lists.
+// A string that consists of a single expression takes the expression's
+// value with its type intact; expressions embedded in text are interpolated
+// with Stringify.
+func Render(v interface{}, scope map[string]interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return RenderString(v, scope)
+
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			r, err := Render(val, scope)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = r
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			r, err := Render(val, scope)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+
+	return v, nil
+}
+
+// RenderMap is Render for the common map case.
+func RenderMap(m map[string]interface{}, scope map[string]interface{}) (map[string]interface{}, error) {
+	if m == nil {
+		return nil, nil
+	}
+	out, err := Render(m, scope)
+	if err != nil {
+		return nil, err
+	}
+	return out.(map[string]interface{}), nil
+}
+
+// RenderString evaluates the ${{ }} expressions in s.
+func RenderString(s string, scope map[string]interface{}) (interface{}, error) {
+	if !IsTemplate(s) {
+		return s, nil
+	}
+
+	segments, err := splitTemplate(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(segments) == 1 && segments[0].expr != nil {
+		return segments[0].expr.Eval(scope)
+	}
+
+	var sb strings.Builder
+	for _, seg := range segments {
+		if seg.expr == nil {
+			sb.WriteString(seg.text)
+			continue
+		}
+		val, err := seg.expr.Eval(scope)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", seg.expr, err)
+		}
+		sb.WriteString(Stringify(val))
+	}
+	return sb.String(), nil
+}
+
+// TemplateExpressions parses every ${{ }} expression in v, so templates can
+// be validated without evaluating them.
+func TemplateExpressions(v interface{}) ([]*Expression, error) {
+	var exprs []*Expression
+
+	var walk func(interface{}) error
+	walk = func(v interface{}) error {
+		switch v := v.(type) {
+		case string:
+			if !IsTemplate(v) {
+				return nil
+			}
+			segments, err := splitTemplate(v)
+			if err != nil {
+				return err
+			}
+			for _, seg := range segments {
+				if seg.expr != nil {
+					exprs = append(exprs, seg.expr)
+				}
+			}
+		case map[string]interface{}:
+			for k, val := range v {
+				if err := walk(val); err != nil {
+					return fmt.Errorf("%s: %w", k, err)
+				}
+			}
+		case []interface{}:
+			for i, val := range v {
+				if err := walk(val); err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(v); err != nil {
+		return nil, err
+	}
+	return exprs, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/prashantsinghb/workflow-engine/pkg/workflow/parser"
@@ -17,31 +19,100 @@ func NewPostgresWorkflowStore(db *sql.DB) *PostgresWorkflowStore {
 	return &PostgresWorkflowStore{db: db}
 }
 
+const workflowColumns = `id, project_id, name, version, yaml, deprecated, deprecation_message, created_at`
+
+// numberAttempts bounds how often Register numbers a version again after
+// a concurrent registration took the number.
+const numberAttempts = 5
+
+// Register stores a new version. Registering an existing name and version
+// again returns its ID if the definition is unchanged, and fails with
+// ErrVersionExists otherwise. An empty version is numbered after the
+// highest vN version, e.g. v3 after v1 and v2.
 func (s *PostgresWorkflowStore) Register(
 	ctx context.Context,
 	projectID string,
 	wf *Workflow,
 ) (string, error) {
 
-	id := uuid.NewString()
 	def, err := parser.ParseWorkflow([]byte(wf.Yaml))
 	if err != nil {
 		return "", err
 	}
 
-	_, err = s.db.ExecContext(
+	if wf.Version == Latest {
+		return "", fmt.Errorf("%q is reserved and cannot be used as a version", Latest)
+	}
+
+	if wf.Version == "" {
+		for attempt := 1; ; attempt++ {
+			if wf.Version, err = s.nextVersion(ctx, projectID, wf.Name); err != nil {
+				return "", err
+			}
+			inserted, err := s.insert(ctx, projectID, wf)
+			if err != nil {
+				return "", err
+			}
+			if inserted {
+				break
+			}
+			if attempt == numberAttempts {
+				return "", fmt.Errorf("numbering a new version of %s: concurrent registrations took %d numbers", wf.Name, attempt)
+			}
+		}
+	} else {
+		inserted, err := s.insert(ctx, projectID, wf)
+		if err != nil {
+			return "", err
+		}
+		if !inserted {
+			existing, err := s.GetByName(ctx, projectID, wf.Name, wf.Version)
+			if err != nil {
+				return "", err
+			}
+			if existing.Yaml != wf.Yaml {
+				return "", fmt.Errorf("%w: %s %s, register a new version instead", ErrVersionExists, wf.Name, wf.Version)
+			}
+			*wf = *existing
+			return wf.ID, nil
+		}
+	}
+
+	wf.ProjectID = projectID
+	wf.Def = def
+	return wf.ID, nil
+}
+
+// nextVersion returns the version after the highest vN version of name.
+// Versions named otherwise, e.g. "2024-01", are not counted.
+func (s *PostgresWorkflowStore) nextVersion(ctx context.Context, projectID, name string) (string, error) {
+	var n int64
+	err := s.db.QueryRowContext(
 		ctx,
-		`INSERT INTO workflows (id, project_id, name, version, yaml)
-		 VALUES ($1,$2,$3,$4,$5)`,
-		id, projectID, wf.Name, wf.Version, wf.Yaml,
-	)
+		`SELECT COALESCE(MAX(substring(version FROM '^v([0-9]{1,18})$')::bigint), 0)
+		 FROM workflows WHERE project_id=$1 AND name=$2`,
+		projectID, name,
+	).Scan(&n)
 	if err != nil {
 		return "", err
 	}
+	return fmt.Sprintf("v%d", n+1), nil
+}
 
-	wf.ID = id
-	wf.Def = def
-	return id, nil
+// insert stores wf unless its version exists, and reports whether it did.
+func (s *PostgresWorkflowStore) insert(ctx context.Context, projectID string, wf *Workflow) (bool, error) {
+	err := s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO workflows (id, project_id, name, version, yaml)
+		 VALUES ($1,$2,$3,$4,$5)
+		 ON CONFLICT (project_id, name, version) DO NOTHING
+		 RETURNING id, created_at`,
+		uuid.NewString(), projectID, wf.Name, wf.Version, wf.Yaml,
+	).Scan(&wf.ID, &wf.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
 }
 
 func (s *PostgresWorkflowStore) Get(
@@ -50,23 +121,49 @@ func (s *PostgresWorkflowStore) Get(
 	workflowID string,
 ) (*Workflow, error) {
 
-	var wf Workflow
-	err := s.db.QueryRowContext(
+	row := s.db.QueryRowContext(
 		ctx,
-		`SELECT id, name, version, yaml FROM workflows
+		`SELECT `+workflowColumns+` FROM workflows
 		 WHERE id=$1 AND project_id=$2`,
 		workflowID, projectID,
-	).Scan(&wf.ID, &wf.Name, &wf.Version, &wf.Yaml)
-	if err != nil {
-		return nil, err
+	)
+	return scanDefinition(row)
+}
+
+func (s *PostgresWorkflowStore) GetByName(
+	ctx context.Context,
+	projectID string,
+	name string,
+	version string,
+) (*Workflow, error) {
+
+	var row *sql.Row
+	if version == "" || version == Latest {
+		row = s.db.QueryRowContext(
+			ctx,
+			`SELECT `+workflowColumns+` FROM workflows
+			 WHERE project_id=$1 AND name=$2 AND NOT deprecated
+			 ORDER BY created_at DESC
+			 LIMIT 1`,
+			projectID, name,
+		)
+	} else {
+		row = s.db.QueryRowContext(
+			ctx,
+			`SELECT `+workflowColumns+` FROM workflows
+			 WHERE project_id=$1 AND name=$2 AND version=$3`,
+			projectID, name, version,
+		)
 	}
 
-	def, err := parser.ParseWorkflow([]byte(wf.Yaml))
-	if err != nil {
-		return nil, err
+	wf, err := scanDefinition(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		if version == "" || version == Latest {
+			return nil, fmt.Errorf("workflow %s has no version that is not deprecated", name)
+		}
+		return nil, fmt.Errorf("workflow %s version %s not found", name, version)
 	}
-	wf.Def = def
-	return &wf, nil
+	return wf, err
 }
 
 func (s *PostgresWorkflowStore) List(
@@ -74,11 +171,59 @@ func (s *PostgresWorkflowStore) List(
 	projectID string,
 ) ([]*Workflow, error) {
 
-	rows, err := s.db.QueryContext(
+	return s.list(
 		ctx,
-		`SELECT id, name, version, yaml FROM workflows WHERE project_id=$1`,
+		`SELECT `+workflowColumns+` FROM workflows
+		 WHERE project_id=$1
+		 ORDER BY name, created_at DESC`,
 		projectID,
 	)
+}
+
+func (s *PostgresWorkflowStore) ListVersions(
+	ctx context.Context,
+	projectID string,
+	name string,
+) ([]*Workflow, error) {
+
+	return s.list(
+		ctx,
+		`SELECT `+workflowColumns+` FROM workflows
+		 WHERE project_id=$1 AND name=$2
+		 ORDER BY created_at DESC`,
+		projectID, name,
+	)
+}
+
+func (s *PostgresWorkflowStore) SetDeprecated(
+	ctx context.Context,
+	projectID string,
+	workflowID string,
+	deprecated bool,
+	message string,
+) (*Workflow, error) {
+
+	if !deprecated {
+		message = ""
+	}
+	row := s.db.QueryRowContext(
+		ctx,
+		`UPDATE workflows
+		 SET deprecated=$3, deprecation_message=$4, updated_at=now()
+		 WHERE id=$1 AND project_id=$2
+		 RETURNING `+workflowColumns,
+		workflowID, projectID, deprecated, message,
+	)
+	return scanDefinition(row)
+}
+
+func (s *PostgresWorkflowStore) list(
+	ctx context.Context,
+	query string,
+	args ...interface{},
+) ([]*Workflow, error) {
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -86,13 +231,13 @@ func (s *PostgresWorkflowStore) List(
 
 	var out []*Workflow
 	for rows.Next() {
-		var wf Workflow
-		if err := rows.Scan(&wf.ID, &wf.Name, &wf.Version, &wf.Yaml); err != nil {
+		wf, err := scanWorkflow(rows)
+		if err != nil {
 			return nil, err
 		}
-		out = append(out, &wf)
+		out = append(out, wf)
 	}
-	return out, nil
+	return out, rows.Err()
 }
 
 func (s *PostgresWorkflowStore) Count(
@@ -111,6 +256,42 @@ func (s *PostgresWorkflowStore) Count(
 	return count, nil
 }
 
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWorkflow(row scanner) (*Workflow, error) {
+	var wf Workflow
+	err := row.Scan(
+		&wf.ID,
+		&wf.ProjectID,
+		&wf.Name,
+		&wf.Version,
+		&wf.Yaml,
+		&wf.Deprecated,
+		&wf.DeprecationMessage,
+		&wf.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// scanDefinition is scanWorkflow with the YAML parsed.
+func scanDefinition(row scanner) (*Workflow, error) {
+	wf, err := scanWorkflow(row)
+	if err != nil {
+		return nil, err
+	}
+	def, err := parser.ParseWorkflow([]byte(wf.Yaml))
+	if err != nil {
+		return nil, err
+	}
+	wf.Def = def
+	return wf, nil
+}
+
 func (r *PostgresWorkflowStore) RegisterStep(ctx context.Context, def StepDefinition) error {
 	metaJSON, _ := json.Marshal(def.Metadata)
 	inputJSON, _ := json.Marshal(def.InputSchema)
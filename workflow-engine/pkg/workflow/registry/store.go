@@ -2,11 +2,26 @@ package registry
 
 import (
 	"context"
+	"errors"
 )
 
+// Latest selects the newest version of a workflow that is not deprecated.
+const Latest = "latest"
+
+// ErrVersionExists is returned when a name and version are registered
+// again with a different definition: versions are immutable.
+var ErrVersionExists = errors.New("workflow version already exists")
+
 type WorkflowStore interface {
 	Register(ctx context.Context, projectID string, wf *Workflow) (string, error)
 	Get(ctx context.Context, projectID string, workflowID string) (*Workflow, error)
+	// GetByName resolves a workflow by name and version; an empty version
+	// or Latest selects the newest version that is not deprecated
+	GetByName(ctx context.Context, projectID, name, version string) (*Workflow, error)
 	List(ctx context.Context, projectID string) ([]*Workflow, error)
+	// ListVersions returns the versions of a workflow, newest first
+	ListVersions(ctx context.Context, projectID, name string) ([]*Workflow, error)
+	// SetDeprecated marks a version as deprecated, or clears the mark
+	SetDeprecated(ctx context.Context, projectID, workflowID string, deprecated bool, message string) (*Workflow, error)
 	Count(ctx context.Context, projectID string) (int64, error)
 }
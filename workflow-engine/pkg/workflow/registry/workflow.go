@@ -1,7 +1,13 @@
 package registry
 
-import "github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+import (
+	"time"
 
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+)
+
+// Workflow is one immutable version of a workflow. Its ID identifies the
+// version; Name groups the versions.
 type Workflow struct {
 	ID        string
 	ProjectID string
@@ -9,4 +15,10 @@ type Workflow struct {
 	Version   string
 	Yaml      string
 	Def       *api.Definition
+
+	// Deprecated versions are skipped by Latest but can still be started
+	// by ID or explicit version
+	Deprecated         bool
+	DeprecationMessage string
+	CreatedAt          time.Time
 }
@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"fmt"
+
+	moduleapi "github.com/prashantsinghb/workflow-engine/pkg/module/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/contract"
+)
+
+// ContractViolationErrorType is the error type of a node whose inputs or
+// outputs do not match its module's contract.
+const ContractViolationErrorType = "ContractViolation"
+
+// checkContract validates a node's inputs or outputs against the contract
+// its module declares. It returns a *contract.ViolationError, or an error
+// for a contract that does not parse.
+func checkContract(
+	mod *moduleapi.Module,
+	direction contract.Direction,
+	values map[string]interface{},
+) error {
+
+	raw := mod.Inputs
+	if direction == contract.DirectionOutput {
+		raw = mod.Outputs
+	}
+
+	c, err := contract.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("module %s: invalid %s contract: %w", mod.Name, direction, err)
+	}
+
+	violations := c.Check(values)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &contract.ViolationError{
+		Module:     mod.Name,
+		Direction:  direction,
+		Violations: violations,
+	}
+}
+
+// contractError fails a node on err from checkContract. Violations are not
+// retried: the same inputs would fail again, and an executor response is
+// not going to change shape.
+func contractError(err error) error {
+	return &NodeError{Type: ContractViolationErrorType, NonRetryable: true, Err: err}
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	moduleapi "github.com/prashantsinghb/workflow-engine/pkg/module/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/contract"
+)
+
+func TestCheckContract(t *testing.T) {
+	mod := &moduleapi.Module{
+		Name:    "dns.create",
+		Inputs:  map[string]interface{}{"name": map[string]interface{}{"type": "string", "required": true}},
+		Outputs: map[string]interface{}{"record_id": "string"},
+	}
+
+	if err := checkContract(mod, contract.DirectionInput, map[string]interface{}{"name": "a"}); err != nil {
+		t.Errorf("valid inputs: %v", err)
+	}
+	if err := checkContract(mod, contract.DirectionOutput, map[string]interface{}{}); err != nil {
+		t.Errorf("outputs without optional fields: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		direction contract.Direction
+		values    map[string]interface{}
+		want      []contract.Violation
+	}{
+		{
+			name:      "missing input",
+			direction: contract.DirectionInput,
+			values:    map[string]interface{}{},
+			want:      []contract.Violation{{Field: "name", Message: "required"}},
+		},
+		{
+			name:      "outputs are checked against Outputs",
+			direction: contract.DirectionOutput,
+			values:    map[string]interface{}{"name": 1, "record_id": 7},
+			want:      []contract.Violation{{Field: "record_id", Message: "expected string, got int"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkContract(mod, tt.direction, tt.values)
+
+			var cv *contract.ViolationError
+			if !errors.As(err, &cv) {
+				t.Fatalf("error = %v, want a contract violation", err)
+			}
+			if cv.Module != mod.Name || cv.Direction != tt.direction {
+				t.Errorf("details = %+v", cv)
+			}
+			if len(cv.Violations) != len(tt.want) || cv.Violations[0] != tt.want[0] {
+				t.Errorf("violations = %v, want %v", cv.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckContractInvalid(t *testing.T) {
+	mod := &moduleapi.Module{Name: "broken", Inputs: map[string]interface{}{"name": "text"}}
+
+	err := checkContract(mod, contract.DirectionInput, map[string]interface{}{"name": "a"})
+
+	want := `module broken: invalid input contract: name: unknown type "text"`
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestContractError(t *testing.T) {
+	cv := &contract.ViolationError{Module: "dns.create", Direction: contract.DirectionInput}
+
+	var nodeErr *NodeError
+	if !errors.As(contractError(cv), &nodeErr) {
+		t.Fatal("contractError did not return a node error")
+	}
+	if nodeErr.Type != ContractViolationErrorType || !nodeErr.NonRetryable {
+		t.Errorf("error = %+v, want a non-retryable %s", nodeErr, ContractViolationErrorType)
+	}
+	if !errors.As(nodeErr, new(*contract.ViolationError)) {
+		t.Error("the violation is not the error's cause")
+	}
+}
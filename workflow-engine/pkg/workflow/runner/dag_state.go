@@ -0,0 +1,186 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/expr"
+)
+
+// DAGState is what a run knows about its DAG: which nodes settled and
+// what they returned. The Temporal workflow and the embedded engine both
+// decide from it whether a node is skipped, what a for_each iterates over
+// and what the workflow outputs are, so the two behave the same.
+type DAGState struct {
+	Graph  *dag.Graph
+	Inputs map[string]interface{}
+
+	// Completed holds succeeded and skipped nodes
+	Completed   map[dag.NodeID]bool
+	Skipped     map[dag.NodeID]bool
+	StepOutputs map[string]map[string]interface{}
+}
+
+func NewDAGState(graph *dag.Graph, inputs map[string]interface{}) *DAGState {
+	return &DAGState{
+		Graph:       graph,
+		Inputs:      inputs,
+		Completed:   map[dag.NodeID]bool{},
+		Skipped:     map[dag.NodeID]bool{},
+		StepOutputs: map[string]map[string]interface{}{},
+	}
+}
+
+// RetryState seeds a retried run with the results of the execution it
+// retries, so only failed and downstream nodes are executed again.
+type RetryState struct {
+	RetryOf string
+	// StepOutputs holds outputs of nodes that succeeded in RetryOf
+	StepOutputs map[string]map[string]interface{}
+	// NodeInputs overrides `with` values for the re-executed nodes
+	NodeInputs map[string]map[string]interface{}
+}
+
+// Seed marks nodes that already succeeded in the original execution as
+// completed and applies `with` overrides to the nodes that will be re-run.
+func (s *DAGState) Seed(retry *RetryState) {
+	if retry == nil {
+		return
+	}
+
+	for nodeID, out := range retry.StepOutputs {
+		id := dag.NodeID(nodeID)
+		if _, ok := s.Graph.Nodes[id]; !ok {
+			continue
+		}
+		s.StepOutputs[nodeID] = out
+		s.Completed[id] = true
+	}
+
+	for nodeID, with := range retry.NodeInputs {
+		node, ok := s.Graph.Nodes[dag.NodeID(nodeID)]
+		if !ok || s.Completed[node.ID] {
+			continue
+		}
+		merged := make(map[string]interface{}, len(node.With)+len(with))
+		for k, v := range node.With {
+			merged[k] = v
+		}
+		for k, v := range with {
+			merged[k] = v
+		}
+		node.With = merged
+	}
+}
+
+// MarkSkipped records a skipped node; it counts as completed.
+func (s *DAGState) MarkSkipped(id dag.NodeID) {
+	s.Completed[id] = true
+	s.Skipped[id] = true
+}
+
+// SkipReason decides whether node should be skipped instead of executed.
+func (s *DAGState) SkipReason(node *dag.Node) (string, bool, error) {
+	if dag.SkippedByUpstream(node, s.Skipped) {
+		return "dependency skipped", true, nil
+	}
+	if node.If == "" {
+		return "", false, nil
+	}
+
+	cond, err := expr.Parse(node.If)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid if expression: %w", err)
+	}
+	ok, err := cond.EvalBool(s.ExprScope())
+	if err != nil {
+		return "", false, fmt.Errorf("evaluating if %q: %w", node.If, err)
+	}
+	if !ok {
+		return fmt.Sprintf("condition %q is false", node.If), true, nil
+	}
+	return "", false, nil
+}
+
+// ForEachItems evaluates the node's for_each expression to its elements.
+func (s *DAGState) ForEachItems(node *dag.Node) ([]interface{}, error) {
+	e, err := expr.Parse(node.ForEach)
+	if err != nil {
+		return nil, fmt.Errorf("invalid for_each expression: %w", err)
+	}
+
+	v, err := e.Eval(s.ExprScope())
+	if err != nil {
+		return nil, fmt.Errorf("evaluating for_each %q: %w", node.ForEach, err)
+	}
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("for_each %q must yield a list, got %T", node.ForEach, v)
+	}
+	return items, nil
+}
+
+// ExprScope exposes workflow inputs and settled nodes to `if`, `for_each`
+// and workflow `outputs` expressions as `inputs.*` and
+// `steps.<id>.{outputs,status}`.
+func (s *DAGState) ExprScope() map[string]interface{} {
+	steps := map[string]interface{}{}
+	for id := range s.Completed {
+		status := execution.NodeSucceeded
+		if s.Skipped[id] {
+			status = execution.NodeSkipped
+		}
+
+		outputs := map[string]interface{}{}
+		for k, v := range s.StepOutputs[string(id)] {
+			outputs[k] = v
+		}
+
+		steps[string(id)] = map[string]interface{}{
+			"outputs": outputs,
+			"status":  string(status),
+		}
+	}
+
+	return map[string]interface{}{
+		"inputs": WorkflowInputs(s.Inputs),
+		"steps":  steps,
+	}
+}
+
+// Outputs returns the workflow's declared outputs, or every step's outputs
+// when it declares none.
+func (s *DAGState) Outputs(def *api.Definition) (map[string]interface{}, error) {
+	if len(def.Outputs) == 0 {
+		return FlattenStepOutputs(s.StepOutputs), nil
+	}
+	outputs, err := expr.RenderMap(def.Outputs, s.ExprScope())
+	if err != nil {
+		return nil, fmt.Errorf("rendering workflow outputs: %w", err)
+	}
+	return outputs, nil
+}
+
+// InstanceID names the node row of one for_each instance, e.g. "assign[2]".
+func InstanceID(id dag.NodeID, index int) string {
+	return fmt.Sprintf("%s[%d]", id, index)
+}
+
+// ParseInstanceID splits a for_each instance ID into its node and index.
+// ok is false for the ID of a plain node.
+func ParseInstanceID(id string) (dag.NodeID, int, bool) {
+	open := strings.LastIndex(id, "[")
+	if open <= 0 || !strings.HasSuffix(id, "]") {
+		return dag.NodeID(id), 0, false
+	}
+	index, err := strconv.Atoi(id[open+1 : len(id)-1])
+	if err != nil || index < 0 {
+		return dag.NodeID(id), 0, false
+	}
+	return dag.NodeID(id[:open]), index, true
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+)
+
+// ForEachOutputKey holds the ordered instance outputs of a for_each node,
+// e.g. steps.assign.outputs.items[0].
+const ForEachOutputKey = "items"
+
+// MarkFanOutStarted records the parent row of a for_each node.
+func MarkFanOutStarted(
+	ctx context.Context,
+	executionID string,
+	nodeID string,
+	items int,
+) error {
+	id, err := uuid.Parse(executionID)
+	if err != nil {
+		return fmt.Errorf("invalid execution ID: %w", err)
+	}
+
+	if NodeStore != nil {
+		if err := NodeStore.Upsert(ctx, &execution.ExecutionNode{
+			ExecutionID:  id,
+			NodeID:       nodeID,
+			ExecutorType: "for_each",
+			Status:       execution.NodePending,
+			Attempt:      1,
+			MaxAttempts:  1,
+		}); err != nil {
+			return err
+		}
+		if err := NodeStore.MarkRunning(ctx, id, nodeID); err != nil {
+			return err
+		}
+	}
+
+	appendNodeEvent(ctx, id, nodeID, execution.EventNodeStarted,
+		fmt.Sprintf("Fanning out over %d items", items),
+		map[string]any{"items": items},
+	)
+	return nil
+}
+
+// MarkFanOutCompleted records the outcome of a for_each node once all of
+// its instances settled. A non-empty errMsg marks it failed.
+func MarkFanOutCompleted(
+	ctx context.Context,
+	executionID string,
+	nodeID string,
+	outputs map[string]interface{},
+	errMsg string,
+) error {
+	id, err := uuid.Parse(executionID)
+	if err != nil {
+		return fmt.Errorf("invalid execution ID: %w", err)
+	}
+
+	if errMsg != "" {
+		payload := map[string]any{"message": errMsg}
+		if NodeStore != nil {
+			if err := NodeStore.MarkFailed(ctx, id, nodeID, payload); err != nil {
+				return err
+			}
+		}
+		appendNodeEvent(ctx, id, nodeID, execution.EventNodeFailed, errMsg, payload)
+		return nil
+	}
+
+	if NodeStore != nil {
+		return NodeStore.MarkSucceeded(ctx, id, nodeID, outputs)
+	}
+	return nil
+}
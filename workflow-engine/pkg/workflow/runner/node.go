@@ -0,0 +1,249 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/contract"
+	"github.com/prashantsinghb/workflow-engine/pkg/secrets"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/expr"
+)
+
+// NodeRequest carries everything RunNode needs to run a single DAG node.
+type NodeRequest struct {
+	ExecutionID string
+	ProjectID   string
+	Node        *dag.Node
+	Inputs      map[string]interface{}
+	StepOutputs map[string]map[string]interface{}
+	// Iteration is set for one instance of a for_each node
+	Iteration *Iteration
+}
+
+// Iteration identifies one element of a for_each fan-out.
+type Iteration struct {
+	Item  interface{}
+	Index int
+}
+
+// NodeAttempt describes one attempt at running a node.
+type NodeAttempt struct {
+	Attempt     int
+	MaxAttempts int
+	// Timeout bounds the executor; zero leaves it to ctx
+	Timeout time.Duration
+}
+
+// NodeError is a node failure typed for its retry policy. The engines
+// handle it the way Temporal handles an application error: a
+// NonRetryable one fails the node at once, Type is matched against the
+// policy's non-retryable error types, and NextRetryDelay, if set,
+// replaces the policy's wait before the next attempt.
+type NodeError struct {
+	Type           string
+	NonRetryable   bool
+	NextRetryDelay time.Duration
+	Err            error
+}
+
+func (e *NodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// HttpStatusErrorType types HTTP status failures for retry policies, e.g.
+// `non_retryable_errors: [HttpStatusError]`.
+const HttpStatusErrorType = "HttpStatusError"
+
+// RunNode executes one attempt at a DAG node and records it: the node row,
+// its events and logs, with secret values redacted. Retries are up to the
+// engine calling it. A node whose ctx is cancelled is left for
+// MarkExecutionCancelled to record.
+func RunNode(
+	ctx context.Context,
+	req NodeRequest,
+	attempt NodeAttempt,
+) (map[string]interface{}, error) {
+
+	if ModuleRegistry == nil {
+		return nil, fmt.Errorf("module registry not set")
+	}
+	if req.Node == nil {
+		return nil, fmt.Errorf("node is required")
+	}
+	executionID, err := uuid.Parse(req.ExecutionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid execution ID: %w", err)
+	}
+
+	// extract workflow-level inputs properly
+	wfInputs := WorkflowInputs(req.Inputs)
+
+	stepOutputs := req.StepOutputs
+	if stepOutputs == nil {
+		stepOutputs = map[string]map[string]interface{}{}
+	}
+
+	// inject execution context
+	actCtx := executor.WithProjectID(ctx, req.ProjectID)
+	actCtx = executor.WithStepOutputs(actCtx, stepOutputs)
+
+	nodeID := string(req.Node.ID)
+	if it := req.Iteration; it != nil {
+		actCtx = executor.WithIteration(actCtx, it.Item, it.Index)
+		nodeID = InstanceID(req.Node.ID, it.Index)
+	}
+
+	mod, err := ModuleRegistry.GetModule(actCtx, req.ProjectID, req.Node.Uses, "")
+	if err != nil {
+		return nil, err
+	}
+
+	execImpl, ok := executor.All()[mod.Runtime]
+	if !ok {
+		return nil, fmt.Errorf("executor not found: %s", mod.Runtime)
+	}
+
+	// secrets are decrypted on first use; every value handed out is
+	// redacted from the inputs, outputs, errors and logs the node records
+	secretValues := secrets.NewResolver(actCtx, SecretStore, req.ProjectID)
+	actCtx = executor.WithSecrets(actCtx, secretValues)
+
+	// Render ${{ }} expressions in `with` against workflow inputs and steps
+	node := *req.Node
+	node.With, err = expr.RenderMap(req.Node.With, executor.TemplateScope(actCtx, wfInputs))
+	if err != nil {
+		return nil, fmt.Errorf("node %s: rendering with: %w", nodeID, err)
+	}
+
+	// A node's inputs are its rendered `with` alone: workflow inputs reach
+	// it through `${{ inputs.x }}`, and outputs of dependencies through
+	// `${{ steps.<id>.outputs.x }}`, so nothing the node did not ask for
+	// can clobber its own values. Runs started before the
+	// per-node-activities version use NodeActivity, which still merges
+	// every workflow input in.
+	nodeInputs := node.With
+	if nodeInputs == nil {
+		nodeInputs = map[string]interface{}{}
+	}
+	recordedInputs := secretValues.RedactMap(nodeInputs)
+
+	// log for debugging
+	log.Printf("Executing node %s with inputs: %+v\n", nodeID, recordedInputs)
+
+	recordNodeStarted(ctx, executionID, nodeID, mod.Runtime, recordedInputs, attempt)
+
+	if err := checkContract(mod, contract.DirectionInput, nodeInputs); err != nil {
+		err = contractError(secretValues.RedactError(err))
+		recordNodeFailed(ctx, executionID, nodeID, attempt.Attempt, err)
+		return nil, err
+	}
+
+	if attempt.Timeout > 0 {
+		var cancel context.CancelFunc
+		actCtx, cancel = context.WithTimeout(actCtx, attempt.Timeout)
+		defer cancel()
+	}
+
+	// stream executor output, e.g. container logs, into NODE_LOG events
+	logs := newNodeLogRecorder(ctx, executionID, nodeID, secretValues.RedactString)
+	actCtx = executor.WithLogFunc(actCtx, logs.Log)
+
+	out, err := execImpl.Execute(actCtx, &node, nodeInputs)
+	logs.Flush()
+	if err != nil {
+		err = secretValues.RedactError(err)
+		// cancelled nodes are marked SKIPPED by MarkExecutionCancelled
+		if ctx.Err() == nil {
+			recordNodeFailed(ctx, executionID, nodeID, attempt.Attempt, err)
+		}
+		return nil, retryError(err)
+	}
+
+	// outputs become inputs of later steps and part of the history, so a
+	// module echoing a secret does not pass it on
+	out = secretValues.RedactMap(out)
+
+	if err := checkContract(mod, contract.DirectionOutput, out); err != nil {
+		err = contractError(err)
+		recordNodeFailed(ctx, executionID, nodeID, attempt.Attempt, err)
+		return nil, err
+	}
+
+	recordNodeSucceeded(ctx, executionID, nodeID, out)
+	return out, nil
+}
+
+// retryError hands an HTTP status failure to the node's retry policy:
+// statuses outside the module's retry_on_status fail the node at once, and
+// a Retry-After header replaces the policy's wait before the next attempt.
+func retryError(err error) error {
+	var statusErr *executor.HttpStatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+	return &NodeError{
+		Type:           HttpStatusErrorType,
+		NonRetryable:   !statusErr.Retryable,
+		NextRetryDelay: statusErr.RetryAfter,
+		Err:            err,
+	}
+}
+
+// MarkNodeSkipped records a node whose `if` condition was false or whose
+// dependency was skipped.
+func MarkNodeSkipped(
+	ctx context.Context,
+	executionID string,
+	nodeID string,
+	reason string,
+) error {
+	id, err := uuid.Parse(executionID)
+	if err != nil {
+		return fmt.Errorf("invalid execution ID: %w", err)
+	}
+
+	payload := map[string]any{"message": reason}
+	if NodeStore != nil {
+		if err := NodeStore.MarkSkipped(ctx, id, nodeID, payload); err != nil {
+			return err
+		}
+	}
+
+	appendNodeEvent(ctx, id, nodeID, execution.EventNodeSkipped, "Node skipped: "+reason, payload)
+	return nil
+}
+
+// MarkNodeFailed records a node that failed without being run, e.g. one
+// the embedded engine gave up on after its attempts ran out.
+func MarkNodeFailed(
+	ctx context.Context,
+	executionID string,
+	nodeID string,
+	errMsg string,
+) error {
+	id, err := uuid.Parse(executionID)
+	if err != nil {
+		return fmt.Errorf("invalid execution ID: %w", err)
+	}
+
+	payload := map[string]any{"message": errMsg}
+	if NodeStore != nil {
+		if err := NodeStore.MarkFailed(ctx, id, nodeID, payload); err != nil {
+			return err
+		}
+	}
+
+	appendNodeEvent(ctx, id, nodeID, execution.EventNodeFailed, errMsg, payload)
+	return nil
+}
@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+)
+
+// Log lines are appended as NODE_LOG events in batches, so a chatty
+// container does not write one event row per line.
+const (
+	logBatchLines    = 50
+	logBatchInterval = 2 * time.Second
+)
+
+// nodeLogRecorder collects an executor's output for one node. Log may be
+// called from several goroutines.
+type nodeLogRecorder struct {
+	ctx         context.Context
+	executionID uuid.UUID
+	nodeID      string
+	redact      func(string) string
+
+	mu        sync.Mutex
+	lines     []map[string]any
+	lastFlush time.Time
+}
+
+// newNodeLogRecorder returns a recorder that passes every line through
+// redact, e.g. to hide secret values, before storing it.
+func newNodeLogRecorder(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	redact func(string) string,
+) *nodeLogRecorder {
+	return &nodeLogRecorder{
+		// logs written while the node is being cancelled are still kept
+		ctx:         context.WithoutCancel(ctx),
+		executionID: executionID,
+		nodeID:      nodeID,
+		redact:      redact,
+		lastFlush:   time.Now(),
+	}
+}
+
+// Log buffers one line and flushes once the batch is full or old enough.
+func (r *nodeLogRecorder) Log(stream, line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lines = append(r.lines, map[string]any{"stream": stream, "line": r.redact(line)})
+	if len(r.lines) >= logBatchLines || time.Since(r.lastFlush) >= logBatchInterval {
+		r.flushLocked()
+	}
+}
+
+// Flush appends any buffered lines.
+func (r *nodeLogRecorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushLocked()
+}
+
+func (r *nodeLogRecorder) flushLocked() {
+	r.lastFlush = time.Now()
+	if len(r.lines) == 0 {
+		return
+	}
+
+	lines := make([]any, len(r.lines))
+	for i, l := range r.lines {
+		lines[i] = l
+	}
+	r.lines = nil
+
+	appendNodeEvent(r.ctx, r.executionID, r.nodeID, execution.EventNodeLog,
+		fmt.Sprintf("%d log lines", len(lines)),
+		map[string]any{"lines": lines},
+	)
+}
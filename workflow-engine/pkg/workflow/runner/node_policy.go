@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	service "github.com/prashantsinghb/workflow-engine/api/service"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+)
+
+// NodePolicy is the timeout and retry policy a node runs with.
+type NodePolicy struct {
+	// Timeout bounds each attempt
+	Timeout time.Duration
+	Retry   RetryPolicy
+}
+
+// RetryPolicy is a node's retry policy, with the fields and semantics of
+// Temporal's: a zero MaximumAttempts retries without limit and a zero
+// MaximumInterval caps the wait at 100 times the initial interval.
+type RetryPolicy struct {
+	InitialInterval        time.Duration
+	BackoffCoefficient     float64
+	MaximumInterval        time.Duration
+	MaximumAttempts        int32
+	NonRetryableErrorTypes []string
+}
+
+// Backoff is the delay before the attempt after `attempt`.
+func (r *RetryPolicy) Backoff(attempt int) time.Duration {
+	max := r.MaximumInterval
+	if max <= 0 {
+		max = 100 * r.InitialInterval
+	}
+	coefficient := r.BackoffCoefficient
+	if coefficient < 1 {
+		coefficient = 2
+	}
+
+	d := float64(r.InitialInterval) * math.Pow(coefficient, float64(attempt-1))
+	if d > float64(max) {
+		return max
+	}
+	return time.Duration(d)
+}
+
+// DefaultNodePolicy applies where neither a node, its module nor the
+// workflow sets a value.
+func DefaultNodePolicy() NodePolicy {
+	return NodePolicy{
+		Timeout: time.Minute,
+		Retry: RetryPolicy{
+			InitialInterval:    5 * time.Second,
+			BackoffCoefficient: 2,
+			MaximumAttempts:    5,
+		},
+	}
+}
+
+// ResolveNodePolicies resolves the policy of every node of def. Module
+// lookups may not run in workflow code, so the workflow runs it as an
+// activity.
+func ResolveNodePolicies(
+	ctx context.Context,
+	projectID string,
+	def *api.Definition,
+) (map[string]NodePolicy, error) {
+
+	policies := make(map[string]NodePolicy, len(def.Nodes))
+	for id := range def.Nodes {
+		p, err := ResolveNodePolicy(ctx, projectID, def, id)
+		if err != nil {
+			return nil, err
+		}
+		policies[id] = p
+	}
+	return policies, nil
+}
+
+// ResolveNodePolicy layers the node's own timeout and retry policy over
+// its module's defaults, the workflow's defaults and DefaultNodePolicy,
+// field by field. An HTTP module's retry_count and retry_backoff_ms count
+// as module defaults its `defaults` override. A module that cannot be
+// looked up contributes nothing; the node fails on it when it runs.
+func ResolveNodePolicy(
+	ctx context.Context,
+	projectID string,
+	def *api.Definition,
+	nodeID string,
+) (NodePolicy, error) {
+
+	p := DefaultNodePolicy()
+	node, ok := def.Nodes[nodeID]
+	if !ok {
+		return p, fmt.Errorf("unknown node %s", nodeID)
+	}
+
+	layers := []*api.NodeDefaults{def.Defaults}
+	if ModuleRegistry != nil {
+		if mod, err := ModuleRegistry.GetModule(ctx, projectID, node.Uses, ""); err == nil {
+			if mod.Runtime == "http" {
+				spec, err := ModuleRegistry.GetStore().GetHttpSpec(ctx, mod.ID)
+				if err == nil && spec != nil {
+					layers = append(layers, httpRetryDefaults(spec))
+				}
+			}
+			layers = append(layers, mod.Defaults)
+		}
+	}
+	layers = append(layers, &api.NodeDefaults{Timeout: node.Timeout, Retry: node.Retry})
+
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+		if err := p.apply(l); err != nil {
+			return p, fmt.Errorf("node %s: %w", nodeID, err)
+		}
+	}
+	return p, nil
+}
+
+// httpRetryDefaults turns an HTTP module's retry settings into node
+// defaults: retry_count retries after the first attempt, the first one
+// after retry_backoff_ms. The executor itself sends one request per
+// attempt, so these are the only retries.
+func httpRetryDefaults(spec *service.HttpModuleSpec) *api.NodeDefaults {
+	retry := &api.RetryPolicy{}
+	if spec.RetryCount > 0 {
+		retry.MaxAttempts = int(spec.RetryCount) + 1
+	}
+	if spec.RetryBackoffMs > 0 {
+		retry.InitialInterval = (time.Duration(spec.RetryBackoffMs) * time.Millisecond).String()
+	}
+	return &api.NodeDefaults{Retry: retry}
+}
+
+// apply overrides the fields d sets.
+func (p *NodePolicy) apply(d *api.NodeDefaults) error {
+	if d.Timeout != "" {
+		timeout, err := time.ParseDuration(d.Timeout)
+		if err != nil {
+			return fmt.Errorf("timeout: %w", err)
+		}
+		p.Timeout = timeout
+	}
+
+	r := d.Retry
+	if r == nil {
+		return nil
+	}
+	if r.MaxAttempts > 0 {
+		p.Retry.MaximumAttempts = int32(r.MaxAttempts)
+	}
+	if r.InitialInterval != "" {
+		interval, err := time.ParseDuration(r.InitialInterval)
+		if err != nil {
+			return fmt.Errorf("retry: initial_interval: %w", err)
+		}
+		p.Retry.InitialInterval = interval
+	}
+	if r.Backoff > 0 {
+		p.Retry.BackoffCoefficient = r.Backoff
+	}
+	if r.MaxInterval != "" {
+		interval, err := time.ParseDuration(r.MaxInterval)
+		if err != nil {
+			return fmt.Errorf("retry: max_interval: %w", err)
+		}
+		p.Retry.MaximumInterval = interval
+	}
+	if r.NonRetryableErrors != nil {
+		p.Retry.NonRetryableErrorTypes = append([]string(nil), r.NonRetryableErrors...)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	service "github.com/prashantsinghb/workflow-engine/api/service"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor"
+)
+
+func TestHttpRetryDefaults(t *testing.T) {
+	p := DefaultNodePolicy()
+	if err := p.apply(httpRetryDefaults(&service.HttpModuleSpec{RetryCount: 3, RetryBackoffMs: 250})); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if p.Retry.MaximumAttempts != 4 || p.Retry.InitialInterval != 250*time.Millisecond {
+		t.Errorf("retry = %+v, want 4 attempts starting at 250ms", p.Retry)
+	}
+
+	// the module's own defaults are applied after the spec and win
+	if err := p.apply(&api.NodeDefaults{Retry: &api.RetryPolicy{MaxAttempts: 2}}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if p.Retry.MaximumAttempts != 2 || p.Retry.InitialInterval != 250*time.Millisecond {
+		t.Errorf("retry = %+v, want 2 attempts starting at 250ms", p.Retry)
+	}
+
+	p = DefaultNodePolicy()
+	if err := p.apply(httpRetryDefaults(&service.HttpModuleSpec{})); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !reflect.DeepEqual(p, DefaultNodePolicy()) {
+		t.Errorf("an unset spec changed the policy: %+v", p.Retry)
+	}
+}
+
+func TestRetryError(t *testing.T) {
+	other := errors.New("connection refused")
+	if got := retryError(other); got != other {
+		t.Errorf("retryError changed a non-HTTP error: %v", got)
+	}
+
+	tests := []struct {
+		name         string
+		err          *executor.HttpStatusError
+		nonRetryable bool
+		delay        time.Duration
+	}{
+		{name: "retry_on_status", err: &executor.HttpStatusError{StatusCode: 503, Retryable: true}},
+		{name: "Retry-After", err: &executor.HttpStatusError{StatusCode: 429, Retryable: true, RetryAfter: 7 * time.Second}, delay: 7 * time.Second},
+		{name: "other status", err: &executor.HttpStatusError{StatusCode: 404}, nonRetryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := retryError(fmt.Errorf("call: %w", tt.err))
+
+			var nodeErr *NodeError
+			if !errors.As(err, &nodeErr) {
+				t.Fatalf("error = %v, want a node error", err)
+			}
+			if nodeErr.Type != HttpStatusErrorType {
+				t.Errorf("type = %q, want %q", nodeErr.Type, HttpStatusErrorType)
+			}
+			if nodeErr.NonRetryable != tt.nonRetryable {
+				t.Errorf("non-retryable = %v, want %v", nodeErr.NonRetryable, tt.nonRetryable)
+			}
+			if nodeErr.NextRetryDelay != tt.delay {
+				t.Errorf("next retry delay = %v, want %v", nodeErr.NextRetryDelay, tt.delay)
+			}
+			if !errors.As(err, new(*executor.HttpStatusError)) {
+				t.Error("the status error is not the cause")
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	r := RetryPolicy{InitialInterval: time.Second, BackoffCoefficient: 2}
+	for attempt, want := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		4: 8 * time.Second,
+		// capped at 100 times the initial interval
+		10: 100 * time.Second,
+	} {
+		if got := r.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	r.MaximumInterval = 3 * time.Second
+	if got := r.Backoff(4); got != 3*time.Second {
+		t.Errorf("Backoff(4) = %v, want the 3s maximum", got)
+	}
+}
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/contract"
+)
+
+// --- recordNodeStarted persists the node row for the current attempt ---
+// Bookkeeping failures are logged, never returned: a node must not fail
+// because its state could not be written.
+func recordNodeStarted(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	executorType string,
+	input map[string]interface{},
+	attempt NodeAttempt,
+) {
+	maxAttempts := attempt.MaxAttempts
+
+	if NodeStore != nil {
+		if attempt.Attempt <= 1 {
+			logStoreErr(nodeID, NodeStore.Upsert(ctx, &execution.ExecutionNode{
+				ExecutionID:  executionID,
+				NodeID:       nodeID,
+				ExecutorType: executorType,
+				Status:       execution.NodePending,
+				Attempt:      1,
+				MaxAttempts:  maxAttempts,
+				Input:        input,
+			}))
+		} else {
+			logStoreErr(nodeID, NodeStore.IncrementAttempt(ctx, executionID, nodeID))
+		}
+		logStoreErr(nodeID, NodeStore.MarkRunning(ctx, executionID, nodeID))
+	}
+
+	if attempt.Attempt > 1 {
+		appendNodeEvent(ctx, executionID, nodeID, execution.EventNodeRetry,
+			fmt.Sprintf("Retrying node (attempt %d)", attempt.Attempt),
+			map[string]any{"attempt": attempt.Attempt, "max_attempts": maxAttempts},
+		)
+	}
+
+	appendNodeEvent(ctx, executionID, nodeID, execution.EventNodeStarted,
+		"Node started",
+		map[string]any{"attempt": attempt.Attempt, "executor": executorType},
+	)
+}
+
+func recordNodeSucceeded(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	output map[string]interface{},
+) {
+	if NodeStore != nil {
+		logStoreErr(nodeID, NodeStore.MarkSucceeded(ctx, executionID, nodeID, output))
+	}
+}
+
+func recordNodeFailed(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	attempt int,
+	nodeErr error,
+) {
+	payload := map[string]any{
+		"message": nodeErr.Error(),
+		"attempt": attempt,
+	}
+
+	var cv *contract.ViolationError
+	if errors.As(nodeErr, &cv) {
+		payload["type"] = "CONTRACT_VIOLATION"
+		payload["contract"] = cv
+	}
+
+	if NodeStore != nil {
+		logStoreErr(nodeID, NodeStore.MarkFailed(ctx, executionID, nodeID, payload))
+	}
+
+	appendNodeEvent(ctx, executionID, nodeID, execution.EventNodeFailed, nodeErr.Error(), payload)
+}
+
+func appendNodeEvent(
+	ctx context.Context,
+	executionID uuid.UUID,
+	nodeID string,
+	eventType string,
+	message string,
+	payload map[string]any,
+) {
+	if EventStore == nil {
+		return
+	}
+
+	logStoreErr(nodeID, EventStore.Append(ctx, &execution.ExecutionEvent{
+		ExecutionID: executionID,
+		NodeID:      &nodeID,
+		EventType:   eventType,
+		Message:     message,
+		Payload:     payload,
+	}))
+}
+
+func logStoreErr(nodeID string, err error) {
+	if err != nil {
+		log.Printf("failed to record state for node %s: %v\n", nodeID, err)
+	}
+}
@@ -0,0 +1,219 @@
+// Package runner runs workflow nodes and tracks a run's DAG state
+// independently of the engine scheduling them. The Temporal workflow calls
+// it through activities, the embedded engine directly, so a run behaves
+// the same on either.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/prashantsinghb/workflow-engine/pkg/execution"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/secrets"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/parser"
+	wfregistry "github.com/prashantsinghb/workflow-engine/pkg/workflow/registry"
+)
+
+// Signals accepted by a running execution.
+const (
+	SignalPause  = "pause-execution"
+	SignalResume = "resume-execution"
+)
+
+// globals injected by worker
+var (
+	ModuleRegistry *registry.ModuleRegistry
+	ExecutionStore execution.ExecutionStore
+	WorkflowStore  wfregistry.WorkflowStore
+	NodeStore      execution.NodeStore
+	EventStore     execution.EventStore
+	SecretStore    secrets.Store
+)
+
+func SetModuleRegistry(m *registry.ModuleRegistry) {
+	ModuleRegistry = m
+}
+
+func SetExecutionStore(s execution.ExecutionStore) {
+	ExecutionStore = s
+}
+
+func SetWorkflowStore(s wfregistry.WorkflowStore) {
+	WorkflowStore = s
+}
+
+func SetNodeStore(s execution.NodeStore) {
+	NodeStore = s
+}
+
+func SetEventStore(s execution.EventStore) {
+	EventStore = s
+}
+
+func SetSecretStore(s secrets.Store) {
+	SecretStore = s
+}
+
+// WorkflowInputs extracts workflow inputs safely from Temporal payloads.
+func WorkflowInputs(inputs map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+
+	// Temporal SDK may pass payloads as "payloads" key
+	if plRaw, ok := inputs["payloads"]; ok {
+		if arr, ok := plRaw.([]interface{}); ok && len(arr) > 0 {
+			// take last item, should be a map[string]interface{}
+			if last, ok := arr[len(arr)-1].(map[string]interface{}); ok {
+				for k, v := range last {
+					result[k] = v
+				}
+			}
+		}
+	}
+
+	// fallback: merge any top-level keys
+	for k, v := range inputs {
+		if k != "payloads" {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+// FlattenStepOutputs returns every step's outputs keyed by node ID.
+func FlattenStepOutputs(in map[string]map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+// loadWorkflow loads the workflow's current definition.
+func loadWorkflow(
+	ctx context.Context,
+	projectID string,
+	workflowID string,
+) (*api.Definition, error) {
+
+	if WorkflowStore == nil {
+		return nil, fmt.Errorf("workflow store not set")
+	}
+
+	wf, err := WorkflowStore.Get(ctx, projectID, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	return wf.Def, nil
+}
+
+// LoadExecutionDefinition loads the definition the execution is pinned
+// to, so changes to the workflow never reach runs already started.
+// Executions recorded before definitions were pinned fall back to the
+// workflow.
+func LoadExecutionDefinition(
+	ctx context.Context,
+	projectID string,
+	workflowID string,
+	executionID string,
+) (*api.Definition, error) {
+
+	if ExecutionStore == nil {
+		return nil, fmt.Errorf("execution store not set")
+	}
+	id, err := uuid.Parse(executionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid execution ID: %w", err)
+	}
+
+	yaml, err := ExecutionStore.GetDefinition(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if yaml == "" {
+		return loadWorkflow(ctx, projectID, workflowID)
+	}
+	return parser.ParseWorkflow([]byte(yaml))
+}
+
+// --- helpers to mark execution status ---
+// An execution that already finished, e.g. one the reconciler settled or a
+// user cancelled first, keeps its state.
+func MarkExecutionSucceeded(
+	ctx context.Context,
+	executionID string,
+	outputs map[string]interface{},
+) error {
+	if ExecutionStore == nil {
+		return fmt.Errorf("execution store not set")
+	}
+	id, err := uuid.Parse(executionID)
+	if err != nil {
+		return fmt.Errorf("invalid execution ID: %w", err)
+	}
+	return ignoreFinished(ExecutionStore.MarkCompleted(ctx, id, outputs))
+}
+
+func MarkExecutionFailed(
+	ctx context.Context,
+	executionID string,
+	errMsg string,
+) error {
+	if ExecutionStore == nil {
+		return fmt.Errorf("execution store not set")
+	}
+	id, err := uuid.Parse(executionID)
+	if err != nil {
+		return fmt.Errorf("invalid execution ID: %w", err)
+	}
+	return ignoreFinished(ExecutionStore.MarkFailed(ctx, id, map[string]any{"message": errMsg}))
+}
+
+func MarkExecutionCancelled(
+	ctx context.Context,
+	executionID string,
+	unfinishedNodes []string,
+) error {
+	if ExecutionStore == nil {
+		return fmt.Errorf("execution store not set")
+	}
+	id, err := uuid.Parse(executionID)
+	if err != nil {
+		return fmt.Errorf("invalid execution ID: %w", err)
+	}
+
+	if NodeStore != nil {
+		for _, nodeID := range unfinishedNodes {
+			if err := NodeStore.MarkSkipped(ctx, id, nodeID, map[string]any{"message": "execution cancelled"}); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = ExecutionStore.MarkCancelled(ctx, id, map[string]any{"message": "execution cancelled"})
+	if err != nil {
+		return ignoreFinished(err)
+	}
+
+	// Recorded once the run has stopped, and only by the run that moved
+	// the execution to CANCELLED
+	if EventStore != nil {
+		_ = EventStore.Append(ctx, &execution.ExecutionEvent{
+			ExecutionID: id,
+			EventType:   execution.EventExecutionCancelled,
+			Message:     "execution cancelled",
+		})
+	}
+	return nil
+}
+
+func ignoreFinished(err error) error {
+	if errors.Is(err, execution.ErrStateChanged) {
+		return nil
+	}
+	return err
+}
@@ -2,213 +2,170 @@ package temporal
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/prashantsinghb/workflow-engine/pkg/execution"
-	"github.com/prashantsinghb/workflow-engine/pkg/module/registry"
-	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
-	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor"
-	wfregistry "github.com/prashantsinghb/workflow-engine/pkg/workflow/registry"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/contract"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/validation"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 )
 
-// globals injected by worker
-var (
-	ModuleRegistry *registry.ModuleRegistry
-	ExecutionStore execution.ExecutionStore
-	WorkflowStore  wfregistry.WorkflowStore
-)
-
-func SetModuleRegistry(m *registry.ModuleRegistry) {
-	ModuleRegistry = m
-}
-
-func SetExecutionStore(s execution.ExecutionStore) {
-	ExecutionStore = s
+// heartbeatInterval must stay well below the HeartbeatTimeout set in
+// activityOptions.
+const heartbeatInterval = 5 * time.Second
+
+// ScheduledRunRequest describes one run fired by a schedule.
+type ScheduledRunRequest struct {
+	ProjectID          string
+	WorkflowID         string
+	ScheduleID         string
+	TemporalWorkflowID string
+	TemporalRunID      string
+	Inputs             map[string]interface{}
 }
 
-func SetWorkflowStore(s wfregistry.WorkflowStore) {
-	WorkflowStore = s
+// ScheduledRun is the execution recorded for a scheduled run.
+type ScheduledRun struct {
+	ExecutionID string
+	// Inputs are validated, with defaults applied
+	Inputs map[string]interface{}
 }
 
-// --- helper to merge inputs for a node ---
-func mergeNodeInputs(node *dag.Node, workflowInputs map[string]interface{}, stepOutputs map[string]map[string]interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
+// CreateScheduledExecution records a scheduled run as a RUNNING execution.
+// The Temporal workflow ID is unique per run and doubles as the client
+// request ID, so a retried activity finds the row it created before.
+// Inputs that no longer match the workflow fail the run without retries.
+func CreateScheduledExecution(
+	ctx context.Context,
+	req ScheduledRunRequest,
+) (*ScheduledRun, error) {
 
-	// workflow-level inputs
-	for k, v := range workflowInputs {
-		result[k] = v
+	if runner.ExecutionStore == nil || runner.WorkflowStore == nil {
+		return nil, fmt.Errorf("execution store not set")
 	}
 
-	// outputs from dependent nodes
-	for _, dep := range node.Depends {
-		if depOut, ok := stepOutputs[string(dep)]; ok {
-			for k, v := range depOut {
-				result[k] = v
+	existing, err := runner.ExecutionStore.GetByIdempotencyKey(ctx, req.ProjectID, req.WorkflowID, req.TemporalWorkflowID)
+	if err == nil {
+		if existing.Status == execution.ExecutionFailed {
+			return nil, temporal.NewNonRetryableApplicationError("scheduled run failed", "InvalidInputs", nil)
+		}
+		if existing.Status == execution.ExecutionPending {
+			if err := runner.ExecutionStore.MarkRunning(ctx, existing.ID, req.TemporalRunID); err != nil {
+				return nil, err
 			}
 		}
+		return &ScheduledRun{ExecutionID: existing.ID.String(), Inputs: existing.Inputs}, nil
 	}
 
-	// node-level 'with' overrides
-	for k, v := range node.With {
-		result[k] = v
+	wf, err := runner.WorkflowStore.Get(ctx, req.ProjectID, req.WorkflowID)
+	if err != nil {
+		return nil, err
 	}
 
-	return result
-}
-
-// --- helper to extract workflow inputs safely from Temporal payloads ---
-func extractWorkflowInputs(inputs map[string]interface{}) map[string]interface{} {
-	result := map[string]interface{}{}
-
-	// Temporal SDK may pass payloads as "payloads" key
-	if plRaw, ok := inputs["payloads"]; ok {
-		if arr, ok := plRaw.([]interface{}); ok && len(arr) > 0 {
-			// take last item, should be a map[string]interface{}
-			if last, ok := arr[len(arr)-1].(map[string]interface{}); ok {
-				for k, v := range last {
-					result[k] = v
-				}
-			}
+	inputs := req.Inputs
+	var inputErr error
+	if wf.Def != nil {
+		inputs, inputErr = validation.ValidateInputs(wf.Def.Inputs, req.Inputs)
+		if inputErr != nil {
+			inputs = req.Inputs
 		}
 	}
 
-	// fallback: merge any top-level keys
-	for k, v := range inputs {
-		if k != "payloads" {
-			result[k] = v
-		}
+	exec := &execution.Execution{
+		ID:                 uuid.New(),
+		ProjectID:          req.ProjectID,
+		WorkflowID:         req.WorkflowID,
+		ClientRequestID:    req.TemporalWorkflowID,
+		TriggerType:        execution.TriggerSchedule,
+		ScheduleID:         req.ScheduleID,
+		Version:            wf.Version,
+		Definition:         wf.Yaml,
+		TemporalWorkflowID: req.TemporalWorkflowID,
+		Status:             execution.ExecutionPending,
+		Inputs:             inputs,
+	}
+	if err := runner.ExecutionStore.Create(ctx, exec); err != nil {
+		return nil, err
+	}
+
+	if inputErr != nil {
+		_ = runner.ExecutionStore.MarkFailed(ctx, exec.ID, map[string]any{"message": inputErr.Error()})
+		return nil, temporal.NewNonRetryableApplicationError(inputErr.Error(), "InvalidInputs", inputErr)
 	}
 
-	return result
+	if err := runner.ExecutionStore.MarkRunning(ctx, exec.ID, req.TemporalRunID); err != nil {
+		return nil, err
+	}
+	return &ScheduledRun{ExecutionID: exec.ID.String(), Inputs: inputs}, nil
 }
 
-// --- NodeActivity executes a workflow DAG inside Temporal ---
-func NodeActivity(
+// --- RunNodeActivity executes a single DAG node inside Temporal ---
+func RunNodeActivity(
 	ctx context.Context,
-	projectID string,
-	workflowID string,
-	inputs map[string]interface{},
+	req runner.NodeRequest,
 ) (map[string]interface{}, error) {
 
-	if WorkflowStore == nil {
-		return nil, fmt.Errorf("workflow store not set")
-	}
-	if ModuleRegistry == nil {
-		return nil, fmt.Errorf("module registry not set")
+	info := activity.GetInfo(ctx)
+	attempt := runner.NodeAttempt{Attempt: int(info.Attempt)}
+	if info.RetryPolicy != nil {
+		attempt.MaxAttempts = int(info.RetryPolicy.MaximumAttempts)
 	}
 
-	// extract workflow-level inputs properly
-	wfInputs := extractWorkflowInputs(inputs)
+	// heartbeat so a workflow cancellation cancels ctx for the executor
+	stopHeartbeat := startHeartbeat(ctx)
+	defer stopHeartbeat()
 
-	// Load workflow
-	wf, err := WorkflowStore.Get(ctx, projectID, workflowID)
+	out, err := runner.RunNode(ctx, req, attempt)
 	if err != nil {
-		return nil, err
-	}
-
-	graph := dag.Build(wf.Def)
-
-	done := map[dag.NodeID]bool{}
-	stepOutputs := map[string]map[string]interface{}{}
-
-	for len(done) < len(graph.Nodes) {
-		progress := false
-
-		for id, node := range graph.Nodes {
-			if done[id] {
-				continue
-			}
-			if !isReady(node, done) {
-				continue
-			}
-
-			// inject execution context
-			actCtx := executor.WithProjectID(ctx, projectID)
-			actCtx = executor.WithStepOutputs(actCtx, stepOutputs)
-
-			mod, err := ModuleRegistry.GetModule(actCtx, projectID, node.Uses, "")
-			if err != nil {
-				return nil, err
-			}
-
-			execImpl, ok := executor.All()[mod.Runtime]
-			if !ok {
-				return nil, fmt.Errorf("executor not found: %s", mod.Runtime)
-			}
-
-			// Merge inputs for this node
-			nodeInputs := mergeNodeInputs(node, wfInputs, stepOutputs)
-
-			// log for debugging
-			log.Printf("Executing node %s with inputs: %+v\n", id, nodeInputs)
-
-			// Execute node
-			out, err := execImpl.Execute(actCtx, node, nodeInputs)
-			if err != nil {
-				return nil, err
-			}
-
-			stepOutputs[string(id)] = out
-			done[id] = true
-			progress = true
-		}
-
-		if !progress {
-			return nil, fmt.Errorf("deadlock detected in DAG")
-		}
+		return nil, applicationError(err)
 	}
-
-	return stepOutputsToFlat(stepOutputs), nil
+	return out, nil
 }
 
-// --- helpers to mark execution status ---
-func MarkExecutionSucceeded(
-	ctx context.Context,
-	executionID string,
-	outputs map[string]interface{},
-) error {
-	if ExecutionStore == nil {
-		return fmt.Errorf("execution store not set")
+// applicationError turns a runner.NodeError into the application error
+// Temporal's retry policy reads. A contract violation travels as its
+// details, so clients can tell which fields were wrong.
+func applicationError(err error) error {
+	var nodeErr *runner.NodeError
+	if !errors.As(err, &nodeErr) {
+		return err
 	}
-	id, err := uuid.Parse(executionID)
-	if err != nil {
-		return fmt.Errorf("invalid execution ID: %w", err)
-	}
-	return ExecutionStore.MarkCompleted(ctx, id, outputs)
-}
 
-func MarkExecutionFailed(
-	ctx context.Context,
-	executionID string,
-	errMsg string,
-) error {
-	if ExecutionStore == nil {
-		return fmt.Errorf("execution store not set")
+	opts := temporal.ApplicationErrorOptions{
+		NonRetryable:   nodeErr.NonRetryable,
+		Cause:          nodeErr.Err,
+		NextRetryDelay: nodeErr.NextRetryDelay,
 	}
-	id, err := uuid.Parse(executionID)
-	if err != nil {
-		return fmt.Errorf("invalid execution ID: %w", err)
+	var cv *contract.ViolationError
+	if errors.As(nodeErr.Err, &cv) {
+		opts.Details = []interface{}{cv}
 	}
-	return ExecutionStore.MarkFailed(ctx, id, map[string]any{"message": errMsg})
+	return temporal.NewApplicationErrorWithOptions(err.Error(), nodeErr.Type, opts)
 }
 
-// --- helper to check if node dependencies are satisfied ---
-func isReady(node *dag.Node, done map[dag.NodeID]bool) bool {
-	for _, dep := range node.Depends {
-		if !done[dep] {
-			return false
+// --- heartbeat running activities until stopped ---
+func startHeartbeat(ctx context.Context) func() {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				activity.RecordHeartbeat(ctx)
+			}
 		}
-	}
-	return true
-}
+	}()
 
-// --- flatten step outputs ---
-func stepOutputsToFlat(in map[string]map[string]interface{}) map[string]interface{} {
-	out := map[string]interface{}{}
-	for k, v := range in {
-		out[k] = v
-	}
-	return out
+	return func() { close(done) }
 }
@@ -0,0 +1,74 @@
+package temporal
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/module/contract"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+func TestApplicationError(t *testing.T) {
+	other := errors.New("connection refused")
+	if got := applicationError(other); got != other {
+		t.Errorf("applicationError changed a plain error: %v", got)
+	}
+
+	cv := &contract.ViolationError{
+		Module:     "dns.create",
+		Direction:  contract.DirectionInput,
+		Violations: []contract.Violation{{Field: "name", Message: "required"}},
+	}
+	tests := []struct {
+		name string
+		err  *runner.NodeError
+	}{
+		{
+			name: "retry delay",
+			err:  &runner.NodeError{Type: runner.HttpStatusErrorType, NextRetryDelay: 7 * time.Second, Err: errors.New("status 429")},
+		},
+		{
+			name: "non-retryable",
+			err:  &runner.NodeError{Type: runner.HttpStatusErrorType, NonRetryable: true, Err: errors.New("status 404")},
+		},
+		{
+			name: "contract violation",
+			err:  &runner.NodeError{Type: runner.ContractViolationErrorType, NonRetryable: true, Err: cv},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applicationError(fmt.Errorf("node: %w", tt.err))
+
+			var appErr *temporal.ApplicationError
+			if !errors.As(err, &appErr) {
+				t.Fatalf("error = %v, want an application error", err)
+			}
+			if appErr.Type() != tt.err.Type || appErr.NonRetryable() != tt.err.NonRetryable {
+				t.Errorf("type %q, non-retryable %v, want %q, %v", appErr.Type(), appErr.NonRetryable(), tt.err.Type, tt.err.NonRetryable)
+			}
+			if appErr.NextRetryDelay() != tt.err.NextRetryDelay {
+				t.Errorf("next retry delay = %v, want %v", appErr.NextRetryDelay(), tt.err.NextRetryDelay)
+			}
+			if !errors.Is(err, tt.err.Err) {
+				t.Error("the node's error is not the cause")
+			}
+
+			if !errors.As(tt.err.Err, new(*contract.ViolationError)) {
+				return
+			}
+			var details *contract.ViolationError
+			if err := appErr.Details(&details); err != nil {
+				t.Fatalf("details: %v", err)
+			}
+			if details.Module != cv.Module || len(details.Violations) != 1 {
+				t.Errorf("details = %+v", details)
+			}
+		})
+	}
+}
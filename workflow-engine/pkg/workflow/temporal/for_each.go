@@ -0,0 +1,99 @@
+package temporal
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+// dispatchForEach runs one RunNodeActivity per element, at most MaxParallel at
+// a time. The node completes once every instance succeeded; its outputs are
+// the instance outputs in element order under runner.ForEachOutputKey.
+func (s *dagScheduler) dispatchForEach(ctx workflow.Context, node *dag.Node, items []interface{}) {
+	id := node.ID
+	s.running[id] = true
+
+	limit := node.MaxParallel
+	if limit <= 0 || limit > len(items) {
+		limit = len(items)
+	}
+
+	workflow.Go(ctx, func(gctx workflow.Context) {
+		_ = workflow.ExecuteActivity(gctx, runner.MarkFanOutStarted, s.executionID, string(id), len(items)).Get(gctx, nil)
+
+		actx := workflow.WithActivityOptions(gctx, activityOptions(s.policy(id)))
+		results := make([]interface{}, len(items))
+		inflight := 0
+		var failed error
+
+		for i, item := range items {
+			if err := workflow.Await(gctx, func() bool {
+				return failed != nil || inflight < limit
+			}); err != nil && failed == nil {
+				failed = err
+			}
+			if failed != nil {
+				break
+			}
+
+			req := s.nodeRequest(node)
+			req.Iteration = &runner.Iteration{Item: item, Index: i}
+
+			instance := runner.InstanceID(id, i)
+			s.instances[instance] = true
+			inflight++
+
+			future := workflow.ExecuteActivity(actx, RunNodeActivity, req)
+			workflow.Go(gctx, func(ictx workflow.Context) {
+				var out map[string]interface{}
+				err := future.Get(ictx, &out)
+				inflight--
+
+				if err != nil {
+					if failed == nil {
+						failed = fmt.Errorf("%s: %w", instance, err)
+					}
+					// a cancelled instance stays unfinished
+					if ictx.Err() == nil {
+						delete(s.instances, instance)
+					}
+					return
+				}
+				delete(s.instances, instance)
+				results[i] = out
+			})
+		}
+
+		// in-flight instances always settle, even after cancellation
+		dctx, _ := workflow.NewDisconnectedContext(gctx)
+		_ = workflow.Await(dctx, func() bool { return inflight == 0 })
+
+		// cancelled fan-outs are marked SKIPPED by MarkExecutionCancelled
+		var outputs map[string]interface{}
+		if gctx.Err() == nil {
+			errMsg := ""
+			if failed != nil {
+				errMsg = failed.Error()
+			} else {
+				outputs = map[string]interface{}{runner.ForEachOutputKey: results}
+			}
+			_ = workflow.ExecuteActivity(gctx, runner.MarkFanOutCompleted, s.executionID, string(id), outputs, errMsg).Get(gctx, nil)
+		}
+
+		delete(s.running, id)
+		s.version++
+
+		if failed != nil {
+			if s.err == nil {
+				s.err = fmt.Errorf("node %s failed: %w", id, failed)
+			}
+			return
+		}
+
+		s.StepOutputs[string(id)] = outputs
+		s.Completed[id] = true
+	})
+}
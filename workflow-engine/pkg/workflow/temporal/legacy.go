@@ -0,0 +1,144 @@
+package temporal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/executor"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+// legacyWorkflowExecution is WorkflowExecution as it was before nodes were
+// scheduled one activity each: the whole DAG runs in a single NodeActivity.
+// Runs started then replay through it and must keep issuing the same
+// commands.
+func legacyWorkflowExecution(
+	ctx workflow.Context,
+	executionID string,
+	projectID string,
+	workflowID string,
+	inputs map[string]interface{},
+) error {
+
+	logger := workflow.GetLogger(ctx)
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    5 * time.Second,
+			BackoffCoefficient: 2,
+			MaximumAttempts:    5,
+		},
+	})
+
+	var outputs map[string]interface{}
+	err := workflow.ExecuteActivity(
+		ctx,
+		NodeActivity,
+		projectID,
+		workflowID,
+		inputs,
+	).Get(ctx, &outputs)
+
+	if err != nil {
+		logger.Error("workflow failed", "error", err)
+
+		_ = workflow.ExecuteActivity(
+			ctx,
+			runner.MarkExecutionFailed,
+			executionID,
+			err.Error(),
+		).Get(ctx, nil)
+
+		return err
+	}
+
+	_ = workflow.ExecuteActivity(
+		ctx,
+		runner.MarkExecutionSucceeded,
+		executionID,
+		outputs,
+	).Get(ctx, nil)
+
+	return nil
+}
+
+// NodeActivity runs a whole workflow DAG for legacyWorkflowExecution, with
+// the semantics of the time: a node's inputs are the workflow inputs, the
+// outputs of its dependencies and its `with`, without expressions. New
+// runs use RunNodeActivity.
+func NodeActivity(
+	ctx context.Context,
+	projectID string,
+	workflowID string,
+	inputs map[string]interface{},
+) (map[string]interface{}, error) {
+
+	if runner.WorkflowStore == nil {
+		return nil, fmt.Errorf("workflow store not set")
+	}
+	if runner.ModuleRegistry == nil {
+		return nil, fmt.Errorf("module registry not set")
+	}
+
+	wfInputs := runner.WorkflowInputs(inputs)
+
+	wf, err := runner.WorkflowStore.Get(ctx, projectID, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	graph := dag.Build(wf.Def)
+
+	done := map[dag.NodeID]bool{}
+	stepOutputs := map[string]map[string]interface{}{}
+
+	for len(done) < len(graph.Nodes) {
+		ready := dag.Ready(*graph, done)
+		if len(ready) == 0 {
+			return nil, fmt.Errorf("deadlock detected in DAG")
+		}
+
+		for _, id := range ready {
+			node := graph.Nodes[id]
+
+			actCtx := executor.WithProjectID(ctx, projectID)
+			actCtx = executor.WithStepOutputs(actCtx, stepOutputs)
+
+			mod, err := runner.ModuleRegistry.GetModule(actCtx, projectID, node.Uses, "")
+			if err != nil {
+				return nil, err
+			}
+			execImpl, ok := executor.All()[mod.Runtime]
+			if !ok {
+				return nil, fmt.Errorf("executor not found: %s", mod.Runtime)
+			}
+
+			nodeInputs := map[string]interface{}{}
+			for k, v := range wfInputs {
+				nodeInputs[k] = v
+			}
+			for _, dep := range node.Depends {
+				for k, v := range stepOutputs[string(dep)] {
+					nodeInputs[k] = v
+				}
+			}
+			for k, v := range node.With {
+				nodeInputs[k] = v
+			}
+
+			out, err := execImpl.Execute(actCtx, node, nodeInputs)
+			if err != nil {
+				return nil, err
+			}
+			stepOutputs[string(id)] = out
+			done[id] = true
+		}
+	}
+
+	return runner.FlattenStepOutputs(stepOutputs), nil
+}
@@ -0,0 +1,32 @@
+package temporal
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+// activityOptions are the options RunNodeActivity runs with under p. The
+// embedded engine applies the same timeout and retry policy.
+func activityOptions(p runner.NodePolicy) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: p.Timeout,
+		// Node activities heartbeat so cancellation reaches running executors
+		HeartbeatTimeout:    30 * time.Second,
+		WaitForCancellation: true,
+		RetryPolicy:         retryPolicy(p.Retry),
+	}
+}
+
+func retryPolicy(r runner.RetryPolicy) *temporal.RetryPolicy {
+	return &temporal.RetryPolicy{
+		InitialInterval:        r.InitialInterval,
+		BackoffCoefficient:     r.BackoffCoefficient,
+		MaximumInterval:        r.MaximumInterval,
+		MaximumAttempts:        r.MaximumAttempts,
+		NonRetryableErrorTypes: r.NonRetryableErrorTypes,
+	}
+}
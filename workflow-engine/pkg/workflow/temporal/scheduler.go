@@ -0,0 +1,236 @@
+package temporal
+
+import (
+	"fmt"
+	"sort"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+// dagScheduler schedules one RunNodeActivity per DAG node. Every node whose
+// dependencies are complete is dispatched immediately, so independent
+// branches run in parallel and each node is retried on its own.
+//
+// A node with for_each fans out into one activity per list element.
+// Nodes whose `if` evaluates to false, or that inherit a skip from a
+// dependency, are marked skipped and count as completed.
+//
+// While paused, no new nodes are dispatched but running ones are allowed to
+// finish; on resume the scheduler continues from the same frontier.
+type dagScheduler struct {
+	*runner.DAGState
+
+	executionID string
+	projectID   string
+
+	// policies holds each node's resolved timeout and retry policy
+	policies map[string]runner.NodePolicy
+
+	running map[dag.NodeID]bool
+	// instances holds in-flight for_each instance IDs, e.g. "assign[2]"
+	instances map[string]bool
+	paused    bool
+	err       error
+
+	// version is bumped on every state change the run loop waits for
+	version int
+}
+
+func newDAGScheduler(
+	graph *dag.Graph,
+	executionID string,
+	projectID string,
+	inputs map[string]interface{},
+) *dagScheduler {
+	return &dagScheduler{
+		DAGState:    runner.NewDAGState(graph, inputs),
+		executionID: executionID,
+		projectID:   projectID,
+		running:     map[dag.NodeID]bool{},
+		instances:   map[string]bool{},
+	}
+}
+
+// run drives the DAG to completion.
+func (s *dagScheduler) run(ctx workflow.Context) error {
+	s.listenForSignals(ctx)
+
+	for len(s.Completed) < len(s.Graph.Nodes) {
+		if !s.paused {
+			s.dispatchReady(ctx)
+
+			if s.err != nil {
+				return s.err
+			}
+			if len(s.Completed) == len(s.Graph.Nodes) {
+				break
+			}
+			if len(s.running) == 0 {
+				return fmt.Errorf("deadlock detected in DAG")
+			}
+		}
+
+		seen := s.version
+		if err := workflow.Await(ctx, func() bool {
+			return s.err != nil || s.version != seen
+		}); err != nil {
+			return err
+		}
+		if s.err != nil {
+			return s.err
+		}
+	}
+
+	return nil
+}
+
+// dispatchReady starts every ready node. Skipping a node can make its
+// dependents ready, so it repeats until no further node was skipped.
+func (s *dagScheduler) dispatchReady(ctx workflow.Context) {
+	for s.dispatchPass(ctx) {
+	}
+}
+
+func (s *dagScheduler) dispatchPass(ctx workflow.Context) bool {
+	skippedAny := false
+
+	for _, id := range dag.Ready(*s.Graph, s.Completed) {
+		if s.running[id] {
+			continue
+		}
+
+		reason, skip, err := s.SkipReason(s.Graph.Nodes[id])
+		if err != nil {
+			s.err = fmt.Errorf("node %s: %w", id, err)
+			return false
+		}
+		if skip {
+			s.markSkipped(ctx, id, reason)
+			skippedAny = true
+			continue
+		}
+
+		node := s.Graph.Nodes[id]
+		if node.ForEach != "" {
+			items, err := s.ForEachItems(node)
+			if err != nil {
+				s.err = fmt.Errorf("node %s: %w", id, err)
+				return false
+			}
+			s.dispatchForEach(ctx, node, items)
+			continue
+		}
+
+		s.running[id] = true
+
+		actx := workflow.WithActivityOptions(ctx, activityOptions(s.policy(id)))
+		future := workflow.ExecuteActivity(actx, RunNodeActivity, s.nodeRequest(node))
+		workflow.Go(ctx, func(gctx workflow.Context) {
+			var out map[string]interface{}
+			err := future.Get(gctx, &out)
+
+			delete(s.running, id)
+			s.version++
+
+			if err != nil {
+				if s.err == nil {
+					s.err = fmt.Errorf("node %s failed: %w", id, err)
+				}
+				return
+			}
+
+			s.StepOutputs[string(id)] = out
+			s.Completed[id] = true
+		})
+	}
+
+	return skippedAny
+}
+
+func (s *dagScheduler) nodeRequest(node *dag.Node) runner.NodeRequest {
+	return runner.NodeRequest{
+		ExecutionID: s.executionID,
+		ProjectID:   s.projectID,
+		Node:        node,
+		Inputs:      s.Inputs,
+		StepOutputs: s.StepOutputs,
+	}
+}
+
+// policy returns the policy node id runs with.
+func (s *dagScheduler) policy(id dag.NodeID) runner.NodePolicy {
+	if p, ok := s.policies[string(id)]; ok {
+		return p
+	}
+	return runner.DefaultNodePolicy()
+}
+
+func (s *dagScheduler) markSkipped(ctx workflow.Context, id dag.NodeID, reason string) {
+	s.MarkSkipped(id)
+	s.version++
+
+	// Recording the skip is bookkeeping; the run continues if it fails
+	_ = workflow.ExecuteActivity(
+		ctx,
+		runner.MarkNodeSkipped,
+		s.executionID,
+		string(id),
+		reason,
+	).Get(ctx, nil)
+}
+
+func (s *dagScheduler) listenForSignals(ctx workflow.Context) {
+	pauseCh := workflow.GetSignalChannel(ctx, runner.SignalPause)
+	resumeCh := workflow.GetSignalChannel(ctx, runner.SignalResume)
+
+	workflow.Go(ctx, func(gctx workflow.Context) {
+		selector := workflow.NewSelector(gctx)
+		selector.AddReceive(pauseCh, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(gctx, nil)
+			s.setPaused(true)
+		})
+		selector.AddReceive(resumeCh, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(gctx, nil)
+			s.setPaused(false)
+		})
+
+		for {
+			selector.Select(gctx)
+		}
+	})
+}
+
+func (s *dagScheduler) setPaused(paused bool) {
+	if s.paused != paused {
+		s.paused = paused
+		s.version++
+	}
+}
+
+// drain blocks until every in-flight node has settled, e.g. after the
+// workflow was cancelled and activities are observing the cancellation.
+func (s *dagScheduler) drain(ctx workflow.Context) {
+	dctx, _ := workflow.NewDisconnectedContext(ctx)
+	_ = workflow.Await(dctx, func() bool {
+		return len(s.running) == 0
+	})
+}
+
+// unfinished returns the sorted IDs of nodes, and of for_each instances,
+// that did not complete.
+func (s *dagScheduler) unfinished() []string {
+	ids := []string{}
+	for id := range s.Graph.Nodes {
+		if !s.Completed[id] {
+			ids = append(ids, string(id))
+		}
+	}
+	for id := range s.instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
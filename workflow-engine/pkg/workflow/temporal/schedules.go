@@ -0,0 +1,278 @@
+package temporal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// Overlap policies: what a schedule does when it fires while its previous
+// run is still going.
+const (
+	// OverlapSkip drops the new run
+	OverlapSkip = "skip"
+	// OverlapBuffer starts one new run after the current one finishes
+	OverlapBuffer = "buffer"
+	// OverlapAllow starts the new run alongside the current one
+	OverlapAllow = "allow"
+)
+
+// scheduleWorkflowMemo holds the workflow ID in a schedule's memo, so
+// schedules can be listed per workflow without describing each of them.
+const scheduleWorkflowMemo = "workflow_id"
+
+// scheduleCronMemo keeps the cron expressions as written on the action:
+// the server compiles them into calendar specs and does not return them.
+const scheduleCronMemo = "cron"
+
+// Schedule starts a workflow on a cron expression or a fixed interval. It
+// is stored as a Temporal Schedule in the project's namespace.
+type Schedule struct {
+	ID         string
+	WorkflowID string
+	// Cron holds standard five-field cron expressions; Interval is used
+	// when it is empty
+	Cron     []string
+	Interval time.Duration
+	// Timezone is an IANA name the cron expressions are evaluated in;
+	// empty means UTC
+	Timezone string
+	// Jitter delays each run by a random duration up to this value
+	Jitter  time.Duration
+	Overlap string
+	Paused  bool
+	Note    string
+	Inputs  map[string]interface{}
+
+	// set by Describe
+	NextRunTimes []time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func overlapPolicy(overlap string) (enumspb.ScheduleOverlapPolicy, error) {
+	switch overlap {
+	case "", OverlapSkip:
+		return enumspb.SCHEDULE_OVERLAP_POLICY_SKIP, nil
+	case OverlapBuffer:
+		return enumspb.SCHEDULE_OVERLAP_POLICY_BUFFER_ONE, nil
+	case OverlapAllow:
+		return enumspb.SCHEDULE_OVERLAP_POLICY_ALLOW_ALL, nil
+	}
+	return 0, fmt.Errorf("invalid overlap policy %q: use %s, %s or %s", overlap, OverlapSkip, OverlapBuffer, OverlapAllow)
+}
+
+func overlapName(p enumspb.ScheduleOverlapPolicy) string {
+	switch p {
+	case enumspb.SCHEDULE_OVERLAP_POLICY_BUFFER_ONE:
+		return OverlapBuffer
+	case enumspb.SCHEDULE_OVERLAP_POLICY_ALLOW_ALL:
+		return OverlapAllow
+	}
+	return OverlapSkip
+}
+
+// ValidateSchedule checks the parts of a schedule Temporal would only
+// reject on create.
+func ValidateSchedule(s *Schedule) error {
+	if s.WorkflowID == "" {
+		return fmt.Errorf("workflow_id is required")
+	}
+	if len(s.Cron) == 0 && s.Interval <= 0 {
+		return fmt.Errorf("a schedule needs a cron expression or an interval")
+	}
+	if len(s.Cron) > 0 && s.Interval > 0 {
+		return fmt.Errorf("cron and interval are mutually exclusive")
+	}
+	if s.Interval > 0 && s.Interval < time.Second {
+		return fmt.Errorf("interval must be at least 1s")
+	}
+	if s.Jitter < 0 {
+		return fmt.Errorf("jitter must not be negative")
+	}
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+		}
+	}
+	_, err := overlapPolicy(s.Overlap)
+	return err
+}
+
+func scheduleSpec(s *Schedule) *client.ScheduleSpec {
+	spec := &client.ScheduleSpec{
+		CronExpressions: s.Cron,
+		Jitter:          s.Jitter,
+		TimeZoneName:    s.Timezone,
+	}
+	if s.Interval > 0 {
+		spec.Intervals = []client.ScheduleIntervalSpec{{Every: s.Interval}}
+	}
+	return spec
+}
+
+// scheduleAction starts ScheduledWorkflowExecution; Temporal appends the
+// fire time to the workflow ID, so every run gets its own ID.
+func scheduleAction(projectID string, s *Schedule) *client.ScheduleWorkflowAction {
+	return &client.ScheduleWorkflowAction{
+		ID:        fmt.Sprintf("%s:%s:schedule:%s", projectID, s.WorkflowID, s.ID),
+		Workflow:  ScheduledWorkflowExecution,
+		Args:      []interface{}{projectID, s.WorkflowID, s.ID, s.Inputs},
+		TaskQueue: TaskQueue,
+		Memo:      map[string]interface{}{scheduleCronMemo: s.Cron},
+	}
+}
+
+func CreateSchedule(ctx context.Context, c *Client, projectID string, s *Schedule) error {
+	overlap, err := overlapPolicy(s.Overlap)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Client.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID:      s.ID,
+		Spec:    *scheduleSpec(s),
+		Action:  scheduleAction(projectID, s),
+		Overlap: overlap,
+		Paused:  s.Paused,
+		Note:    s.Note,
+		Memo:    map[string]interface{}{scheduleWorkflowMemo: s.WorkflowID},
+	})
+	return err
+}
+
+// UpdateSchedule replaces a schedule's spec, inputs and overlap policy.
+// Pausing is left to PauseSchedule and ResumeSchedule.
+func UpdateSchedule(ctx context.Context, c *Client, projectID string, s *Schedule) error {
+	overlap, err := overlapPolicy(s.Overlap)
+	if err != nil {
+		return err
+	}
+
+	h := c.Client.ScheduleClient().GetHandle(ctx, s.ID)
+	return h.Update(ctx, client.ScheduleUpdateOptions{
+		DoUpdate: func(in client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+			sched := in.Description.Schedule
+			sched.Spec = scheduleSpec(s)
+			sched.Action = scheduleAction(projectID, s)
+			if sched.Policy == nil {
+				sched.Policy = &client.SchedulePolicies{}
+			}
+			sched.Policy.Overlap = overlap
+			return &client.ScheduleUpdate{Schedule: &sched}, nil
+		},
+	})
+}
+
+func DescribeSchedule(ctx context.Context, c *Client, scheduleID string) (*Schedule, error) {
+	desc, err := c.Client.ScheduleClient().GetHandle(ctx, scheduleID).Describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fromScheduleDescription(scheduleID, desc)
+}
+
+// ListSchedules returns the project's schedules, or only those of
+// workflowID when it is set.
+func ListSchedules(ctx context.Context, c *Client, workflowID string) ([]*Schedule, error) {
+	it, err := c.Client.ScheduleClient().List(ctx, client.ScheduleListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*Schedule
+	for it.HasNext() {
+		entry, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if workflowID != "" && memoString(entry.Memo, scheduleWorkflowMemo) != workflowID {
+			continue
+		}
+
+		s, err := DescribeSchedule(ctx, c, entry.ID)
+		if err != nil {
+			// deleted between listing and describing
+			continue
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func DeleteSchedule(ctx context.Context, c *Client, scheduleID string) error {
+	return c.Client.ScheduleClient().GetHandle(ctx, scheduleID).Delete(ctx)
+}
+
+func PauseSchedule(ctx context.Context, c *Client, scheduleID, note string) error {
+	return c.Client.ScheduleClient().GetHandle(ctx, scheduleID).
+		Pause(ctx, client.SchedulePauseOptions{Note: note})
+}
+
+func ResumeSchedule(ctx context.Context, c *Client, scheduleID, note string) error {
+	return c.Client.ScheduleClient().GetHandle(ctx, scheduleID).
+		Unpause(ctx, client.ScheduleUnpauseOptions{Note: note})
+}
+
+func fromScheduleDescription(scheduleID string, desc *client.ScheduleDescription) (*Schedule, error) {
+	s := &Schedule{
+		ID:           scheduleID,
+		NextRunTimes: desc.Info.NextActionTimes,
+		CreatedAt:    desc.Info.CreatedAt,
+		UpdatedAt:    desc.Info.LastUpdateAt,
+	}
+
+	if spec := desc.Schedule.Spec; spec != nil {
+		s.Cron = spec.CronExpressions
+		s.Jitter = spec.Jitter
+		s.Timezone = spec.TimeZoneName
+		if len(spec.Intervals) > 0 {
+			s.Interval = spec.Intervals[0].Every
+		}
+	}
+	if desc.Schedule.Policy != nil {
+		s.Overlap = overlapName(desc.Schedule.Policy.Overlap)
+	}
+	if desc.Schedule.State != nil {
+		s.Paused = desc.Schedule.State.Paused
+		s.Note = desc.Schedule.State.Note
+	}
+
+	action, ok := desc.Schedule.Action.(*client.ScheduleWorkflowAction)
+	if !ok {
+		return nil, fmt.Errorf("schedule %s does not start a workflow", scheduleID)
+	}
+	// Described arguments and memo are still encoded; the arguments are
+	// projectID, workflowID, scheduleID, inputs
+	dc := converter.GetDefaultDataConverter()
+	if p, ok := action.Memo[scheduleCronMemo].(*commonpb.Payload); ok && len(s.Cron) == 0 {
+		_ = dc.FromPayload(p, &s.Cron)
+	}
+	if len(action.Args) == 4 {
+		if p, ok := action.Args[1].(*commonpb.Payload); ok {
+			_ = dc.FromPayload(p, &s.WorkflowID)
+		}
+		if p, ok := action.Args[3].(*commonpb.Payload); ok {
+			_ = dc.FromPayload(p, &s.Inputs)
+		}
+	}
+	return s, nil
+}
+
+func memoString(memo *commonpb.Memo, key string) string {
+	if memo == nil {
+		return ""
+	}
+	p, ok := memo.Fields[key]
+	if !ok {
+		return ""
+	}
+	var v string
+	_ = converter.GetDefaultDataConverter().FromPayload(p, &v)
+	return v
+}
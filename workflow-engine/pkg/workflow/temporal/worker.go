@@ -3,15 +3,29 @@ package temporal
 import (
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
 )
 
+// TaskQueue is the queue workflows are started on.
+const TaskQueue = "workflow-task-queue"
+
 func StartWorker(c client.Client, taskQueue string) error {
 	w := worker.New(c, taskQueue, worker.Options{})
 
 	w.RegisterWorkflow(WorkflowExecution)
+	w.RegisterWorkflow(ScheduledWorkflowExecution)
+	w.RegisterActivity(runner.LoadExecutionDefinition)
+	w.RegisterActivity(runner.ResolveNodePolicies)
+	w.RegisterActivity(CreateScheduledExecution)
+	w.RegisterActivity(RunNodeActivity)
 	w.RegisterActivity(NodeActivity)
-	w.RegisterActivity(MarkExecutionSucceeded)
-	w.RegisterActivity(MarkExecutionFailed)
+	w.RegisterActivity(runner.MarkExecutionSucceeded)
+	w.RegisterActivity(runner.MarkExecutionFailed)
+	w.RegisterActivity(runner.MarkExecutionCancelled)
+	w.RegisterActivity(runner.MarkNodeSkipped)
+	w.RegisterActivity(runner.MarkFanOutStarted)
+	w.RegisterActivity(runner.MarkFanOutCompleted)
 
 	return w.Run(worker.InterruptCh())
 }
@@ -1,10 +1,15 @@
 package temporal
 
 import (
+	"errors"
 	"time"
 
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
 )
 
 func WorkflowExecution(
@@ -13,36 +18,77 @@ func WorkflowExecution(
 	projectID string,
 	workflowID string,
 	inputs map[string]interface{},
+	retry *runner.RetryState,
 ) error {
 
-	logger := workflow.GetLogger(ctx)
-
-	ao := workflow.ActivityOptions{
-		StartToCloseTimeout: time.Minute,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    5 * time.Second,
-			BackoffCoefficient: 2,
-			MaximumAttempts:    5,
-		},
+	// Runs started before nodes were scheduled one by one run the whole
+	// DAG in one activity
+	if workflow.GetVersion(ctx, "per-node-activities", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return legacyWorkflowExecution(ctx, executionID, projectID, workflowID, inputs)
 	}
-	ctx = workflow.WithActivityOptions(ctx, ao)
 
-	var outputs map[string]interface{}
+	logger := workflow.GetLogger(ctx)
+
+	ctx = workflow.WithActivityOptions(ctx, activityOptions(runner.DefaultNodePolicy()))
 
+	var def api.Definition
 	err := workflow.ExecuteActivity(
 		ctx,
-		NodeActivity,
+		runner.LoadExecutionDefinition,
 		projectID,
 		workflowID,
-		inputs,
-	).Get(ctx, &outputs)
+		executionID,
+	).Get(ctx, &def)
+
+	var policies map[string]runner.NodePolicy
+	if err == nil {
+		err = workflow.ExecuteActivity(
+			ctx,
+			runner.ResolveNodePolicies,
+			projectID,
+			&def,
+		).Get(ctx, &policies)
+	}
+
+	var sched *dagScheduler
+	var outputs map[string]interface{}
+	if err == nil {
+		sched = newDAGScheduler(dag.Build(&def), executionID, projectID, inputs)
+		sched.policies = policies
+		sched.Seed(retry)
+		err = sched.run(ctx)
+	}
+	if err == nil {
+		outputs, err = sched.Outputs(&def)
+	}
+
+	if errors.Is(ctx.Err(), workflow.ErrCanceled) {
+		logger.Info("workflow cancelled")
+
+		var unfinished []string
+		if sched != nil {
+			sched.drain(ctx)
+			unfinished = sched.unfinished()
+		}
+
+		// The workflow context is cancelled; bookkeeping needs its own
+		dctx, _ := workflow.NewDisconnectedContext(ctx)
+		_ = workflow.ExecuteActivity(
+			dctx,
+			runner.MarkExecutionCancelled,
+			executionID,
+			unfinished,
+		).Get(dctx, nil)
+
+		return workflow.ErrCanceled
+	}
 
 	if err != nil {
 		logger.Error("workflow failed", "error", err)
 
 		_ = workflow.ExecuteActivity(
 			ctx,
-			MarkExecutionFailed,
+			runner.MarkExecutionFailed,
 			executionID,
 			err.Error(),
 		).Get(ctx, nil)
@@ -52,10 +98,51 @@ func WorkflowExecution(
 
 	_ = workflow.ExecuteActivity(
 		ctx,
-		MarkExecutionSucceeded,
+		runner.MarkExecutionSucceeded,
 		executionID,
 		outputs,
 	).Get(ctx, nil)
 
 	return nil
 }
+
+// ScheduledWorkflowExecution is started by a schedule. It records the run
+// as an execution, then runs the workflow like WorkflowExecution.
+func ScheduledWorkflowExecution(
+	ctx workflow.Context,
+	projectID string,
+	workflowID string,
+	scheduleID string,
+	inputs map[string]interface{},
+) error {
+
+	info := workflow.GetInfo(ctx)
+
+	actx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    5 * time.Second,
+			BackoffCoefficient: 2,
+			MaximumAttempts:    5,
+		},
+	})
+
+	var run ScheduledRun
+	err := workflow.ExecuteActivity(
+		actx,
+		CreateScheduledExecution,
+		ScheduledRunRequest{
+			ProjectID:          projectID,
+			WorkflowID:         workflowID,
+			ScheduleID:         scheduleID,
+			TemporalWorkflowID: info.WorkflowExecution.ID,
+			TemporalRunID:      info.WorkflowExecution.RunID,
+			Inputs:             inputs,
+		},
+	).Get(ctx, &run)
+	if err != nil {
+		return err
+	}
+
+	return WorkflowExecution(ctx, run.ExecutionID, projectID, workflowID, run.Inputs, nil)
+}
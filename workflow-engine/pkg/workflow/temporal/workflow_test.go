@@ -0,0 +1,520 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/parser"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/runner"
+)
+
+const testExecutionID = "exec-1"
+
+// workflowTest runs WorkflowExecution over a definition with every
+// activity mocked. RunNodeActivity returns {"node": <id>} at once unless
+// a test sets up the node with onNode.
+type workflowTest struct {
+	t     *testing.T
+	env   *testsuite.TestWorkflowEnvironment
+	start time.Time
+
+	mu sync.Mutex
+	// started lists node and for_each instance IDs in the order they were
+	// dispatched, startedAt when, on the workflow clock
+	started   []string
+	startedAt map[string]time.Duration
+	// timeouts holds the StartToCloseTimeout each node ran with
+	timeouts    map[string]time.Duration
+	inflight    int
+	maxInflight int
+
+	skipped   map[string]string
+	fanOuts   map[string]fanOut
+	succeeded map[string]interface{}
+	failed    string
+	cancelled []string
+}
+
+type fanOut struct {
+	items   int
+	outputs map[string]interface{}
+	errMsg  string
+}
+
+func newWorkflowTest(t *testing.T, yaml string, policies map[string]runner.NodePolicy) *workflowTest {
+	t.Helper()
+
+	def, err := parser.ParseWorkflow([]byte(yaml))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	wt := &workflowTest{
+		t:         t,
+		env:       suite.NewTestWorkflowEnvironment(),
+		startedAt: map[string]time.Duration{},
+		timeouts:  map[string]time.Duration{},
+		skipped:   map[string]string{},
+		fanOuts:   map[string]fanOut{},
+	}
+	env := wt.env
+	wt.start = env.Now()
+
+	// nodes are not retried unless a test says so
+	resolved := map[string]runner.NodePolicy{}
+	for id := range def.Nodes {
+		p := runner.DefaultNodePolicy()
+		p.Retry.MaximumAttempts = 1
+		resolved[id] = p
+	}
+	for id, p := range policies {
+		resolved[id] = p
+	}
+
+	env.OnActivity(runner.LoadExecutionDefinition, mock.Anything, "p1", "wf1", testExecutionID).Return(def, nil)
+	env.OnActivity(runner.ResolveNodePolicies, mock.Anything, "p1", mock.Anything).Return(resolved, nil)
+
+	env.OnActivity(runner.MarkNodeSkipped, mock.Anything, testExecutionID, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, executionID, nodeID, reason string) error {
+			wt.mu.Lock()
+			defer wt.mu.Unlock()
+			wt.skipped[nodeID] = reason
+			return nil
+		})
+	env.OnActivity(runner.MarkFanOutStarted, mock.Anything, testExecutionID, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, executionID, nodeID string, items int) error {
+			wt.mu.Lock()
+			defer wt.mu.Unlock()
+			wt.fanOuts[nodeID] = fanOut{items: items}
+			return nil
+		})
+	env.OnActivity(runner.MarkFanOutCompleted, mock.Anything, testExecutionID, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, executionID, nodeID string, outputs map[string]interface{}, errMsg string) error {
+			wt.mu.Lock()
+			defer wt.mu.Unlock()
+			f := wt.fanOuts[nodeID]
+			f.outputs, f.errMsg = outputs, errMsg
+			wt.fanOuts[nodeID] = f
+			return nil
+		})
+
+	env.OnActivity(runner.MarkExecutionSucceeded, mock.Anything, testExecutionID, mock.Anything).Return(
+		func(ctx context.Context, executionID string, outputs map[string]interface{}) error {
+			wt.mu.Lock()
+			defer wt.mu.Unlock()
+			wt.succeeded = outputs
+			return nil
+		})
+	env.OnActivity(runner.MarkExecutionFailed, mock.Anything, testExecutionID, mock.Anything).Return(
+		func(ctx context.Context, executionID, errMsg string) error {
+			wt.mu.Lock()
+			defer wt.mu.Unlock()
+			wt.failed = errMsg
+			return nil
+		})
+	env.OnActivity(runner.MarkExecutionCancelled, mock.Anything, testExecutionID, mock.Anything).Return(
+		func(ctx context.Context, executionID string, unfinished []string) error {
+			wt.mu.Lock()
+			defer wt.mu.Unlock()
+			wt.cancelled = unfinished
+			return nil
+		})
+
+	env.SetOnActivityStartedListener(func(info *activity.Info, ctx context.Context, args converter.EncodedValues) {
+		if info.ActivityType.Name != "RunNodeActivity" {
+			return
+		}
+		var req runner.NodeRequest
+		if err := args.Get(&req); err != nil {
+			t.Errorf("decoding node request: %v", err)
+			return
+		}
+		id := nodeID(req)
+
+		wt.mu.Lock()
+		defer wt.mu.Unlock()
+		wt.started = append(wt.started, id)
+		wt.startedAt[id] = env.Now().Sub(wt.start)
+		wt.timeouts[id] = info.StartToCloseTimeout
+		wt.inflight++
+		if wt.inflight > wt.maxInflight {
+			wt.maxInflight = wt.inflight
+		}
+	})
+	env.SetOnActivityCompletedListener(func(info *activity.Info, result converter.EncodedValue, err error) {
+		if info.ActivityType.Name != "RunNodeActivity" {
+			return
+		}
+		wt.mu.Lock()
+		defer wt.mu.Unlock()
+		wt.inflight--
+	})
+
+	return wt
+}
+
+func nodeID(req runner.NodeRequest) string {
+	if req.Iteration != nil {
+		return runner.InstanceID(req.Node.ID, req.Iteration.Index)
+	}
+	return string(req.Node.ID)
+}
+
+// onNode makes the node or for_each instance id take d on the workflow
+// clock and return run's result. Call it before run.
+func (wt *workflowTest) onNode(id string, d time.Duration, run func(req runner.NodeRequest) (map[string]interface{}, error)) {
+	matches := mock.MatchedBy(func(req runner.NodeRequest) bool { return nodeID(req) == id })
+	wt.env.OnActivity(RunNodeActivity, mock.Anything, matches).After(d).Return(
+		func(ctx context.Context, req runner.NodeRequest) (map[string]interface{}, error) {
+			return run(req)
+		})
+}
+
+// run executes the workflow and returns its error.
+func (wt *workflowTest) run(inputs map[string]interface{}) error {
+	wt.env.OnActivity(RunNodeActivity, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, req runner.NodeRequest) (map[string]interface{}, error) {
+			return map[string]interface{}{"node": nodeID(req)}, nil
+		})
+
+	wt.env.ExecuteWorkflow(WorkflowExecution, testExecutionID, "p1", "wf1", inputs, (*runner.RetryState)(nil))
+	if !wt.env.IsWorkflowCompleted() {
+		wt.t.Fatal("workflow did not complete")
+	}
+	return wt.env.GetWorkflowError()
+}
+
+func (wt *workflowTest) assertStarted(want ...string) {
+	wt.t.Helper()
+	if !reflect.DeepEqual(wt.started, want) {
+		wt.t.Errorf("started %v, want %v", wt.started, want)
+	}
+}
+
+func returns(out map[string]interface{}) func(runner.NodeRequest) (map[string]interface{}, error) {
+	return func(runner.NodeRequest) (map[string]interface{}, error) { return out, nil }
+}
+
+func fails(msg string) func(runner.NodeRequest) (map[string]interface{}, error) {
+	return func(runner.NodeRequest) (map[string]interface{}, error) { return nil, errors.New(msg) }
+}
+
+func TestWorkflowDispatchesEachNode(t *testing.T) {
+	policy := runner.DefaultNodePolicy()
+	policy.Timeout = 2 * time.Minute
+	wt := newWorkflowTest(t, `
+nodes:
+  a:
+    uses: test.a
+  b:
+    uses: test.b
+  c:
+    uses: test.c
+    depends_on: [a, b]
+`, map[string]runner.NodePolicy{"b": policy})
+
+	wt.onNode("a", time.Minute, returns(map[string]interface{}{"id": "a1"}))
+	wt.onNode("b", 5*time.Minute, returns(map[string]interface{}{"id": "b1"}))
+
+	var stepOutputs map[string]map[string]interface{}
+	wt.onNode("c", 0, func(req runner.NodeRequest) (map[string]interface{}, error) {
+		stepOutputs = req.StepOutputs
+		return map[string]interface{}{"id": "c1"}, nil
+	})
+
+	if err := wt.run(nil); err != nil {
+		t.Fatalf("workflow: %v", err)
+	}
+
+	// independent nodes run side by side; c waits for both
+	if wt.startedAt["a"] != 0 || wt.startedAt["b"] != 0 || wt.startedAt["c"] != 5*time.Minute {
+		t.Errorf("started at %v", wt.startedAt)
+	}
+	if wt.maxInflight != 2 {
+		t.Errorf("%d nodes ran at once, want 2", wt.maxInflight)
+	}
+	if wt.timeouts["a"] != time.Minute || wt.timeouts["b"] != 2*time.Minute {
+		t.Errorf("timeouts = %v, want each node's policy", wt.timeouts)
+	}
+	if stepOutputs["a"]["id"] != "a1" || stepOutputs["b"]["id"] != "b1" {
+		t.Errorf("c saw step outputs %v", stepOutputs)
+	}
+
+	want := map[string]interface{}{
+		"a": map[string]interface{}{"id": "a1"},
+		"b": map[string]interface{}{"id": "b1"},
+		"c": map[string]interface{}{"id": "c1"},
+	}
+	if !reflect.DeepEqual(wt.succeeded, want) {
+		t.Errorf("outputs = %v, want %v", wt.succeeded, want)
+	}
+}
+
+func TestWorkflowNodeFailure(t *testing.T) {
+	wt := newWorkflowTest(t, `
+nodes:
+  a:
+    uses: test.a
+  b:
+    uses: test.b
+  c:
+    uses: test.c
+    depends_on: [a]
+`, nil)
+
+	wt.onNode("a", time.Minute, fails("boom"))
+	wt.onNode("b", time.Hour, returns(nil))
+
+	err := wt.run(nil)
+	if err == nil {
+		t.Fatal("workflow succeeded, want the node's failure")
+	}
+
+	wt.assertStarted("a", "b")
+	if !strings.HasPrefix(wt.failed, "node a failed: ") || !strings.Contains(wt.failed, "boom") {
+		t.Errorf("execution failed with %q", wt.failed)
+	}
+	if wt.succeeded != nil {
+		t.Error("a failed execution was marked succeeded")
+	}
+}
+
+func TestWorkflowRetriesNode(t *testing.T) {
+	policy := runner.DefaultNodePolicy()
+	policy.Retry.InitialInterval = time.Second
+	policy.Retry.MaximumAttempts = 3
+	wt := newWorkflowTest(t, `
+nodes:
+  a:
+    uses: test.a
+`, map[string]runner.NodePolicy{"a": policy})
+
+	attempts := 0
+	wt.onNode("a", 0, func(runner.NodeRequest) (map[string]interface{}, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("flaky")
+		}
+		return map[string]interface{}{"ok": true}, nil
+	})
+
+	if err := wt.run(nil); err != nil {
+		t.Fatalf("workflow: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("node ran %d times, want 3", attempts)
+	}
+}
+
+func TestWorkflowSkipPropagation(t *testing.T) {
+	wt := newWorkflowTest(t, `
+nodes:
+  check:
+    uses: test.check
+  create:
+    uses: test.create
+    depends_on: [check]
+    if: steps.check.outputs.exists == false
+  notify:
+    uses: test.notify
+    depends_on: [create]
+  report:
+    uses: test.report
+    depends_on: [create]
+    if: steps.create.status == 'SKIPPED'
+`, nil)
+
+	wt.onNode("check", 0, returns(map[string]interface{}{"exists": true}))
+
+	if err := wt.run(nil); err != nil {
+		t.Fatalf("workflow: %v", err)
+	}
+
+	wt.assertStarted("check", "report")
+	want := map[string]string{
+		"create": `condition "steps.check.outputs.exists == false" is false`,
+		"notify": "dependency skipped",
+	}
+	if !reflect.DeepEqual(wt.skipped, want) {
+		t.Errorf("skipped %v, want %v", wt.skipped, want)
+	}
+	if wt.succeeded == nil {
+		t.Error("execution was not marked succeeded")
+	}
+}
+
+func TestWorkflowPauseResume(t *testing.T) {
+	wt := newWorkflowTest(t, `
+nodes:
+  a:
+    uses: test.a
+  b:
+    uses: test.b
+    depends_on: [a]
+`, nil)
+
+	wt.onNode("a", 10*time.Minute, returns(map[string]interface{}{"id": "a1"}))
+
+	wt.env.RegisterDelayedCallback(func() {
+		wt.env.SignalWorkflow(runner.SignalPause, nil)
+	}, time.Minute)
+	wt.env.RegisterDelayedCallback(func() {
+		wt.env.SignalWorkflow(runner.SignalResume, nil)
+	}, time.Hour)
+
+	if err := wt.run(nil); err != nil {
+		t.Fatalf("workflow: %v", err)
+	}
+
+	// a finished while paused; b starts from the same frontier on resume
+	wt.assertStarted("a", "b")
+	if got := wt.startedAt["b"]; got != time.Hour {
+		t.Errorf("b started at %v, want on resume at 1h", got)
+	}
+}
+
+func TestWorkflowCancel(t *testing.T) {
+	wt := newWorkflowTest(t, `
+nodes:
+  fast:
+    uses: test.fast
+  slow:
+    uses: test.slow
+  later:
+    uses: test.later
+    depends_on: [slow]
+`, nil)
+
+	wt.onNode("slow", time.Hour, returns(nil))
+	wt.env.RegisterDelayedCallback(wt.env.CancelWorkflow, 10*time.Minute)
+
+	err := wt.run(nil)
+	if !temporal.IsCanceledError(err) {
+		t.Fatalf("workflow error = %v, want cancelled", err)
+	}
+
+	wt.assertStarted("fast", "slow")
+	if want := []string{"later", "slow"}; !reflect.DeepEqual(wt.cancelled, want) {
+		t.Errorf("unfinished nodes %v, want %v", wt.cancelled, want)
+	}
+	if wt.failed != "" || wt.succeeded != nil {
+		t.Error("a cancelled execution was marked failed or succeeded")
+	}
+}
+
+const forEachWorkflow = `
+nodes:
+  assign:
+    uses: test.assign
+    for_each: inputs.users
+    max_parallel: 2
+  summary:
+    uses: test.summary
+    depends_on: [assign]
+`
+
+func TestWorkflowForEach(t *testing.T) {
+	wt := newWorkflowTest(t, forEachWorkflow, nil)
+
+	// instance 0 finishes last
+	durations := []time.Duration{30 * time.Minute, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, d := range durations {
+		wt.onNode(runner.InstanceID("assign", i), d, func(req runner.NodeRequest) (map[string]interface{}, error) {
+			return map[string]interface{}{"user": req.Iteration.Item}, nil
+		})
+	}
+
+	var summarized map[string]interface{}
+	wt.onNode("summary", 0, func(req runner.NodeRequest) (map[string]interface{}, error) {
+		summarized = req.StepOutputs["assign"]
+		return nil, nil
+	})
+
+	err := wt.run(map[string]interface{}{"users": []interface{}{"ann", "bob", "cid", "dan"}})
+	if err != nil {
+		t.Fatalf("workflow: %v", err)
+	}
+
+	wt.assertStarted("assign[0]", "assign[1]", "assign[2]", "assign[3]", "summary")
+	if wt.maxInflight != 2 {
+		t.Errorf("%d instances ran at once, want max_parallel 2", wt.maxInflight)
+	}
+	if got := wt.startedAt["assign[3]"]; got != 10*time.Minute {
+		t.Errorf("assign[3] started at %v, want 10m once a slot freed", got)
+	}
+
+	items := map[string]interface{}{runner.ForEachOutputKey: []interface{}{
+		map[string]interface{}{"user": "ann"},
+		map[string]interface{}{"user": "bob"},
+		map[string]interface{}{"user": "cid"},
+		map[string]interface{}{"user": "dan"},
+	}}
+	f := wt.fanOuts["assign"]
+	if f.items != 4 || f.errMsg != "" || !reflect.DeepEqual(f.outputs, items) {
+		t.Errorf("fan-out = %+v, want 4 items with outputs %v", f, items)
+	}
+	if !reflect.DeepEqual(summarized, items) {
+		t.Errorf("summary saw %v, want %v", summarized, items)
+	}
+}
+
+func TestWorkflowForEachFailure(t *testing.T) {
+	wt := newWorkflowTest(t, forEachWorkflow, nil)
+
+	wt.onNode("assign[0]", time.Minute, fails("boom"))
+	wt.onNode("assign[1]", 10*time.Minute, returns(nil))
+
+	err := wt.run(map[string]interface{}{"users": []interface{}{"ann", "bob", "cid"}})
+	if err == nil {
+		t.Fatal("workflow succeeded, want the instance's failure")
+	}
+
+	// no instance starts after the failure, and the running one finishes
+	wt.assertStarted("assign[0]", "assign[1]")
+	f := wt.fanOuts["assign"]
+	if !strings.HasPrefix(f.errMsg, "assign[0]: ") || f.outputs != nil {
+		t.Errorf("fan-out = %+v, want it failed by assign[0]", f)
+	}
+	if wt.inflight != 0 {
+		t.Errorf("%d instances still running when the node failed", wt.inflight)
+	}
+	if !strings.HasPrefix(wt.failed, "node assign failed: assign[0]: ") {
+		t.Errorf("execution failed with %q", wt.failed)
+	}
+}
+
+func TestWorkflowForEachCancel(t *testing.T) {
+	wt := newWorkflowTest(t, forEachWorkflow, nil)
+
+	for i := 0; i < 3; i++ {
+		wt.onNode(runner.InstanceID("assign", i), time.Hour, returns(nil))
+	}
+	wt.env.RegisterDelayedCallback(wt.env.CancelWorkflow, 10*time.Minute)
+
+	err := wt.run(map[string]interface{}{"users": []interface{}{"ann", "bob", "cid"}})
+	if !temporal.IsCanceledError(err) {
+		t.Fatalf("workflow error = %v, want cancelled", err)
+	}
+
+	// the running instances stay unfinished and no others start
+	wt.assertStarted("assign[0]", "assign[1]")
+	want := []string{"assign", "assign[0]", "assign[1]", "summary"}
+	if !reflect.DeepEqual(wt.cancelled, want) {
+		t.Errorf("unfinished nodes %v, want %v", wt.cancelled, want)
+	}
+	if f := wt.fanOuts["assign"]; f.outputs != nil || f.errMsg != "" {
+		t.Errorf("cancelled fan-out was completed: %+v", f)
+	}
+}
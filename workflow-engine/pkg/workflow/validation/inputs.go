@@ -0,0 +1,171 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/module/contract"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+)
+
+// ErrInvalidInputs is wrapped by the error ValidateInputs returns.
+var ErrInvalidInputs = errors.New("invalid inputs")
+
+// ValidateInputs checks execution inputs against the workflow's declared
+// inputs and returns them with defaults applied. Inputs that are not
+// declared are passed through unchanged. All violations are reported at
+// once, e.g. "invalid inputs: count: expected integer; region: required".
+func ValidateInputs(
+	schema map[string]api.InputSpec,
+	inputs map[string]interface{},
+) (map[string]interface{}, error) {
+
+	out := make(map[string]interface{}, len(inputs))
+	for k, v := range inputs {
+		out[k] = v
+	}
+
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		spec := schema[name]
+
+		v, ok := out[name]
+		if !ok || v == nil {
+			switch {
+			case spec.Default != nil:
+				out[name] = spec.Default
+			case spec.Required:
+				problems = append(problems, name+": required")
+			}
+			continue
+		}
+
+		if err := checkInput(spec, v); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidInputs, strings.Join(problems, "; "))
+	}
+	return out, nil
+}
+
+// validateInputSchema checks the declared inputs themselves when a workflow
+// is registered.
+func validateInputSchema(schema map[string]api.InputSpec) error {
+	for name, spec := range schema {
+		switch spec.Type {
+		case "", "string", "number", "integer", "boolean", "object", "array":
+		default:
+			return fmt.Errorf("input %s: unknown type %q", name, spec.Type)
+		}
+
+		if spec.Pattern != "" {
+			if spec.Type != "" && spec.Type != "string" {
+				return fmt.Errorf("input %s: pattern requires type string", name)
+			}
+			if _, err := compilePattern(spec.Pattern); err != nil {
+				return fmt.Errorf("input %s: invalid pattern: %w", name, err)
+			}
+		}
+
+		for _, e := range spec.Enum {
+			if err := contract.CheckType(spec.Type, e); err != nil {
+				return fmt.Errorf("input %s: enum value %v: %w", name, e, err)
+			}
+		}
+
+		if spec.Default != nil {
+			if err := checkInput(spec, spec.Default); err != nil {
+				return fmt.Errorf("input %s: default: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func checkInput(spec api.InputSpec, v interface{}) error {
+	if err := contract.CheckType(spec.Type, v); err != nil {
+		return err
+	}
+
+	if len(spec.Enum) > 0 {
+		found := false
+		for _, e := range spec.Enum {
+			if sameValue(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("must be one of %v", spec.Enum)
+		}
+	}
+
+	if spec.Pattern != "" {
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("pattern requires a string")
+		}
+		re, err := compilePattern(spec.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("must match %s", spec.Pattern)
+		}
+	}
+
+	return nil
+}
+
+// patterns caches compiled input patterns, as inputs are validated on
+// every start of a workflow.
+var patterns sync.Map // pattern -> *regexp.Regexp
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+func sameValue(a, b interface{}) bool {
+	if fa, ok := number(a); ok {
+		fb, ok := number(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// number normalises the numeric types produced by YAML and JSON decoding.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,164 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+)
+
+func TestValidateInputs(t *testing.T) {
+	schema := map[string]api.InputSpec{
+		"region": {Type: "string", Required: true, Enum: []interface{}{"eu-west-1", "us-east-1"}},
+		"count":  {Type: "integer", Default: 1},
+		"name":   {Type: "string", Pattern: `^[a-z][a-z0-9-]*$`},
+		"tags":   {Type: "array"},
+		"debug":  {Type: "boolean", Default: false},
+		"extra":  {},
+	}
+
+	tests := []struct {
+		name    string
+		inputs  map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:   "defaults are filled in",
+			inputs: map[string]interface{}{"region": "eu-west-1"},
+			want:   map[string]interface{}{"region": "eu-west-1", "count": 1, "debug": false},
+		},
+		{
+			name:   "given values win over defaults",
+			inputs: map[string]interface{}{"region": "us-east-1", "count": float64(3), "debug": true},
+			want:   map[string]interface{}{"region": "us-east-1", "count": float64(3), "debug": true},
+		},
+		{
+			name:   "null takes the default",
+			inputs: map[string]interface{}{"region": "eu-west-1", "count": nil},
+			want:   map[string]interface{}{"region": "eu-west-1", "count": 1, "debug": false},
+		},
+		{
+			name:   "undeclared inputs pass through",
+			inputs: map[string]interface{}{"region": "eu-west-1", "other": "x", "extra": map[string]interface{}{}},
+			want: map[string]interface{}{
+				"region": "eu-west-1", "count": 1, "debug": false,
+				"other": "x", "extra": map[string]interface{}{},
+			},
+		},
+		{
+			name:   "pattern match",
+			inputs: map[string]interface{}{"region": "eu-west-1", "name": "web-1"},
+			want:   map[string]interface{}{"region": "eu-west-1", "name": "web-1", "count": 1, "debug": false},
+		},
+		{
+			name:    "required",
+			inputs:  map[string]interface{}{},
+			wantErr: "invalid inputs: region: required",
+		},
+		{
+			name:    "required and null",
+			inputs:  map[string]interface{}{"region": nil},
+			wantErr: "invalid inputs: region: required",
+		},
+		{
+			name:    "enum",
+			inputs:  map[string]interface{}{"region": "ap-south-1"},
+			wantErr: "invalid inputs: region: must be one of [eu-west-1 us-east-1]",
+		},
+		{
+			name:    "pattern mismatch",
+			inputs:  map[string]interface{}{"region": "eu-west-1", "name": "Web_1"},
+			wantErr: "invalid inputs: name: must match ^[a-z][a-z0-9-]*$",
+		},
+		{
+			name:    "type",
+			inputs:  map[string]interface{}{"region": "eu-west-1", "count": 1.5},
+			wantErr: "invalid inputs: count: expected integer, got float64",
+		},
+		{
+			name:    "all problems at once, sorted by name",
+			inputs:  map[string]interface{}{"count": "two", "tags": "a,b"},
+			wantErr: "invalid inputs: count: expected integer, got string; region: required; tags: expected array, got string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateInputs(schema, tt.inputs)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if !errors.Is(err, ErrInvalidInputs) {
+					t.Errorf("error %v does not wrap ErrInvalidInputs", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateInputs: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateInputsEnumNumbers(t *testing.T) {
+	// YAML enums decode as int, JSON inputs as float64
+	schema := map[string]api.InputSpec{"size": {Type: "integer", Enum: []interface{}{1, 2, 4}}}
+
+	if _, err := ValidateInputs(schema, map[string]interface{}{"size": float64(2)}); err != nil {
+		t.Errorf("size 2: %v", err)
+	}
+	if _, err := ValidateInputs(schema, map[string]interface{}{"size": float64(3)}); err == nil {
+		t.Error("size 3: expected an error")
+	}
+}
+
+func TestValidateInputSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    api.InputSpec
+		wantErr string
+	}{
+		{name: "valid", spec: api.InputSpec{Type: "string", Pattern: `^\d+$`, Enum: []interface{}{"1"}, Default: "1"}},
+		{name: "unknown type", spec: api.InputSpec{Type: "text"}, wantErr: `input x: unknown type "text"`},
+		{name: "pattern on a number", spec: api.InputSpec{Type: "number", Pattern: `^1$`}, wantErr: "input x: pattern requires type string"},
+		{name: "invalid pattern", spec: api.InputSpec{Type: "string", Pattern: `(`}, wantErr: "input x: invalid pattern: error parsing regexp: missing closing ): `(`"},
+		{name: "enum of the wrong type", spec: api.InputSpec{Type: "integer", Enum: []interface{}{"a"}}, wantErr: "input x: enum value a: expected integer, got string"},
+		{name: "default not in enum", spec: api.InputSpec{Type: "string", Enum: []interface{}{"a"}, Default: "b"}, wantErr: "input x: default: must be one of [a]"},
+		{name: "default not matching", spec: api.InputSpec{Type: "string", Pattern: `^a`, Default: "b"}, wantErr: "input x: default: must match ^a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInputSchema(map[string]api.InputSpec{"x": tt.spec})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateInputSchema: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompilePatternCaches(t *testing.T) {
+	a, err := compilePattern(`^cached$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := compilePattern(`^cached$`)
+	if a != b {
+		t.Error("pattern was compiled twice")
+	}
+	if _, err := compilePattern(`[`); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,87 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+)
+
+// ValidateNodeDefaults checks a timeout and retry policy as a node, a
+// workflow's defaults or a module sets them.
+func ValidateNodeDefaults(timeout string, retry *api.RetryPolicy) error {
+	if timeout != "" {
+		if _, err := positiveDuration(timeout); err != nil {
+			return fmt.Errorf("timeout: %w", err)
+		}
+	}
+	if retry == nil {
+		return nil
+	}
+
+	if retry.MaxAttempts < 0 {
+		return fmt.Errorf("retry: max_attempts must not be negative")
+	}
+	if retry.Backoff != 0 && retry.Backoff < 1 {
+		return fmt.Errorf("retry: backoff must be at least 1")
+	}
+
+	var initial, max time.Duration
+	var err error
+	if retry.InitialInterval != "" {
+		if initial, err = positiveDuration(retry.InitialInterval); err != nil {
+			return fmt.Errorf("retry: initial_interval: %w", err)
+		}
+	}
+	if retry.MaxInterval != "" {
+		if max, err = positiveDuration(retry.MaxInterval); err != nil {
+			return fmt.Errorf("retry: max_interval: %w", err)
+		}
+	}
+	if initial > 0 && max > 0 && max < initial {
+		return fmt.Errorf("retry: max_interval must not be shorter than initial_interval")
+	}
+
+	for _, t := range retry.NonRetryableErrors {
+		if t == "" {
+			return fmt.Errorf("retry: non_retryable_errors must not contain empty types")
+		}
+	}
+	return nil
+}
+
+// validatePolicies checks the workflow's defaults and every node's own
+// timeout and retry policy.
+func validatePolicies(def *api.Definition) error {
+	if d := def.Defaults; d != nil {
+		if err := ValidateNodeDefaults(d.Timeout, d.Retry); err != nil {
+			return fmt.Errorf("defaults: %w", err)
+		}
+	}
+
+	ids := make([]string, 0, len(def.Nodes))
+	for id := range def.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		n := def.Nodes[id]
+		if err := ValidateNodeDefaults(n.Timeout, n.Retry); err != nil {
+			return fmt.Errorf("node %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func positiveDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return d, nil
+}
@@ -3,9 +3,14 @@ package validation
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
+	moduleapi "github.com/prashantsinghb/workflow-engine/pkg/module/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/module/contract"
 	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
 	"github.com/prashantsinghb/workflow-engine/pkg/workflow/dag"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/expr"
 )
 
 type WorkflowValidator struct{}
@@ -31,6 +36,18 @@ func (v *WorkflowValidator) Validate(
 		return err
 	}
 
+	if err := validateInputSchema(req.Definition.Inputs); err != nil {
+		return err
+	}
+
+	if err := v.validateExpressions(req.Definition); err != nil {
+		return err
+	}
+
+	if err := validatePolicies(req.Definition); err != nil {
+		return err
+	}
+
 	if err := v.validateModules(ctx, req); err != nil {
 		return err
 	}
@@ -50,13 +67,176 @@ func (v *WorkflowValidator) validateDAG(def *api.Definition) error {
 	return nil
 }
 
+// validateExpressions checks that every `if`, `for_each` and ${{ }} in
+// `with` parses and only references workflow inputs and steps the node
+// (transitively) depends on.
+func (v *WorkflowValidator) validateExpressions(def *api.Definition) error {
+	g := dag.Build(def)
+
+	for id, node := range g.Nodes {
+		if node.MaxParallel < 0 {
+			return fmt.Errorf("node %s: max_parallel must not be negative", id)
+		}
+		if node.MaxParallel > 0 && node.ForEach == "" {
+			return fmt.Errorf("node %s: max_parallel requires for_each", id)
+		}
+
+		refs := &nodeRefs{id: id, upstream: ancestors(g, id), inputs: def.Inputs}
+
+		if err := refs.checkCondition("if", node.If); err != nil {
+			return err
+		}
+		if err := refs.checkCondition("for_each", node.ForEach); err != nil {
+			return err
+		}
+
+		exprs, err := expr.TemplateExpressions(node.With)
+		if err != nil {
+			return fmt.Errorf("node %s: invalid with expression: %w", id, err)
+		}
+		// item and index exist once the node has fanned out
+		refs.iteration = node.ForEach != ""
+		for _, e := range exprs {
+			if err := refs.check("with", e); err != nil {
+				return err
+			}
+		}
+	}
+
+	return validateOutputs(g, def)
+}
+
+// validateOutputs checks the workflow `outputs` section, which is rendered
+// after every node settled and may therefore reference any node.
+func validateOutputs(g *dag.Graph, def *api.Definition) error {
+	exprs, err := expr.TemplateExpressions(def.Outputs)
+	if err != nil {
+		return fmt.Errorf("invalid outputs expression: %w", err)
+	}
+
+	for _, e := range exprs {
+		if e.Calls("now") {
+			return fmt.Errorf("outputs: now() is not allowed")
+		}
+		for _, ref := range e.References() {
+			switch ref[0] {
+			case "inputs":
+				if err := checkDeclaredInput(def.Inputs, ref); err != nil {
+					return fmt.Errorf("outputs: %w", err)
+				}
+			case "steps":
+				if len(ref) < 2 {
+					continue
+				}
+				if _, ok := g.Nodes[dag.NodeID(ref[1])]; !ok {
+					return fmt.Errorf("outputs: references unknown step %s", ref[1])
+				}
+			default:
+				return fmt.Errorf("outputs: references unknown variable %s", ref[0])
+			}
+		}
+	}
+	return nil
+}
+
+// nodeRefs checks the variables an expression of one node may reference.
+type nodeRefs struct {
+	id        dag.NodeID
+	upstream  map[dag.NodeID]bool
+	inputs    map[string]api.InputSpec
+	iteration bool
+}
+
+// checkCondition validates an expression evaluated by the scheduler, which
+// must be deterministic and therefore cannot call now().
+func (r *nodeRefs) checkCondition(field string, src string) error {
+	if src == "" {
+		return nil
+	}
+
+	e, err := expr.Parse(src)
+	if err != nil {
+		return fmt.Errorf("node %s: invalid %s expression: %w", r.id, field, err)
+	}
+	if e.Calls("now") {
+		return fmt.Errorf("node %s: now() is not allowed in %s", r.id, field)
+	}
+	return r.check(field, e)
+}
+
+func (r *nodeRefs) check(field string, e *expr.Expression) error {
+	for _, ref := range e.References() {
+		switch ref[0] {
+		case "inputs":
+			if err := checkDeclaredInput(r.inputs, ref); err != nil {
+				return fmt.Errorf("node %s: %s %w", r.id, field, err)
+			}
+		case "steps":
+			if len(ref) < 2 {
+				continue
+			}
+			if !r.upstream[dag.NodeID(ref[1])] {
+				return fmt.Errorf(
+					"node %s: %s references step %s which is not an upstream dependency",
+					r.id, field, ref[1],
+				)
+			}
+		case "item", "index":
+			if !r.iteration {
+				return fmt.Errorf("node %s: %s references %s outside of for_each", r.id, field, ref[0])
+			}
+		case "secrets":
+			// conditions are evaluated by the scheduler, whose state ends
+			// up in the workflow history; secrets resolve on the worker only
+			if field != "with" {
+				return fmt.Errorf("node %s: secrets cannot be used in %s", r.id, field)
+			}
+			if len(ref) < 2 {
+				return fmt.Errorf("node %s: %s must reference a secret by name", r.id, field)
+			}
+		default:
+			return fmt.Errorf("node %s: %s references unknown variable %s", r.id, field, ref[0])
+		}
+	}
+	return nil
+}
+
+// checkDeclaredInput rejects references to undeclared inputs once a
+// workflow declares an inputs section.
+func checkDeclaredInput(schema map[string]api.InputSpec, ref []string) error {
+	if len(schema) == 0 || len(ref) < 2 {
+		return nil
+	}
+	if _, ok := schema[ref[1]]; !ok {
+		return fmt.Errorf("references undeclared input %s", ref[1])
+	}
+	return nil
+}
+
+func ancestors(g *dag.Graph, id dag.NodeID) map[dag.NodeID]bool {
+	seen := map[dag.NodeID]bool{}
+
+	var visit func(dag.NodeID)
+	visit = func(n dag.NodeID) {
+		for _, dep := range g.Nodes[n].Depends {
+			if !seen[dep] {
+				seen[dep] = true
+				visit(dep)
+			}
+		}
+	}
+	visit(id)
+
+	return seen
+}
+
 func (v *WorkflowValidator) validateModules(
 	ctx context.Context,
 	req *Request,
 ) error {
 
-	for _, node := range req.Definition.Nodes {
-		_, err := req.Modules.Resolve(
+	for id, node := range req.Definition.Nodes {
+		mod, err := req.Modules.Resolve(
 			ctx,
 			req.ProjectID,
 			node.Uses,
@@ -67,6 +247,104 @@ func (v *WorkflowValidator) validateModules(
 				node.Uses,
 			)
 		}
+
+		if err := checkModuleInputs(id, node, mod, req.Definition); err != nil {
+			return err
+		}
+
+		if mod.Runtime == "http" {
+			spec, err := req.Modules.GetStore().GetHttpSpec(ctx, mod.ID)
+			if err != nil {
+				return fmt.Errorf("module %s: loading http spec: %w", node.Uses, err)
+			}
+			if spec != nil && spec.BodyTemplate != nil {
+				if err := ValidateBodyTemplate(spec.BodyTemplate.AsMap()); err != nil {
+					return fmt.Errorf("module %s: %w", node.Uses, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkModuleInputs verifies a node's `with` against the inputs its module
+// declares: no unknown keys, literal values of the declared type, and every
+// required input set in `with`, since a node receives nothing else. Values
+// computed by ${{ }} expressions are checked at runtime instead.
+func checkModuleInputs(
+	id string,
+	node api.Node,
+	mod *moduleapi.Module,
+	def *api.Definition,
+) error {
+
+	c, err := contract.Parse(mod.Inputs)
+	if err != nil {
+		return fmt.Errorf("module %s: invalid inputs contract: %w", node.Uses, err)
+	}
+	if c.Empty() {
+		return nil
+	}
+
+	var problems []string
+	for _, k := range c.Unknown(node.With) {
+		problems = append(problems, k+": not an input of "+node.Uses)
+	}
+
+	literals := map[string]interface{}{}
+	for k, v := range node.With {
+		if s, ok := v.(string); ok && expr.IsTemplate(s) {
+			continue
+		}
+		literals[k] = v
+	}
+	for _, v := range c.Check(literals) {
+		if v.Message == "required" {
+			continue
+		}
+		problems = append(problems, v.Field+": "+v.Message)
+	}
+
+	for name, f := range c.Fields {
+		if !f.Required {
+			continue
+		}
+		if _, ok := node.With[name]; ok {
+			continue
+		}
+		// workflow inputs are not passed through; point at the reference
+		if _, ok := def.Inputs[name]; ok {
+			problems = append(problems, fmt.Sprintf(`%s: required, e.g. %s: "${{ inputs.%s }}"`, name, name, name))
+			continue
+		}
+		problems = append(problems, name+": required")
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("node %s: %s", id, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ValidateBodyTemplate checks the ${{ }} expressions of an HTTP module's
+// body template. Step and secret references cannot be checked against a
+// workflow or project here, only that the expressions parse and use known
+// variables.
+func ValidateBodyTemplate(tpl map[string]interface{}) error {
+	exprs, err := expr.TemplateExpressions(tpl)
+	if err != nil {
+		return fmt.Errorf("invalid body template: %w", err)
+	}
+
+	for _, e := range exprs {
+		for _, ref := range e.References() {
+			switch ref[0] {
+			case "inputs", "steps", "item", "index", "secrets":
+			default:
+				return fmt.Errorf("body template references unknown variable %s", ref[0])
+			}
+		}
 	}
 	return nil
 }
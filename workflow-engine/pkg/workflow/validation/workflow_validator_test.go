@@ -0,0 +1,57 @@
+package validation
+
+import (
+	"testing"
+
+	moduleapi "github.com/prashantsinghb/workflow-engine/pkg/module/api"
+	"github.com/prashantsinghb/workflow-engine/pkg/workflow/api"
+)
+
+func TestCheckModuleInputs(t *testing.T) {
+	mod := &moduleapi.Module{
+		Name: "dns.create",
+		Inputs: map[string]interface{}{
+			"name": map[string]interface{}{"type": "string", "required": true},
+			"zone": map[string]interface{}{"type": "string", "required": true},
+			"ttl":  "integer",
+		},
+	}
+	def := &api.Definition{Inputs: map[string]api.InputSpec{"name": {Type: "string"}}}
+
+	tests := []struct {
+		name    string
+		with    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "literals and references",
+			with: map[string]interface{}{"name": "${{ inputs.name }}", "zone": "example.com", "ttl": 60},
+		},
+		{
+			name:    "a workflow input of the same name is not passed through",
+			with:    map[string]interface{}{"zone": "example.com"},
+			wantErr: `node create: name: required, e.g. name: "${{ inputs.name }}"`,
+		},
+		{
+			name:    "missing, unknown and mistyped inputs",
+			with:    map[string]interface{}{"name": "a", "ttl": "60", "owner": "me"},
+			wantErr: "node create: owner: not an input of dns.create; ttl: expected integer, got string; zone: required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := api.Node{Uses: mod.Name, With: tt.with}
+			err := checkModuleInputs("create", node, mod, def)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkModuleInputs: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -24,6 +24,8 @@ import {
   Http as HttpIcon,
   Storage as ContainerIcon,
   Info as InfoIcon,
+  Terminal as ExecIcon,
+  Hub as GrpcIcon,
 } from "@mui/icons-material";
 import { Formik, Form } from "formik";
 import * as Yup from "yup";
@@ -32,7 +34,7 @@ import "ace-builds/src-noconflict/mode-json";
 import "ace-builds/src-noconflict/theme-github";
 import { moduleApi } from "@/services/client/moduleApi";
 import { toast } from "react-toastify";
-import type { HttpModuleSpec, ContainerRegistryModuleSpec } from "@/types/module";
+import type { HttpAuth, HttpModuleSpec, ContainerRegistryModuleSpec, ExecModuleSpec, GrpcModuleSpec } from "@/types/module";
 import { useProject } from "@/contexts/ProjectContext";
 
 const defaultInputs = `{
@@ -45,6 +47,20 @@ const defaultOutputs = `{
   "status": "string"
 }`;
 
+// parseStatusCodes reads a comma-separated list such as "200, 201".
+const parseStatusCodes = (value: string): number[] =>
+  value
+    .split(",")
+    .map((code) => parseInt(code.trim(), 10))
+    .filter((code) => !Number.isNaN(code));
+
+const runtimeLabels: Record<string, string> = {
+  http: "HTTP",
+  docker: "Container",
+  exec: "Command",
+  grpc: "gRPC",
+};
+
 const validationSchema = Yup.object({
   name: Yup.string().required("Name is required"),
   version: Yup.string().required("Version is required"),
@@ -75,13 +91,31 @@ const validationSchema = Yup.object({
   }),
   httpUrl: Yup.string().when("runtime", {
     is: "http",
-    then: (schema) => schema.required("HTTP URL is required").url("Must be a valid URL"),
+    // the URL may contain ${{ }} expressions, so only require an http(s) scheme
+    then: (schema) => schema.required("HTTP URL is required").matches(/^https?:\/\//, "Must start with http:// or https://"),
   }),
   // Container spec validation
   containerImage: Yup.string().when("runtime", {
     is: "docker",
     then: (schema) => schema.required("Container image is required"),
   }),
+  // gRPC spec validation
+  grpcTarget: Yup.string().when("runtime", {
+    is: "grpc",
+    then: (schema) => schema.required("Target is required"),
+  }),
+  grpcMethod: Yup.string().when("runtime", {
+    is: "grpc",
+    then: (schema) =>
+      schema
+        .required("Method is required")
+        .matches(/^\/?[\w.]+[/.]\w+$/, "Expected package.Service/Method"),
+  }),
+  // Exec spec validation
+  execCommand: Yup.string().when(["runtime", "execScript"], {
+    is: (runtime: string, script: string) => runtime === "exec" && !script,
+    then: (schema) => schema.required("Command or script is required"),
+  }),
 });
 
 const ModuleCreate = () => {
@@ -125,6 +159,9 @@ const ModuleCreate = () => {
           httpBodyTemplate: "{}",
           httpTimeoutMs: 30000,
           httpRetryCount: 3,
+          httpRetryBackoffMs: 500,
+          httpRetryOnStatus: "429, 500, 502, 503, 504",
+          httpSuccessCodes: "200, 201, 202, 204",
           // Auth
           authType: "none",
           authBearerToken: "",
@@ -134,12 +171,37 @@ const ModuleCreate = () => {
           authOAuth2ClientId: "",
           authOAuth2ClientSecret: "",
           authOAuth2Scope: "",
+          authOAuth2GrantType: "client_credentials",
+          authOAuth2RefreshToken: "",
+          authOAuth2Audience: "",
+          authBasicUsername: "",
+          authBasicPassword: "",
+          authHmacSecret: "",
+          authHmacAlgorithm: "sha256",
+          authHmacHeader: "",
+          authHmacTimestampHeader: "",
           // Container spec
           containerImage: "",
           containerCommand: "",
           containerEnv: "{}",
           containerCpu: "",
           containerMemory: "",
+          // Exec spec
+          execCommand: "",
+          execScript: "",
+          execArgs: "",
+          execEnv: "{}",
+          execWorkingDir: "",
+          execTimeoutMs: 60000,
+          execOutputFormat: "",
+          // gRPC spec
+          grpcTarget: "",
+          grpcMethod: "",
+          grpcTlsEnabled: false,
+          grpcTlsServerName: "",
+          grpcRequestTemplate: "{}",
+          grpcMetadata: "{}",
+          grpcTimeoutMs: 30000,
         }}
         validationSchema={validationSchema}
         onSubmit={async (values, { setSubmitting }) => {
@@ -173,7 +235,7 @@ const ModuleCreate = () => {
 
             const projectId = values.isGlobal ? "global" : contextProjectId;
 
-            let spec: HttpModuleSpec | ContainerRegistryModuleSpec | undefined;
+            let spec: HttpModuleSpec | ContainerRegistryModuleSpec | ExecModuleSpec | GrpcModuleSpec | undefined;
 
             if (values.runtime === "http") {
               try {
@@ -187,19 +249,35 @@ const ModuleCreate = () => {
               }
 
               // Build auth object
-              let auth: { api_key?: { header: string; value: string }; bearer?: { token: string }; oauth2?: { token_url: string; client_id: string; client_secret?: string; scope?: string } } | undefined;
-              
+              let auth: HttpAuth | undefined;
+
               if (values.authType === "bearer" && values.authBearerToken) {
                 auth = { bearer: { token: values.authBearerToken } };
               } else if (values.authType === "api_key" && values.authApiKeyHeader && values.authApiKeyValue) {
                 auth = { api_key: { header: values.authApiKeyHeader, value: values.authApiKeyValue } };
-              } else if (values.authType === "oauth2" && values.authOAuth2TokenUrl && values.authOAuth2ClientId) {
+              } else if (values.authType === "basic" && values.authBasicUsername) {
+                auth = { basic: { username: values.authBasicUsername, password: values.authBasicPassword } };
+              } else if (values.authType === "oauth2" && values.authOAuth2TokenUrl) {
+                const grantType = values.authOAuth2GrantType === "refresh_token" ? "refresh_token" : "client_credentials";
                 auth = {
                   oauth2: {
                     token_url: values.authOAuth2TokenUrl,
-                    client_id: values.authOAuth2ClientId,
+                    grant_type: grantType,
+                    ...(values.authOAuth2ClientId ? { client_id: values.authOAuth2ClientId } : {}),
                     ...(values.authOAuth2ClientSecret ? { client_secret: values.authOAuth2ClientSecret } : {}),
                     ...(values.authOAuth2Scope ? { scope: values.authOAuth2Scope } : {}),
+                    ...(values.authOAuth2Audience ? { audience: values.authOAuth2Audience } : {}),
+                    ...(grantType === "refresh_token" ? { refresh_token: values.authOAuth2RefreshToken } : {}),
+                  },
+                };
+              } else if (values.authType === "hmac" && values.authHmacSecret) {
+                const algorithm = values.authHmacAlgorithm === "sha1" || values.authHmacAlgorithm === "sha512" ? values.authHmacAlgorithm : "sha256";
+                auth = {
+                  hmac: {
+                    secret: values.authHmacSecret,
+                    algorithm,
+                    ...(values.authHmacHeader ? { header: values.authHmacHeader } : {}),
+                    ...(values.authHmacTimestampHeader ? { timestamp_header: values.authHmacTimestampHeader } : {}),
                   },
                 };
               }
@@ -213,6 +291,9 @@ const ModuleCreate = () => {
                 ...(auth ? { auth } : {}),
                 timeout_ms: values.httpTimeoutMs,
                 retry_count: values.httpRetryCount,
+                retry_backoff_ms: values.httpRetryBackoffMs,
+                retry_on_status: parseStatusCodes(values.httpRetryOnStatus),
+                success_codes: parseStatusCodes(values.httpSuccessCodes),
               };
             } else if (values.runtime === "docker") {
               try {
@@ -234,15 +315,68 @@ const ModuleCreate = () => {
                 cpu: values.containerCpu || undefined,
                 memory: values.containerMemory || undefined,
               };
+            } else if (values.runtime === "exec") {
+              let execEnv = {};
+              try {
+                execEnv = JSON.parse(values.execEnv || "{}");
+              } catch (e) {
+                toast.error("Invalid JSON in exec environment variables");
+                setSubmitting(false);
+                return;
+              }
+
+              const args = values.execArgs
+                ? values.execArgs.split("\n").filter((a) => a.trim())
+                : undefined;
+
+              spec = {
+                ...(values.execScript ? { script: values.execScript } : { command: values.execCommand }),
+                args,
+                env: execEnv as Record<string, string>,
+                working_dir: values.execWorkingDir || undefined,
+                timeout_ms: values.execTimeoutMs,
+                output_format: values.execOutputFormat as ExecModuleSpec["output_format"],
+              };
+            } else if (values.runtime === "grpc") {
+              let requestTemplate = {};
+              let grpcMetadata = {};
+              try {
+                requestTemplate = JSON.parse(values.grpcRequestTemplate || "{}");
+                grpcMetadata = JSON.parse(values.grpcMetadata || "{}");
+              } catch (e) {
+                toast.error("Invalid JSON in gRPC configuration");
+                setSubmitting(false);
+                return;
+              }
+
+              spec = {
+                target: values.grpcTarget,
+                method: values.grpcMethod,
+                ...(values.grpcTlsEnabled
+                  ? { tls: { enabled: true, server_name: values.grpcTlsServerName || undefined } }
+                  : {}),
+                ...(Object.keys(requestTemplate).length > 0 ? { request_template: requestTemplate } : {}),
+                metadata: grpcMetadata as Record<string, string>,
+                timeout_ms: values.grpcTimeoutMs,
+              };
             }
 
+            const runtimeSpec =
+              values.runtime === "http"
+                ? { http: spec as HttpModuleSpec }
+                : values.runtime === "exec"
+                  ? { exec: spec as ExecModuleSpec }
+                  : values.runtime === "grpc"
+                    ? { grpc: spec as GrpcModuleSpec }
+                    : { container_registry: spec as ContainerRegistryModuleSpec };
+
             await moduleApi.registerModule({
               projectId,
               module: {
                 name: values.name,
                 version: values.version,
                 runtime: values.runtime,
-                ...(values.runtime === "http" ? { http: spec as HttpModuleSpec } : { container_registry: spec as ContainerRegistryModuleSpec }),
+                ...runtimeSpec,
                 inputs,
                 outputs,
               },
@@ -327,11 +461,24 @@ const ModuleCreate = () => {
                 <Accordion expanded={expandedSection === "runtime"} onChange={handleSectionChange("runtime")} elevation={2}>
                   <AccordionSummary expandIcon={<ExpandMoreIcon />}>
                     <Box sx={{ display: "flex", alignItems: "center", gap: 1 }}>
-                      {values.runtime === "http" ? <HttpIcon color="primary" /> : <ContainerIcon color="primary" />}
+                      {values.runtime === "http" ? (
+                        <HttpIcon color="primary" />
+                      ) : values.runtime === "exec" ? (
+                        <ExecIcon color="primary" />
+                      ) : values.runtime === "grpc" ? (
+                        <GrpcIcon color="primary" />
+                      ) : (
+                        <ContainerIcon color="primary" />
+                      )}
                       <Typography variant="h6" sx={{ fontWeight: 500 }}>
                         Runtime Configuration
                       </Typography>
-                      <Chip label={values.runtime === "http" ? "HTTP" : "Container"} size="small" color="primary" sx={{ ml: 1 }} />
+                      <Chip
+                        label={runtimeLabels[values.runtime] ?? values.runtime}
+                        size="small"
+                        color="primary"
+                        sx={{ ml: 1 }}
+                      />
                     </Box>
                   </AccordionSummary>
                   <AccordionDetails>
@@ -360,6 +507,18 @@ const ModuleCreate = () => {
                               <span>Container Image</span>
                             </Box>
                           </MenuItem>
+                          <MenuItem value="exec">
+                            <Box sx={{ display: "flex", alignItems: "center", gap: 1 }}>
+                              <ExecIcon fontSize="small" />
+                              <span>Local Command</span>
+                            </Box>
+                          </MenuItem>
+                          <MenuItem value="grpc">
+                            <Box sx={{ display: "flex", alignItems: "center", gap: 1 }}>
+                              <GrpcIcon fontSize="small" />
+                              <span>gRPC</span>
+                            </Box>
+                          </MenuItem>
                         </TextField>
                       </Grid>
 
@@ -469,6 +628,40 @@ const ModuleCreate = () => {
                               variant="outlined"
                             />
                           </Grid>
+                          <Grid item xs={12} md={4}>
+                            <TextField
+                              fullWidth
+                              label="Retry Backoff (ms)"
+                              name="httpRetryBackoffMs"
+                              type="number"
+                              value={values.httpRetryBackoffMs}
+                              onChange={handleChange}
+                              helperText="Doubled after each retry"
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={4}>
+                            <TextField
+                              fullWidth
+                              label="Retry On Status"
+                              name="httpRetryOnStatus"
+                              value={values.httpRetryOnStatus}
+                              onChange={handleChange}
+                              helperText="Comma-separated status codes"
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={4}>
+                            <TextField
+                              fullWidth
+                              label="Success Codes"
+                              name="httpSuccessCodes"
+                              value={values.httpSuccessCodes}
+                              onChange={handleChange}
+                              helperText="Other statuses fail the node"
+                              variant="outlined"
+                            />
+                          </Grid>
                           {/* Authentication */}
                           <Grid item xs={12}>
                             <Divider sx={{ my: 2 }} />
@@ -488,8 +681,10 @@ const ModuleCreate = () => {
                             >
                               <MenuItem value="none">None</MenuItem>
                               <MenuItem value="bearer">Bearer Token</MenuItem>
+                              <MenuItem value="basic">Basic</MenuItem>
                               <MenuItem value="api_key">API Key</MenuItem>
                               <MenuItem value="oauth2">OAuth2</MenuItem>
+                              <MenuItem value="hmac">HMAC Signature</MenuItem>
                             </TextField>
                           </Grid>
                           {values.authType === "bearer" && (
@@ -498,10 +693,10 @@ const ModuleCreate = () => {
                                 fullWidth
                                 label="Bearer Token"
                                 name="authBearerToken"
-                                type="password"
                                 value={values.authBearerToken}
                                 onChange={handleChange}
-                                placeholder="Enter bearer token"
+                                placeholder="${{ secrets.API_TOKEN }}"
+                                helperText="Reference a project secret"
                                 variant="outlined"
                               />
                             </Grid>
@@ -524,10 +719,36 @@ const ModuleCreate = () => {
                                   fullWidth
                                   label="API Key Value"
                                   name="authApiKeyValue"
-                                  type="password"
                                   value={values.authApiKeyValue}
                                   onChange={handleChange}
-                                  placeholder="Enter API key"
+                                  placeholder="${{ secrets.API_KEY }}"
+                                  helperText="Reference a project secret"
+                                  variant="outlined"
+                                />
+                              </Grid>
+                            </>
+                          )}
+                          {values.authType === "basic" && (
+                            <>
+                              <Grid item xs={12} md={6}>
+                                <TextField
+                                  fullWidth
+                                  label="Username"
+                                  name="authBasicUsername"
+                                  value={values.authBasicUsername}
+                                  onChange={handleChange}
+                                  variant="outlined"
+                                />
+                              </Grid>
+                              <Grid item xs={12} md={6}>
+                                <TextField
+                                  fullWidth
+                                  label="Password"
+                                  name="authBasicPassword"
+                                  value={values.authBasicPassword}
+                                  onChange={handleChange}
+                                  placeholder="${{ secrets.API_PASSWORD }}"
+                                  helperText="Reference a project secret"
                                   variant="outlined"
                                 />
                               </Grid>
@@ -535,6 +756,20 @@ const ModuleCreate = () => {
                           )}
                           {values.authType === "oauth2" && (
                             <>
+                              <Grid item xs={12} md={6}>
+                                <TextField
+                                  fullWidth
+                                  select
+                                  label="Grant Type"
+                                  name="authOAuth2GrantType"
+                                  value={values.authOAuth2GrantType}
+                                  onChange={handleChange}
+                                  variant="outlined"
+                                >
+                                  <MenuItem value="client_credentials">Client Credentials</MenuItem>
+                                  <MenuItem value="refresh_token">Refresh Token</MenuItem>
+                                </TextField>
+                              </Grid>
                               <Grid item xs={12} md={6}>
                                 <TextField
                                   fullWidth
@@ -562,10 +797,10 @@ const ModuleCreate = () => {
                                   fullWidth
                                   label="Client Secret"
                                   name="authOAuth2ClientSecret"
-                                  type="password"
                                   value={values.authOAuth2ClientSecret}
                                   onChange={handleChange}
-                                  placeholder="Enter client secret (optional)"
+                                  placeholder="${{ secrets.CLIENT_SECRET }}"
+                                  helperText="Reference a project secret (optional)"
                                   variant="outlined"
                                 />
                               </Grid>
@@ -580,6 +815,84 @@ const ModuleCreate = () => {
                                   variant="outlined"
                                 />
                               </Grid>
+                              <Grid item xs={12} md={6}>
+                                <TextField
+                                  fullWidth
+                                  label="Audience"
+                                  name="authOAuth2Audience"
+                                  value={values.authOAuth2Audience}
+                                  onChange={handleChange}
+                                  placeholder="e.g., https://api.example.com (optional)"
+                                  variant="outlined"
+                                />
+                              </Grid>
+                              {values.authOAuth2GrantType === "refresh_token" && (
+                                <Grid item xs={12} md={6}>
+                                  <TextField
+                                    fullWidth
+                                    label="Refresh Token"
+                                    name="authOAuth2RefreshToken"
+                                    value={values.authOAuth2RefreshToken}
+                                    onChange={handleChange}
+                                    placeholder="${{ secrets.REFRESH_TOKEN }}"
+                                    helperText="Reference a project secret"
+                                    variant="outlined"
+                                  />
+                                </Grid>
+                              )}
+                            </>
+                          )}
+                          {values.authType === "hmac" && (
+                            <>
+                              <Grid item xs={12} md={6}>
+                                <TextField
+                                  fullWidth
+                                  label="Signing Secret"
+                                  name="authHmacSecret"
+                                  value={values.authHmacSecret}
+                                  onChange={handleChange}
+                                  placeholder="${{ secrets.SIGNING_SECRET }}"
+                                  helperText="Reference a project secret"
+                                  variant="outlined"
+                                />
+                              </Grid>
+                              <Grid item xs={12} md={6}>
+                                <TextField
+                                  fullWidth
+                                  select
+                                  label="Algorithm"
+                                  name="authHmacAlgorithm"
+                                  value={values.authHmacAlgorithm}
+                                  onChange={handleChange}
+                                  variant="outlined"
+                                >
+                                  <MenuItem value="sha256">SHA-256</MenuItem>
+                                  <MenuItem value="sha512">SHA-512</MenuItem>
+                                  <MenuItem value="sha1">SHA-1</MenuItem>
+                                </TextField>
+                              </Grid>
+                              <Grid item xs={12} md={6}>
+                                <TextField
+                                  fullWidth
+                                  label="Signature Header"
+                                  name="authHmacHeader"
+                                  value={values.authHmacHeader}
+                                  onChange={handleChange}
+                                  placeholder="X-Signature"
+                                  variant="outlined"
+                                />
+                              </Grid>
+                              <Grid item xs={12} md={6}>
+                                <TextField
+                                  fullWidth
+                                  label="Timestamp Header"
+                                  name="authHmacTimestampHeader"
+                                  value={values.authHmacTimestampHeader}
+                                  onChange={handleChange}
+                                  placeholder="X-Signature-Timestamp"
+                                  variant="outlined"
+                                />
+                              </Grid>
                             </>
                           )}
                         </>
@@ -655,6 +968,206 @@ const ModuleCreate = () => {
                           </Grid>
                         </>
                       )}
+
+                      {/* gRPC Configuration */}
+                      {values.runtime === "grpc" && (
+                        <>
+                          <Grid item xs={12} md={4}>
+                            <TextField
+                              fullWidth
+                              label="Target"
+                              name="grpcTarget"
+                              value={values.grpcTarget}
+                              onChange={handleChange}
+                              error={touched.grpcTarget && !!errors.grpcTarget}
+                              helperText={touched.grpcTarget && errors.grpcTarget || "e.g., dns.internal:443"}
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={8}>
+                            <TextField
+                              fullWidth
+                              label="Method"
+                              name="grpcMethod"
+                              value={values.grpcMethod}
+                              onChange={handleChange}
+                              error={touched.grpcMethod && !!errors.grpcMethod}
+                              helperText={
+                                touched.grpcMethod && errors.grpcMethod ||
+                                "Resolved through server reflection, e.g. dns.v1.RecordService/CreateRecord"
+                              }
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={4}>
+                            <FormControlLabel
+                              control={
+                                <Switch
+                                  checked={values.grpcTlsEnabled}
+                                  onChange={(e) => setFieldValue("grpcTlsEnabled", e.target.checked)}
+                                  color="primary"
+                                />
+                              }
+                              label="TLS"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={4}>
+                            <TextField
+                              fullWidth
+                              label="TLS Server Name"
+                              name="grpcTlsServerName"
+                              value={values.grpcTlsServerName}
+                              onChange={handleChange}
+                              disabled={!values.grpcTlsEnabled}
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={4}>
+                            <TextField
+                              fullWidth
+                              type="number"
+                              label="Timeout (ms)"
+                              name="grpcTimeoutMs"
+                              value={values.grpcTimeoutMs}
+                              onChange={handleChange}
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={6}>
+                            <Box>
+                              <Typography variant="subtitle2" gutterBottom>
+                                Request Template (JSON, empty sends the node inputs)
+                              </Typography>
+                              <AceEditor
+                                mode="json"
+                                theme="github"
+                                value={values.grpcRequestTemplate}
+                                onChange={(value) => setFieldValue("grpcRequestTemplate", value)}
+                                width="100%"
+                                height="150px"
+                                fontSize={13}
+                                setOptions={{ showLineNumbers: true, tabSize: 2 }}
+                              />
+                            </Box>
+                          </Grid>
+                          <Grid item xs={12} md={6}>
+                            <Box>
+                              <Typography variant="subtitle2" gutterBottom>
+                                Metadata (JSON)
+                              </Typography>
+                              <AceEditor
+                                mode="json"
+                                theme="github"
+                                value={values.grpcMetadata}
+                                onChange={(value) => setFieldValue("grpcMetadata", value)}
+                                width="100%"
+                                height="150px"
+                                fontSize={13}
+                                setOptions={{ showLineNumbers: true, tabSize: 2 }}
+                              />
+                            </Box>
+                          </Grid>
+                        </>
+                      )}
+
+                      {/* Exec Configuration */}
+                      {values.runtime === "exec" && (
+                        <>
+                          <Grid item xs={12} md={8}>
+                            <TextField
+                              fullWidth
+                              label="Command"
+                              name="execCommand"
+                              value={values.execCommand}
+                              onChange={handleChange}
+                              error={touched.execCommand && !!errors.execCommand}
+                              helperText={touched.execCommand && errors.execCommand || "Executable to run, e.g. /usr/local/bin/dnsctl"}
+                              disabled={!!values.execScript}
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={4}>
+                            <TextField
+                              fullWidth
+                              select
+                              label="Output Format"
+                              name="execOutputFormat"
+                              value={values.execOutputFormat}
+                              onChange={handleChange}
+                              variant="outlined"
+                            >
+                              <MenuItem value="">Auto</MenuItem>
+                              <MenuItem value="json">JSON</MenuItem>
+                              <MenuItem value="key_value">key=value lines</MenuItem>
+                              <MenuItem value="text">Text</MenuItem>
+                            </TextField>
+                          </Grid>
+                          <Grid item xs={12}>
+                            <TextField
+                              fullWidth
+                              label="Script"
+                              name="execScript"
+                              value={values.execScript}
+                              onChange={handleChange}
+                              helperText="Inline script run with /bin/sh -c instead of a command; args are $1, $2, ..."
+                              multiline
+                              rows={4}
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={6}>
+                            <TextField
+                              fullWidth
+                              label="Arguments (one per line)"
+                              name="execArgs"
+                              value={values.execArgs}
+                              onChange={handleChange}
+                              helperText="Supports ${{ inputs.name }} expressions"
+                              multiline
+                              rows={4}
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={6}>
+                            <Box>
+                              <Typography variant="subtitle2" gutterBottom>
+                                Environment Variables (JSON)
+                              </Typography>
+                              <AceEditor
+                                mode="json"
+                                theme="github"
+                                value={values.execEnv}
+                                onChange={(value) => setFieldValue("execEnv", value)}
+                                width="100%"
+                                height="150px"
+                                fontSize={13}
+                                setOptions={{ showLineNumbers: true, tabSize: 2 }}
+                              />
+                            </Box>
+                          </Grid>
+                          <Grid item xs={12} md={6}>
+                            <TextField
+                              fullWidth
+                              label="Working Directory"
+                              name="execWorkingDir"
+                              value={values.execWorkingDir}
+                              onChange={handleChange}
+                              variant="outlined"
+                            />
+                          </Grid>
+                          <Grid item xs={12} md={6}>
+                            <TextField
+                              fullWidth
+                              type="number"
+                              label="Timeout (ms)"
+                              name="execTimeoutMs"
+                              value={values.execTimeoutMs}
+                              onChange={handleChange}
+                              variant="outlined"
+                            />
+                          </Grid>
+                        </>
+                      )}
                     </Grid>
                   </AccordionDetails>
                 </Accordion>
@@ -40,6 +40,10 @@ const ExecutionDetails = () => {
         return "info";
       case ExecutionState.PENDING:
         return "warning";
+      case ExecutionState.CANCELLED:
+        return "default";
+      case ExecutionState.PAUSED:
+        return "secondary";
       default:
         return "default";
     }
@@ -68,7 +72,11 @@ const ExecutionDetails = () => {
       fetchTimeline();
 
       // Auto-poll if still running
-      if (result.state === ExecutionState.RUNNING || result.state === ExecutionState.PENDING) {
+      if (
+        result.state === ExecutionState.RUNNING ||
+        result.state === ExecutionState.PENDING ||
+        result.state === ExecutionState.PAUSED
+      ) {
         if (!polling) {
           setPolling(true);
           const interval = setInterval(async () => {
@@ -76,7 +84,11 @@ const ExecutionDetails = () => {
             setExecution(updated);
             // Refresh timeline on each poll
             fetchTimeline();
-            if (updated.state === ExecutionState.SUCCESS || updated.state === ExecutionState.FAILED) {
+            if (
+              updated.state === ExecutionState.SUCCESS ||
+              updated.state === ExecutionState.FAILED ||
+              updated.state === ExecutionState.CANCELLED
+            ) {
               clearInterval(interval);
               setPolling(false);
             }
@@ -91,6 +103,51 @@ const ExecutionDetails = () => {
     }
   };
 
+  const runAction = async (action: () => Promise<unknown>, success: string, failure: string) => {
+    try {
+      await action();
+      toast.success(success);
+      const updated = await workflowApi.getExecution(projectId, executionId!);
+      setExecution(updated);
+      fetchTimeline();
+    } catch (error: unknown) {
+      const errorMessage = error instanceof Error ? error.message : failure;
+      toast.error(errorMessage);
+    }
+  };
+
+  const handleCancel = () =>
+    runAction(
+      () => workflowApi.cancelExecution({ projectId, executionId: executionId! }),
+      "Cancellation requested",
+      "Failed to cancel execution"
+    );
+
+  const handlePause = () =>
+    runAction(
+      () => workflowApi.pauseExecution({ projectId, executionId: executionId! }),
+      "Execution paused",
+      "Failed to pause execution"
+    );
+
+  const handleResume = () =>
+    runAction(
+      () => workflowApi.resumeExecution({ projectId, executionId: executionId! }),
+      "Execution resumed",
+      "Failed to resume execution"
+    );
+
+  const handleRetry = async () => {
+    try {
+      const result = await workflowApi.retryExecution({ projectId, executionId: executionId! });
+      toast.success("Retry started");
+      navigate(`/workflows/executions/${result.executionId}`);
+    } catch (error: unknown) {
+      const errorMessage = error instanceof Error ? error.message : "Failed to retry execution";
+      toast.error(errorMessage);
+    }
+  };
+
   useEffect(() => {
     fetchExecution();
     // eslint-disable-next-line react-hooks/exhaustive-deps
@@ -118,9 +175,33 @@ const ExecutionDetails = () => {
         <Typography variant="h4" component="h1">
           Execution Details
         </Typography>
-        <Button variant="outlined" onClick={() => navigate("/workflows")}>
-          Back to List
-        </Button>
+        <Box sx={{ display: "flex", gap: 1 }}>
+          {execution.state === ExecutionState.RUNNING && (
+            <Button variant="outlined" onClick={handlePause}>
+              Pause
+            </Button>
+          )}
+          {execution.state === ExecutionState.PAUSED && (
+            <Button variant="outlined" onClick={handleResume}>
+              Resume
+            </Button>
+          )}
+          {(execution.state === ExecutionState.FAILED || execution.state === ExecutionState.CANCELLED) && (
+            <Button variant="contained" onClick={handleRetry}>
+              Retry
+            </Button>
+          )}
+          {(execution.state === ExecutionState.RUNNING ||
+            execution.state === ExecutionState.PENDING ||
+            execution.state === ExecutionState.PAUSED) && (
+            <Button variant="outlined" color="error" onClick={handleCancel}>
+              Cancel
+            </Button>
+          )}
+          <Button variant="outlined" onClick={() => navigate("/workflows")}>
+            Back to List
+          </Button>
+        </Box>
       </Box>
 
       <Grid container spacing={3}>
@@ -14,10 +14,29 @@ import { useNavigate, useParams } from "react-router-dom";
 import { Formik, Form } from "formik";
 import * as Yup from "yup";
 import { workflowApi } from "@/services/client/workflowApi";
-import { GetWorkflowResponse } from "@/types/workflow";
+import { GetWorkflowResponse, WorkflowInput } from "@/types/workflow";
 import { toast } from "react-toastify";
 import { useProject } from "@/contexts/ProjectContext";
 
+// initialInputs pre-fills the inputs JSON with every declared input, using
+// its default where one is set.
+const initialInputs = (inputs: WorkflowInput[] = []): string => {
+  if (inputs.length === 0) return "{}";
+  const values: Record<string, unknown> = {};
+  for (const input of inputs) {
+    values[input.name] = input.default ?? null;
+  }
+  return JSON.stringify(values, null, 2);
+};
+
+const describeInput = (input: WorkflowInput): string => {
+  const parts = [input.type || "any"];
+  if (input.required) parts.push("required");
+  if (input.enum?.length) parts.push(`one of ${input.enum.map((e) => JSON.stringify(e)).join(", ")}`);
+  if (input.pattern) parts.push(`matches ${input.pattern}`);
+  return parts.join(", ");
+};
+
 const WorkflowDetails = () => {
   const { workflowId } = useParams<{ workflowId: string }>();
   const navigate = useNavigate();
@@ -136,7 +155,7 @@ const WorkflowDetails = () => {
             <Formik
               initialValues={{
                 clientRequestId: `req-${Date.now()}`,
-                inputs: "{}",
+                inputs: initialInputs(workflow.inputs),
               }}
               validationSchema={executionSchema}
               onSubmit={async (values, { setSubmitting }) => {
@@ -189,9 +208,21 @@ const WorkflowDetails = () => {
                         helperText={touched.inputs && errors.inputs}
                         margin="normal"
                         multiline
-                        rows={4}
+                        rows={Math.max(4, (workflow.inputs?.length ?? 0) + 2)}
                       />
                     </Grid>
+                    {workflow.inputs && workflow.inputs.length > 0 && (
+                      <Grid item xs={12}>
+                        {workflow.inputs.map((input) => (
+                          <Typography key={input.name} variant="body2" color="text.secondary">
+                            <Box component="span" sx={{ fontFamily: "monospace" }}>
+                              {input.name}
+                            </Box>{" "}
+                            ({describeInput(input)}){input.description ? ` – ${input.description}` : ""}
+                          </Typography>
+                        ))}
+                      </Grid>
+                    )}
                     <Grid item xs={12}>
                       <Button
                         variant="contained"
@@ -11,6 +11,12 @@ import type {
   StartWorkflowRequest,
   StartWorkflowResponse,
   GetExecutionResponse,
+  CancelExecutionRequest,
+  CancelExecutionResponse,
+  PauseExecutionRequest,
+  ResumeExecutionRequest,
+  RetryExecutionRequest,
+  ExecutionStateResponse,
   ListExecutionsRequest,
   ListExecutionsResponse,
   GetDashboardStatsRequest,
@@ -68,6 +74,41 @@ export const workflowApi = {
     return response.data;
   },
 
+  cancelExecution: async (request: CancelExecutionRequest): Promise<CancelExecutionResponse> => {
+    const response = await apiClient.instance.post<CancelExecutionResponse>(
+      `/v1/projects/${request.projectId}/executions/${request.executionId}:cancel`,
+      { reason: request.reason }
+    );
+    return response.data;
+  },
+
+  pauseExecution: async (request: PauseExecutionRequest): Promise<ExecutionStateResponse> => {
+    const response = await apiClient.instance.post<ExecutionStateResponse>(
+      `/v1/projects/${request.projectId}/executions/${request.executionId}:pause`,
+      { reason: request.reason }
+    );
+    return response.data;
+  },
+
+  resumeExecution: async (request: ResumeExecutionRequest): Promise<ExecutionStateResponse> => {
+    const response = await apiClient.instance.post<ExecutionStateResponse>(
+      `/v1/projects/${request.projectId}/executions/${request.executionId}:resume`,
+      {}
+    );
+    return response.data;
+  },
+
+  retryExecution: async (request: RetryExecutionRequest): Promise<ExecutionStateResponse> => {
+    const response = await apiClient.instance.post<ExecutionStateResponse>(
+      `/v1/projects/${request.projectId}/executions/${request.executionId}:retry`,
+      {
+        clientRequestId: request.clientRequestId,
+        nodeInputs: request.nodeInputs || {},
+      }
+    );
+    return response.data;
+  },
+
   listExecutions: async (request: ListExecutionsRequest): Promise<ListExecutionsResponse> => {
     const params = new URLSearchParams();
     if (request.workflowId) {
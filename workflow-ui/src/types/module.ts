@@ -7,17 +7,34 @@ export interface BearerAuth {
   token: string;
 }
 
+export interface BasicAuth {
+  username: string;
+  password?: string;
+}
+
 export interface OAuth2Auth {
   token_url: string;
-  client_id: string;
+  client_id?: string;
   client_secret?: string;
   scope?: string;
+  grant_type?: "client_credentials" | "refresh_token";
+  refresh_token?: string;
+  audience?: string;
+}
+
+export interface HmacAuth {
+  secret: string;
+  algorithm?: "sha1" | "sha256" | "sha512";
+  header?: string;
+  timestamp_header?: string;
 }
 
 export interface HttpAuth {
   api_key?: ApiKeyAuth;
   bearer?: BearerAuth;
+  basic?: BasicAuth;
   oauth2?: OAuth2Auth;
+  hmac?: HmacAuth;
 }
 
 export interface HttpModuleSpec {
@@ -30,6 +47,9 @@ export interface HttpModuleSpec {
   output_mapping?: Record<string, unknown>;
   timeout_ms?: number;
   retry_count?: number;
+  retry_backoff_ms?: number;
+  retry_on_status?: number[];
+  success_codes?: number[];
 }
 
 export interface ContainerRegistryModuleSpec {
@@ -40,16 +60,60 @@ export interface ContainerRegistryModuleSpec {
   memory?: string;
 }
 
+export interface ExecModuleSpec {
+  command?: string;
+  script?: string;
+  args?: string[];
+  env?: Record<string, string>;
+  working_dir?: string;
+  timeout_ms?: number;
+  output_format?: "" | "json" | "key_value" | "text";
+}
+
+export interface GrpcTls {
+  enabled: boolean;
+  insecure_skip_verify?: boolean;
+  ca_cert?: string;
+  server_name?: string;
+}
+
+export interface GrpcModuleSpec {
+  target: string;
+  method: string; // e.g. "dns.v1.RecordService/CreateRecord"
+  tls?: GrpcTls;
+  request_template?: Record<string, unknown>;
+  metadata?: Record<string, string>;
+  descriptor_set?: string; // base64 FileDescriptorSet; reflection is used when empty
+  timeout_ms?: number;
+}
+
+export interface RetryPolicy {
+  max_attempts?: number;
+  initial_interval?: string; // e.g. "5s"
+  backoff?: number;
+  max_interval?: string;
+  non_retryable_errors?: string[];
+}
+
+// NodeDefaults apply to the nodes using a module unless a node sets its own
+export interface NodeDefaults {
+  timeout?: string; // e.g. "30s"
+  retry?: RetryPolicy;
+}
+
 export interface Module {
   id: string;
   project_id?: string;
   name: string;
   version: string;
-  runtime: string; // "http" | "docker"
+  runtime: string; // "http" | "docker" | "exec" | "grpc"
   http?: HttpModuleSpec;
   container_registry?: ContainerRegistryModuleSpec;
+  exec?: ExecModuleSpec;
+  grpc?: GrpcModuleSpec;
   inputs?: Record<string, unknown>;
   outputs?: Record<string, unknown>;
+  defaults?: NodeDefaults;
 }
 
 export interface RegisterModuleRequest {
@@ -60,8 +124,11 @@ export interface RegisterModuleRequest {
     runtime: string;
     http?: HttpModuleSpec;
     container_registry?: ContainerRegistryModuleSpec;
+    exec?: ExecModuleSpec;
+    grpc?: GrpcModuleSpec;
     inputs?: Record<string, unknown>;
     outputs?: Record<string, unknown>;
+    defaults?: NodeDefaults;
   };
 }
 
@@ -21,6 +21,7 @@ export interface RegisterWorkflowRequest {
 
 export interface RegisterWorkflowResponse {
   workflowId: string;
+  version?: string;
 }
 
 export interface ListWorkflowsRequest {
@@ -32,6 +33,9 @@ export interface WorkflowInfo {
   name: string;
   version: string;
   projectId: string;
+  deprecated?: boolean;
+  deprecationMessage?: string;
+  createdAt?: string;
 }
 
 export interface ListWorkflowsResponse {
@@ -43,9 +47,22 @@ export interface GetWorkflowRequest {
   workflowId: string;
 }
 
+export type WorkflowInputType = "" | "string" | "number" | "integer" | "boolean" | "object" | "array";
+
+export interface WorkflowInput {
+  name: string;
+  type?: WorkflowInputType;
+  description?: string;
+  required?: boolean;
+  default?: unknown;
+  enum?: unknown[];
+  pattern?: string;
+}
+
 export interface GetWorkflowResponse {
   workflow: WorkflowInfo;
   yaml: string;
+  inputs?: WorkflowInput[];
 }
 
 export interface StartWorkflowRequest {
@@ -58,6 +75,8 @@ export interface StartWorkflowRequest {
 export interface StartWorkflowResponse {
   executionId: string;
   state: string;
+  workflowId?: string;
+  version?: string;
 }
 
 export enum ExecutionState {
@@ -66,12 +85,50 @@ export enum ExecutionState {
   RUNNING = "RUNNING",
   SUCCESS = "SUCCESS",
   FAILED = "FAILED",
+  CANCELLED = "CANCELLED",
+  PAUSED = "PAUSED",
 }
 
 export interface GetExecutionResponse {
   state: ExecutionState;
   outputs?: Record<string, unknown>;
   error?: string;
+  workflowId?: string;
+  workflowVersion?: string;
+}
+
+export interface CancelExecutionRequest {
+  projectId: string;
+  executionId: string;
+  reason?: string;
+}
+
+export interface CancelExecutionResponse {
+  executionId: string;
+  state: string;
+}
+
+export interface PauseExecutionRequest {
+  projectId: string;
+  executionId: string;
+  reason?: string;
+}
+
+export interface ResumeExecutionRequest {
+  projectId: string;
+  executionId: string;
+}
+
+export interface RetryExecutionRequest {
+  projectId: string;
+  executionId: string;
+  clientRequestId?: string;
+  nodeInputs?: Record<string, Record<string, unknown>>;
+}
+
+export interface ExecutionStateResponse {
+  executionId: string;
+  state: string;
 }
 
 export interface ListExecutionsRequest {
@@ -83,10 +140,15 @@ export interface ExecutionInfo {
   id: string;
   workflowId: string;
   workflowName?: string;
+  workflowVersion?: string;
   projectId: string;
   clientRequestId: string;
   state: string;
   error?: string;
+  retryOf?: string;
+  triggerType?: string;
+  scheduleId?: string;
+  webhookId?: string;
 }
 
 export interface ListExecutionsResponse {